@@ -0,0 +1,48 @@
+//go:build !windows
+
+package iomux
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMuxAbstractNamespace(t *testing.T) {
+	for _, network := range networks {
+		t.Run(network, func(t *testing.T) {
+			mux := newMux(network, []Option[string]{WithAbstractNamespace[string]()})
+			t.Cleanup(func() {
+				mux.Close()
+			})
+			taga, err := mux.Tag("a")
+			if err != nil {
+				skipIfProtocolNotSupported(t, err)
+				assert.Nil(t, err)
+			}
+			tagb, err := mux.Tag("b")
+			assert.Nil(t, err)
+			if runtime.GOOS == "linux" {
+				assert.Empty(t, mux.dir)
+				assert.True(t, strings.HasPrefix(mux.recvaddr.Name, "@iomux-"))
+			} else {
+				assert.NotEmpty(t, mux.dir)
+			}
+
+			taga.WriteString("hello taga")
+			data, tag, err := mux.Read(context.Background())
+			assert.Nil(t, err)
+			assert.Equal(t, "a", tag)
+			assert.Equal(t, "hello taga", string(data))
+
+			tagb.WriteString("hello tagb")
+			data, tag, err = mux.Read(context.Background())
+			assert.Nil(t, err)
+			assert.Equal(t, "b", tag)
+			assert.Equal(t, "hello tagb", string(data))
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package iomux
+
+import (
+	"net"
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// mmsghdr is struct mmsghdr, which golang.org/x/sys/unix doesn't define.
+type mmsghdr struct {
+	hdr unix.Msghdr
+	len uint32
+}
+
+// recvBatch receives several datagrams or packets from a socket with a single recvmmsg syscall, returning them one at
+// a time, so high-frequency small writes don't each cost the reader a syscall.
+type recvBatch struct {
+	network string
+	bufs    [][]byte
+	names   []unix.RawSockaddrUnix
+	iovs    []unix.Iovec
+	hdrs    []mmsghdr
+	count   int
+	next    int
+}
+
+// newRecvBatch returns a batch of size buffers of bufsize bytes, or nil if batching is disabled or not supported by
+// network.
+func newRecvBatch(network string, size, bufsize int) *recvBatch {
+	if size <= 1 || (network != "unixgram" && network != "unixpacket") {
+		return nil
+	}
+	b := &recvBatch{
+		network: network,
+		bufs:    make([][]byte, size),
+		names:   make([]unix.RawSockaddrUnix, size),
+		iovs:    make([]unix.Iovec, size),
+		hdrs:    make([]mmsghdr, size),
+	}
+	slab := make([]byte, size*bufsize)
+	for i := range b.bufs {
+		b.bufs[i] = slab[i*bufsize : (i+1)*bufsize : (i+1)*bufsize]
+		b.iovs[i].Base = &b.bufs[i][0]
+		b.iovs[i].SetLen(bufsize)
+	}
+	return b
+}
+
+// pending reports whether messages from the last receive remain to be returned.
+func (b *recvBatch) pending() bool {
+	return b != nil && b.next < b.count
+}
+
+// discard drops any messages remaining from the last receive.
+func (b *recvBatch) discard() {
+	b.next = b.count
+}
+
+// pop returns the next message, the address of its sender if it has one, and whether it was truncated. Only valid
+// until the next receive.
+func (b *recvBatch) pop() ([]byte, net.Addr, bool) {
+	i := b.next
+	b.next++
+	h := &b.hdrs[i]
+	data := b.bufs[i][:h.len]
+	if len(data) == 0 && b.network == "unixpacket" {
+		// the sender has closed, so every message after this is also empty
+		b.discard()
+	}
+	return data, b.addr(i), h.hdr.Flags&unix.MSG_TRUNC != 0
+}
+
+// addr returns the sender address of message i, converted like the net package does for ReadMsgUnix.
+func (b *recvBatch) addr(i int) net.Addr {
+	n := int(b.hdrs[i].hdr.Namelen) - int(unsafe.Offsetof(b.names[i].Path))
+	if n <= 0 {
+		return nil
+	}
+	path := b.names[i].Path[:n]
+	name := make([]byte, 0, n)
+	for j, c := range path {
+		if c == 0 {
+			if j > 0 {
+				break
+			}
+			// an abstract address, shown with a leading @
+			c = '@'
+		}
+		name = append(name, byte(c))
+	}
+	return &net.UnixAddr{Name: string(name), Net: b.network}
+}
+
+// recvmmsg receives up to a full batch from fd without blocking, returning unix.EAGAIN if nothing is ready.
+func (b *recvBatch) recvmmsg(fd uintptr) error {
+	for i := range b.hdrs {
+		b.hdrs[i] = mmsghdr{}
+		b.hdrs[i].hdr.Name = (*byte)(unsafe.Pointer(&b.names[i]))
+		b.hdrs[i].hdr.Namelen = uint32(unsafe.Sizeof(b.names[i]))
+		b.hdrs[i].hdr.Iov = &b.iovs[i]
+		b.hdrs[i].hdr.SetIovlen(1)
+	}
+	n, _, errno := unix.Syscall6(unix.SYS_RECVMMSG, fd, uintptr(unsafe.Pointer(&b.hdrs[0])), uintptr(len(b.hdrs)),
+		unix.MSG_DONTWAIT, 0, 0)
+	if errno != 0 {
+		return errno
+	}
+	b.count, b.next = int(n), 0
+	return nil
+}
+
+// read returns the next message from conn, receiving another batch once the last has been used up, waiting for data
+// until the read deadline of conn.
+func (b *recvBatch) read(conn *net.UnixConn) ([]byte, net.Addr, bool, error) {
+	if !b.pending() {
+		raw, err := conn.SyscallConn()
+		if err != nil {
+			return nil, nil, false, err
+		}
+		var recvErr error
+		err = raw.Read(func(fd uintptr) bool {
+			recvErr = b.recvmmsg(fd)
+			return recvErr != unix.EAGAIN
+		})
+		if err != nil {
+			return nil, nil, false, err
+		}
+		if recvErr != nil {
+			return nil, nil, false, &net.OpError{Op: "read", Net: b.network, Source: conn.LocalAddr(),
+				Err: os.NewSyscallError("recvmmsg", recvErr)}
+		}
+	}
+	data, addr, truncated := b.pop()
+	return data, addr, truncated, nil
+}
+
+// readReady receives from conn, which the poller reported ready, returning errWouldBlock if nothing was ready. The
+// read deadline of conn doesn't apply, see readConn.
+func (b *recvBatch) readReady(conn *net.UnixConn) ([]byte, bool, error) {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return nil, false, err
+	}
+	var recvErr error
+	err = raw.Control(func(fd uintptr) {
+		recvErr = b.recvmmsg(fd)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	if recvErr == unix.EAGAIN {
+		return nil, false, errWouldBlock
+	}
+	if recvErr != nil {
+		return nil, false, os.NewSyscallError("recvmmsg", recvErr)
+	}
+	data, _, truncated := b.pop()
+	return data, truncated, nil
+}
@@ -0,0 +1,31 @@
+//go:build !linux
+
+package iomux
+
+import "net"
+
+// recvBatch is only supported on Linux, where recvmmsg is available.
+type recvBatch struct{}
+
+func newRecvBatch(network string, size, bufsize int) *recvBatch {
+	return nil
+}
+
+func (b *recvBatch) pending() bool {
+	return false
+}
+
+func (b *recvBatch) discard() {
+}
+
+func (b *recvBatch) pop() ([]byte, net.Addr, bool) {
+	return nil, nil, false
+}
+
+func (b *recvBatch) read(conn *net.UnixConn) ([]byte, net.Addr, bool, error) {
+	return nil, nil, false, errWouldBlock
+}
+
+func (b *recvBatch) readReady(conn *net.UnixConn) ([]byte, bool, error) {
+	return nil, false, errWouldBlock
+}
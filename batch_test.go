@@ -0,0 +1,80 @@
+//go:build linux
+
+package iomux
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadBatch(t *testing.T) {
+	for _, network := range []string{"unixgram", "unixpacket"} {
+		for _, batch := range []int{1, 8} {
+			t.Run(fmt.Sprintf("%s/%d", network, batch), func(t *testing.T) {
+				mux := newMux(network, []Option[string]{WithReadBatch[string](batch)})
+				defer mux.Close()
+				a, err := mux.Tag("a")
+				assert.Nil(t, err)
+				b, err := mux.Tag("b")
+				assert.Nil(t, err)
+
+				var want strings.Builder
+				for i := 0; i < 100; i++ {
+					want.WriteString(fmt.Sprintf("line %d\n", i))
+				}
+				// write concurrently, as datagram sockets only queue a few datagrams before writes block
+				td, err := mux.ReadWhile(func() error {
+					for i := 0; i < 100; i++ {
+						line := fmt.Sprintf("line %d\n", i)
+						_, _ = a.Write([]byte(line))
+						_, _ = b.Write([]byte(line))
+					}
+					return nil
+				})
+				assert.Nil(t, err)
+				got := map[string]*strings.Builder{"a": {}, "b": {}}
+				for _, d := range td {
+					got[d.Tag].Write(d.Data)
+				}
+				assert.Equal(t, want.String(), got["a"].String())
+				assert.Equal(t, want.String(), got["b"].String())
+			})
+		}
+	}
+}
+
+func TestReadBatchSenderClosed(t *testing.T) {
+	var closed []string
+	mux := NewMuxUnixPacket[string](WithCloseHandler(func(tag string) {
+		closed = append(closed, tag)
+	}))
+	defer mux.Close()
+	a, err := mux.Tag("a")
+	assert.Nil(t, err)
+
+	td, err := mux.ReadWhile(func() error {
+		for i := 0; i < 20; i++ {
+			_, _ = a.Write([]byte("x"))
+		}
+		return a.Close()
+	})
+	assert.Nil(t, err)
+	assert.Len(t, td, 1)
+	assert.Equal(t, strings.Repeat("x", 20), string(td[0].Data))
+	assert.Equal(t, []string{"a"}, closed)
+}
+
+func BenchmarkMuxSmallWritesUnbatched(b *testing.B) {
+	mux := NewMux[string](WithReadBatch[string](1))
+	b.Cleanup(func() {
+		mux.Close()
+	})
+	taga, err := mux.Tag("a")
+	if err != nil {
+		b.Fatal(err)
+	}
+	benchmarkSmallWrites(b, taga, mux.Read)
+}
@@ -0,0 +1,156 @@
+package iomux
+
+import (
+	"context"
+	"io"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Broadcaster fans the data read from a Mux out to any number of consumers, each receiving all of it, for example to
+// write it to a file, serve it to clients and scan it for errors at the same time. Created by Mux.Broadcast.
+type Broadcaster[T comparable] struct {
+	read      func(context.Context) ([]byte, T, error)
+	mutex     sync.Mutex
+	consumers []*consumer[T]
+	ended     bool
+	keep      int
+	history   []*TaggedData[T]
+}
+
+type consumer[T comparable] struct {
+	ch   chan *TaggedData[T]
+	done chan struct{}
+	once sync.Once
+	// nowait unsubscribes the consumer when its buffer is full, rather than Run waiting for it
+	nowait bool
+}
+
+// Broadcast Create a Broadcaster for the data read from the Mux. Subscribe consumers then call Run, and don't call Read
+// while it runs.
+func (mux *Mux[T]) Broadcast() *Broadcaster[T] {
+	return &Broadcaster[T]{read: mux.Read}
+}
+
+// Subscribe Return a channel receiving every chunk read from now on, buffering up to buffer chunks. The chunks are
+// shared between consumers, so must not be modified. Run waits for every consumer to have room, so each must keep
+// receiving until the channel is closed, when Run returns, or call the returned function to unsubscribe. Consumers
+// that may stall should use SubscribeNoWait.
+func (b *Broadcaster[T]) Subscribe(buffer int) (<-chan *TaggedData[T], func()) {
+	_, ch, unsubscribe := b.SubscribeHistory(buffer)
+	return ch, unsubscribe
+}
+
+// SetHistory Keep the last n chunks read, to be returned by SubscribeHistory. Call before Run.
+func (b *Broadcaster[T]) SetHistory(n int) {
+	b.keep = n
+}
+
+// SubscribeHistory Subscribe like Subscribe, also returning the chunks kept by SetHistory, so a consumer that joins
+// late can catch up without missing any chunks in between.
+func (b *Broadcaster[T]) SubscribeHistory(buffer int) ([]*TaggedData[T], <-chan *TaggedData[T], func()) {
+	return b.subscribe(buffer, false)
+}
+
+// SubscribeNoWait Subscribe like SubscribeHistory, except that Run never waits for the consumer. Once its buffer is
+// full the consumer is unsubscribed and its channel closed, so a consumer that stalls, such as a network client that
+// stops reading, can't hold up reading for everyone else. Use Ended to tell this apart from the end of the broadcast.
+func (b *Broadcaster[T]) SubscribeNoWait(buffer int) ([]*TaggedData[T], <-chan *TaggedData[T], func()) {
+	return b.subscribe(buffer, true)
+}
+
+// Ended Report whether Run has returned, closing the channel of every consumer.
+func (b *Broadcaster[T]) Ended() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.ended
+}
+
+func (b *Broadcaster[T]) subscribe(buffer int, nowait bool) ([]*TaggedData[T], <-chan *TaggedData[T], func()) {
+	c := &consumer[T]{ch: make(chan *TaggedData[T], buffer), done: make(chan struct{}), nowait: nowait}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	history := slices.Clone(b.history)
+	if b.ended {
+		close(c.ch)
+		return history, c.ch, func() {}
+	}
+	b.consumers = append(b.consumers, c)
+	return history, c.ch, func() {
+		c.once.Do(func() {
+			close(c.done)
+		})
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+		b.consumers = slices.DeleteFunc(b.consumers, func(other *consumer[T]) bool {
+			return other == c
+		})
+	}
+}
+
+// Run Read until ctx is done or there is no more data, delivering each chunk to every consumer, then close their
+// channels. Returns the error that ended reading, if it wasn't io.EOF.
+func (b *Broadcaster[T]) Run(ctx context.Context) error {
+	defer func() {
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+		b.ended = true
+		for _, c := range b.consumers {
+			close(c.ch)
+		}
+		b.consumers = nil
+	}()
+	var seq uint64
+	for {
+		data, tag, err := b.read(ctx)
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		seq++
+		td := &TaggedData[T]{Data: data, Tag: tag, Seq: seq, Time: time.Now()}
+		b.mutex.Lock()
+		if b.keep > 0 {
+			if len(b.history) == b.keep {
+				b.history[0] = nil
+				b.history = b.history[1:]
+			}
+			b.history = append(b.history, td)
+		}
+		consumers := slices.Clone(b.consumers)
+		b.mutex.Unlock()
+		for _, c := range consumers {
+			if c.nowait {
+				select {
+				case c.ch <- td:
+				case <-c.done:
+				default:
+					b.drop(c)
+				}
+				continue
+			}
+			select {
+			case c.ch <- td:
+			case <-c.done:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	}
+}
+
+// drop unsubscribes c and closes its channel, unless it has already unsubscribed.
+func (b *Broadcaster[T]) drop(c *consumer[T]) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	n := len(b.consumers)
+	b.consumers = slices.DeleteFunc(b.consumers, func(other *consumer[T]) bool {
+		return other == c
+	})
+	if len(b.consumers) < n {
+		close(c.ch)
+	}
+}
@@ -0,0 +1,133 @@
+//go:build !windows
+
+package iomux
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMuxBroadcast(t *testing.T) {
+	mux := NewMuxUnix[string]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	taga, _ := mux.Tag("a")
+	tagb, _ := mux.Tag("b")
+
+	b := mux.Broadcast()
+	const consumers = 3
+	received := make([]map[string]string, consumers)
+	wg := sync.WaitGroup{}
+	for i := 0; i < consumers; i++ {
+		ch, _ := b.Subscribe(0)
+		received[i] = make(map[string]string)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for td := range ch {
+				received[i][td.Tag] += string(td.Data)
+			}
+		}()
+	}
+	unsubscribed, unsubscribe := b.Subscribe(0)
+	unsubscribe()
+
+	io.WriteString(taga, "hello taga")
+	io.WriteString(tagb, "hello tagb")
+	taga.Close()
+	tagb.Close()
+	assert.Nil(t, b.Run(context.Background()))
+	wg.Wait()
+	for i := 0; i < consumers; i++ {
+		assert.Equal(t, map[string]string{"a": "hello taga", "b": "hello tagb"}, received[i])
+	}
+	assert.Empty(t, unsubscribed)
+
+	ch, _ := b.Subscribe(0)
+	_, ok := <-ch
+	assert.False(t, ok)
+}
+
+func TestBroadcasterHistory(t *testing.T) {
+	mux := NewMuxUnixGram[string]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	taga, _ := mux.Tag("a")
+
+	b := mux.Broadcast()
+	b.SetHistory(2)
+	ch, _ := b.Subscribe(0)
+	var seen []string
+	done := make(chan struct{})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	go func() {
+		defer close(done)
+		for td := range ch {
+			seen = append(seen, string(td.Data))
+			if len(seen) == 4 {
+				cancel()
+			}
+			if len(seen) == 3 {
+				history, late, _ := b.SubscribeHistory(1)
+				var data []string
+				for _, td := range history {
+					data = append(data, string(td.Data))
+				}
+				assert.Equal(t, []string{"2", "3"}, data)
+				go func() {
+					for range late {
+					}
+				}()
+			}
+		}
+	}()
+	go func() {
+		for _, s := range []string{"1", "2", "3", "4"} {
+			io.WriteString(taga, s)
+			// keep the writes from being read as one
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	assert.Nil(t, b.Run(ctx))
+	<-done
+	assert.Equal(t, []string{"1", "2", "3", "4"}, seen)
+}
+
+func TestBroadcasterNoWait(t *testing.T) {
+	mux := NewMuxUnix[string]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	taga, _ := mux.Tag("a")
+
+	b := mux.Broadcast()
+	// never received from, so is dropped rather than holding up Run
+	_, stalled, unsubscribe := b.SubscribeNoWait(1)
+	defer unsubscribe()
+	go func() {
+		for i := 0; i < 3; i++ {
+			io.WriteString(taga, "data")
+			// keep the writes from being read as one
+			time.Sleep(time.Millisecond)
+		}
+		taga.Close()
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.Nil(t, b.Run(ctx))
+	assert.Nil(t, ctx.Err())
+	assert.True(t, b.Ended())
+	n := 0
+	for range stalled {
+		n++
+	}
+	assert.Equal(t, 1, n)
+}
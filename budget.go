@@ -0,0 +1,39 @@
+package iomux
+
+import (
+	"fmt"
+	"time"
+)
+
+// dropMarkers collects a marker per tag for the data discarded by BufferDropOldestMarker, to be put in front of the
+// data that was kept once reading is done.
+type dropMarkers[T comparable] struct {
+	markers []*TaggedData[T]
+	index   map[T]*TaggedData[T]
+}
+
+// drop returns a function counting discarded data in the marker for its tag before reporting it to dropped, if not nil.
+func (m *dropMarkers[T]) drop(dropped func(tag T, n int)) func(tag T, n int) {
+	return func(tag T, n int) {
+		marker, ok := m.index[tag]
+		if !ok {
+			marker = &TaggedData[T]{Tag: tag}
+			m.index[tag] = marker
+			m.markers = append(m.markers, marker)
+		}
+		marker.Truncated += n
+		marker.Time = time.Now()
+		marker.Data = fmt.Appendf(nil, "[... %d bytes dropped ...]\n", marker.Truncated)
+		if dropped != nil {
+			dropped(tag, n)
+		}
+	}
+}
+
+// prepend puts the markers in front of td, in the order their tags first had data discarded.
+func (m *dropMarkers[T]) prepend(td []*TaggedData[T]) []*TaggedData[T] {
+	if len(m.markers) == 0 {
+		return td
+	}
+	return append(m.markers, td...)
+}
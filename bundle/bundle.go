@@ -0,0 +1,125 @@
+// Package bundle writes the output and record of a command run with iomux.Run to a single archive, for CI jobs keeping
+// one artifact with everything inside.
+package bundle
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/netflix/go-iomux"
+)
+
+// bundleFile is a file of a bundle written by Write.
+type bundleFile struct {
+	name string
+	data []byte
+}
+
+// Write Write the run r to path as a single archive holding the raw output of each stream as stdout.log, stderr.log and
+// so on, the output in the order it was written as capture.jsonl, see iomux.JSONLEncoder, and the record of the run
+// without its capture as run.json, see iomux.RunRecord. Paths ending in .zip are written as zip, .tar.gz or .tgz as tar
+// compressed with gzip, and anything else as tar. The file is removed if writing fails.
+func Write(path string, r iomux.Result) (err error) {
+	files, err := bundleFiles(r)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(path)
+		}
+	}()
+	modTime := r.Start.Add(r.Duration)
+	switch {
+	case strings.HasSuffix(path, ".zip"):
+		return writeZip(f, files, modTime)
+	case strings.HasSuffix(path, ".tar.gz"), strings.HasSuffix(path, ".tgz"):
+		gz := gzip.NewWriter(f)
+		if err := writeTar(gz, files, modTime); err != nil {
+			return err
+		}
+		return gz.Close()
+	default:
+		return writeTar(f, files, modTime)
+	}
+}
+
+// bundleFiles returns the files of the bundle of the run r, with stdout and stderr always included.
+func bundleFiles(r iomux.Result) ([]bundleFile, error) {
+	streams := []iomux.StdStream{iomux.Stdout, iomux.Stderr}
+	output := map[iomux.StdStream][]byte{iomux.Stdout: {}, iomux.Stderr: {}}
+	for _, td := range r.Data {
+		if _, ok := output[td.Tag]; !ok {
+			streams = append(streams, td.Tag)
+		}
+		output[td.Tag] = append(output[td.Tag], td.Data...)
+	}
+	var files []bundleFile
+	for _, stream := range streams {
+		files = append(files, bundleFile{stream.String() + ".log", output[stream]})
+	}
+	var capture bytes.Buffer
+	if err := iomux.EncodeJSONL(&capture, r.Data); err != nil {
+		return nil, err
+	}
+	files = append(files, bundleFile{"capture.jsonl", capture.Bytes()})
+	record := r.Record()
+	record.Capture = nil
+	run, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, bundleFile{"run.json", append(run, '\n')})
+	return files, nil
+}
+
+func writeTar(w io.Writer, files []bundleFile, modTime time.Time) error {
+	tw := tar.NewWriter(w)
+	for _, file := range files {
+		header := &tar.Header{
+			Name:    file.name,
+			Mode:    0o644,
+			Size:    int64(len(file.data)),
+			ModTime: modTime,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(file.data); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+func writeZip(w io.Writer, files []bundleFile, modTime time.Time) error {
+	zw := zip.NewWriter(w)
+	for _, file := range files {
+		fw, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: modTime,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(file.data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
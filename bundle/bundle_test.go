@@ -0,0 +1,105 @@
+package bundle
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/netflix/go-iomux"
+	"github.com/stretchr/testify/assert"
+)
+
+var bundleResult = iomux.Result{
+	Data: []*iomux.TaggedData[iomux.StdStream]{
+		{Tag: iomux.Stdout, Seq: 1, Data: []byte("out1\n")},
+		{Tag: iomux.ExtraFd(3), Seq: 2, Data: []byte("{}\n")},
+		{Tag: iomux.Stdout, Seq: 3, Data: []byte("out2\n")},
+	},
+	ExitCode: 0,
+	Duration: time.Second,
+	Start:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	Args:     []string{"make", "test"},
+}
+
+var bundleNames = []string{"stdout.log", "stderr.log", "fd3.log", "capture.jsonl", "run.json"}
+
+func checkBundle(t *testing.T, files map[string][]byte) {
+	assert.Equal(t, "out1\nout2\n", string(files["stdout.log"]))
+	assert.Equal(t, "", string(files["stderr.log"]))
+	assert.Equal(t, "{}\n", string(files["fd3.log"]))
+
+	dec := iomux.NewJSONLDecoder[iomux.StdStream](bytes.NewReader(files["capture.jsonl"]))
+	for _, want := range bundleResult.Data {
+		td, err := dec.Decode()
+		assert.Nil(t, err)
+		assert.Equal(t, want.Tag, td.Tag)
+		assert.Equal(t, want.Data, td.Data)
+	}
+
+	var record iomux.RunRecord
+	assert.Nil(t, json.Unmarshal(files["run.json"], &record))
+	assert.Equal(t, []string{"make", "test"}, record.Args)
+	assert.Equal(t, bundleResult.Start.Add(time.Second), record.End)
+	assert.Empty(t, record.Capture)
+}
+
+func TestWriteZip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.zip")
+	assert.Nil(t, Write(path, bundleResult))
+	zr, err := zip.OpenReader(path)
+	assert.Nil(t, err)
+	defer zr.Close()
+	files := make(map[string][]byte)
+	var names []string
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		assert.Nil(t, err)
+		files[f.Name], _ = io.ReadAll(rc)
+		rc.Close()
+		names = append(names, f.Name)
+	}
+	assert.Equal(t, bundleNames, names)
+	checkBundle(t, files)
+}
+
+func TestWriteTar(t *testing.T) {
+	for _, name := range []string{"run.tar", "run.tar.gz", "run.tgz"} {
+		path := filepath.Join(t.TempDir(), name)
+		assert.Nil(t, Write(path, bundleResult))
+		f, err := os.Open(path)
+		assert.Nil(t, err)
+		var r io.Reader = f
+		if name != "run.tar" {
+			r, err = gzip.NewReader(f)
+			assert.Nil(t, err)
+		}
+		tr := tar.NewReader(r)
+		files := make(map[string][]byte)
+		var names []string
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			assert.Nil(t, err)
+			assert.True(t, bundleResult.Start.Add(time.Second).Equal(header.ModTime))
+			files[header.Name], _ = io.ReadAll(tr)
+			names = append(names, header.Name)
+		}
+		f.Close()
+		assert.Equal(t, bundleNames, names)
+		checkBundle(t, files)
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "run.zip")
+	assert.NotNil(t, Write(path, bundleResult))
+}
@@ -0,0 +1,25 @@
+package iomux
+
+import "context"
+
+// ByTag Concatenate the data of td by tag, for callers that only care about the final contents of each tag and not
+// how they were interleaved.
+func ByTag[T comparable](td []*TaggedData[T]) map[T][]byte {
+	result := make(map[T][]byte)
+	for _, d := range td {
+		result[d.Tag] = append(result[d.Tag], d.Data...)
+	}
+	return result
+}
+
+// ReadAllByTag Read until ctx is done like ReadUntil, returning the data of each tag concatenated, see ByTag.
+func (mux *Mux[T]) ReadAllByTag(ctx context.Context) (map[T][]byte, error) {
+	td, err := mux.ReadUntil(ctx)
+	return ByTag(td), err
+}
+
+// ReadWhileByTag Read until waitFn returns like ReadWhile, returning the data of each tag concatenated, see ByTag.
+func (mux *Mux[T]) ReadWhileByTag(waitFn func() error) (map[T][]byte, error) {
+	td, err := mux.ReadWhile(waitFn)
+	return ByTag(td), err
+}
@@ -0,0 +1,45 @@
+//go:build !windows
+
+package iomux
+
+import (
+	"context"
+	"io"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMuxReadWhileByTag(t *testing.T) {
+	mux := NewMux[StdStream]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	cmd := exec.Command("sh", "-c", "echo out1 && echo err1 1>&2 && echo out2")
+	cmd.Stdout, _ = mux.Tag(Stdout)
+	cmd.Stderr, _ = mux.Tag(Stderr)
+	got, err := mux.ReadWhileByTag(cmd.Run)
+	assert.Nil(t, err)
+	assert.Equal(t, map[StdStream][]byte{Stdout: []byte("out1\nout2\n"), Stderr: []byte("err1\n")}, got)
+}
+
+func TestMuxReadAllByTag(t *testing.T) {
+	mux := NewMux[string]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	taga, _ := mux.Tag("a")
+	tagb, _ := mux.Tag("b")
+	io.WriteString(taga, "hello ")
+	io.WriteString(tagb, "other")
+	io.WriteString(taga, "world")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	got, err := mux.ReadAllByTag(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "hello world", string(got["a"]))
+	assert.Equal(t, "other", string(got["b"]))
+}
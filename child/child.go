@@ -0,0 +1,68 @@
+// Package child lets a process started by a parent capturing its output with a Mux obtain writers for tags of its own,
+// for structured output beyond stdout and stderr. The parent sets the address to connect to in the environment with
+// Mux.ChildTags.
+package child
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/netflix/go-iomux"
+)
+
+// ErrNoParent is returned when the process wasn't started with Mux.ChildTags.
+var ErrNoParent = errors.New("iomux: no parent mux, " + iomux.ChildAddrEnv + " is not set")
+
+// Client A connection to the Mux of the parent process.
+type Client struct {
+	mutex sync.Mutex
+	conn  *net.UnixConn
+}
+
+// Available Whether the process was started with Mux.ChildTags, so Dial can be expected to succeed.
+func Available() bool {
+	return os.Getenv(iomux.ChildAddrEnv) != ""
+}
+
+// Dial Connect to the Mux of the parent process, at the address set by Mux.ChildTags.
+func Dial() (*Client, error) {
+	addr := os.Getenv(iomux.ChildAddrEnv)
+	if addr == "" {
+		return nil, ErrNoParent
+	}
+	conn, err := net.DialUnix("unix", nil, &net.UnixAddr{Name: addr, Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn}, nil
+}
+
+// Tag Obtain a writer for the tag named name from the parent. Data written to it is read by the parent's Mux tagged
+// with the tag name maps to. name must not contain a newline. The writer should be closed once writing is finished.
+func (c *Client) Tag(name string) (*os.File, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if _, err := io.WriteString(c.conn, name+"\n"); err != nil {
+		return nil, err
+	}
+	w, _, err := iomux.ReceiveWriter(c.conn)
+	return w, err
+}
+
+// Close closes the connection to the parent. Writers already obtained remain open.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Tag Obtain a writer for the tag named name from the parent, using a connection just for this call, see Client.Tag.
+func Tag(name string) (*os.File, error) {
+	c, err := Dial()
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+	return c.Tag(name)
+}
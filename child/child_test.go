@@ -0,0 +1,92 @@
+//go:build !windows
+
+package child
+
+import (
+	"errors"
+	"io"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/netflix/go-iomux"
+	"github.com/stretchr/testify/assert"
+)
+
+// childEnv starts listening for children of mux, and sets the environment as the parent would for a child.
+func childEnv[T comparable](t *testing.T, mux *iomux.Mux[T], tagOf func(string) (T, error)) {
+	cmd := exec.Command("true")
+	closer, err := mux.ChildTags(cmd, tagOf)
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		closer.Close()
+	})
+	for _, env := range cmd.Env {
+		if addr, ok := strings.CutPrefix(env, iomux.ChildAddrEnv+"="); ok {
+			t.Setenv(iomux.ChildAddrEnv, addr)
+		}
+	}
+}
+
+func TestTag(t *testing.T) {
+	mux := iomux.NewMux[string]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	childEnv(t, mux, nil)
+	assert.True(t, Available())
+	// the parent tags at least stdout before reading
+	stdout, err := mux.Tag("stdout")
+	assert.Nil(t, err)
+
+	td, err := mux.ReadWhile(func() error {
+		defer stdout.Close()
+		c, err := Dial()
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+		for _, name := range []string{"metrics", "events"} {
+			w, err := c.Tag(name)
+			if err != nil {
+				return err
+			}
+			io.WriteString(w, name+" data")
+			w.Close()
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Len(t, td, 2)
+	assert.Equal(t, "metrics", td[0].Tag)
+	assert.Equal(t, "metrics data", string(td[0].Data))
+	assert.Equal(t, "events", td[1].Tag)
+	assert.Equal(t, "events data", string(td[1].Data))
+}
+
+func TestTagRejected(t *testing.T) {
+	type stream int
+	mux := iomux.NewMux[stream]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	childEnv(t, mux, func(name string) (stream, error) {
+		if name == "metrics" {
+			return 3, nil
+		}
+		return 0, errors.New("unknown stream")
+	})
+
+	_, err := Tag("bogus")
+	assert.EqualError(t, err, "iomux: error: unknown stream")
+	w, err := Tag("metrics")
+	assert.Nil(t, err)
+	w.Close()
+}
+
+func TestNoParent(t *testing.T) {
+	t.Setenv(iomux.ChildAddrEnv, "")
+	assert.False(t, Available())
+	_, err := Dial()
+	assert.ErrorIs(t, err, ErrNoParent)
+}
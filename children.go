@@ -0,0 +1,130 @@
+package iomux
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ChildAddrEnv is the environment variable holding the address a cooperating child process connects to for tagged
+// writers, see ChildTags and the child package.
+const ChildAddrEnv = "IOMUX_ADDR"
+
+// ServeChildren Accept cooperating child processes connecting to l until l is closed, sending each a writer for every
+// tag it asks for by name, see the child package. Names are mapped to tags with tagOf, or used as tags when tagOf is nil,
+// in which case T must be string. An error from tagOf is returned to the child rather than a writer. Once l is closed,
+// the connections of children are closed too, and ServeChildren returns when they are no longer being served.
+func (mux *Mux[T]) ServeChildren(l *net.UnixListener, tagOf func(name string) (T, error)) error {
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	conns := make(map[*net.UnixConn]struct{})
+	defer func() {
+		mutex.Lock()
+		for conn := range conns {
+			conn.Close()
+		}
+		mutex.Unlock()
+		wg.Wait()
+	}()
+	for {
+		conn, err := l.AcceptUnix()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		mutex.Lock()
+		conns[conn] = struct{}{}
+		mutex.Unlock()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mux.serveChild(conn, tagOf)
+			mutex.Lock()
+			delete(conns, conn)
+			mutex.Unlock()
+		}()
+	}
+}
+
+// serveChild replies to each tag name requested by a child process on conn with a writer, or an error.
+func (mux *Mux[T]) serveChild(conn *net.UnixConn, tagOf func(name string) (T, error)) {
+	defer conn.Close()
+	rd := bufio.NewReader(conn)
+	for {
+		line, err := rd.ReadString('\n')
+		if err != nil {
+			return
+		}
+		name := strings.TrimSuffix(line, "\n")
+		tag, err := childTag(name, tagOf)
+		if err == nil {
+			err = mux.SendTag(conn, tag)
+		}
+		if err != nil {
+			if _, err := fmt.Fprintf(conn, "error: %v\n", err); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// childTag maps name to a tag with tagOf, or converts it when tagOf is nil.
+func childTag[T comparable](name string, tagOf func(name string) (T, error)) (T, error) {
+	if tagOf != nil {
+		return tagOf(name)
+	}
+	tag, ok := any(name).(T)
+	if !ok {
+		return tag, fmt.Errorf("no tag for %q", name)
+	}
+	return tag, nil
+}
+
+// ChildTags Listen for cmd to ask for tagged writers, see ServeChildren, setting ChildAddrEnv in its environment to the
+// address to connect to. Returns a closer to stop listening, once cmd has exited. Tags created for cmd this way are
+// only read if the Mux already has a tag when reading begins, such as for the stdout of cmd.
+func (mux *Mux[T]) ChildTags(cmd *exec.Cmd, tagOf func(name string) (T, error)) (io.Closer, error) {
+	dir, err := os.MkdirTemp(mux.sockDir, "mux")
+	if err != nil {
+		return nil, err
+	}
+	addr := filepath.Join(dir, "child.sock")
+	l, err := net.ListenUnix("unix", &net.UnixAddr{Name: addr, Net: "unix"})
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, ChildAddrEnv+"="+addr)
+	c := &childListener{l: l, dir: dir, served: make(chan struct{})}
+	go func() {
+		defer close(c.served)
+		mux.ServeChildren(l, tagOf)
+	}()
+	return c, nil
+}
+
+// childListener closes the listener of ChildTags and removes its directory, once ServeChildren has returned.
+type childListener struct {
+	l      *net.UnixListener
+	dir    string
+	served chan struct{}
+}
+
+func (c *childListener) Close() error {
+	err := c.l.Close()
+	<-c.served
+	os.RemoveAll(c.dir)
+	return err
+}
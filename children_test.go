@@ -0,0 +1,68 @@
+//go:build !windows
+
+package iomux
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChildTag(t *testing.T) {
+	tag, err := childTag[string]("metrics", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "metrics", tag)
+	_, err = childTag[int]("metrics", nil)
+	assert.EqualError(t, err, `no tag for "metrics"`)
+}
+
+func TestServeChildrenError(t *testing.T) {
+	mux := NewMux[int]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	parent, child := connPair(t)
+	go mux.serveChild(parent, nil)
+	_, err := child.Write([]byte("metrics\n"))
+	assert.Nil(t, err)
+	_, _, err = ReceiveWriter(child)
+	assert.EqualError(t, err, `iomux: error: no tag for "metrics"`)
+}
+
+func TestServeChildrenClose(t *testing.T) {
+	mux := NewMux[string]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	addr := &net.UnixAddr{Name: filepath.Join(t.TempDir(), "child.sock"), Net: "unix"}
+	l, err := net.ListenUnix("unix", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := make(chan error)
+	go func() {
+		served <- mux.ServeChildren(l, nil)
+	}()
+	conn, err := net.DialUnix("unix", nil, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte("a\n"))
+	assert.Nil(t, err)
+	w, _, err := ReceiveWriter(conn)
+	assert.Nil(t, err)
+	w.Close()
+
+	// the child is still connected, but closing the listener stops serving it
+	l.Close()
+	select {
+	case err := <-served:
+		assert.Nil(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("ServeChildren didn't return")
+	}
+}
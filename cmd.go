@@ -0,0 +1,232 @@
+package iomux
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// runWaitDelay is how long Run waits for a command to exit after SIGTERM before killing it, if cmd.WaitDelay is unset.
+const runWaitDelay = 5 * time.Second
+
+// StdStream tags the standard output streams of a command, and any extra file descriptors it writes to, see ExtraFd.
+type StdStream int
+
+const (
+	Stdout StdStream = iota
+	Stderr
+)
+
+// ExtraFd The tag for file descriptor fd of a command, where fd is 3 or more, for use with ExtraFile.
+func ExtraFd(fd int) StdStream {
+	return StdStream(fd - 1)
+}
+
+// Fd The file descriptor of the stream in the command.
+func (s StdStream) Fd() int {
+	return int(s) + 1
+}
+
+func (s StdStream) String() string {
+	switch {
+	case s == Stdout:
+		return "stdout"
+	case s == Stderr:
+		return "stderr"
+	case s > Stderr:
+		return fmt.Sprintf("fd%d", s.Fd())
+	default:
+		return "unknown"
+	}
+}
+
+// ExtraFile Create a file tagged tag and append it to cmd.ExtraFiles, returning the file and the file descriptor the
+// command sees it as, so output a command writes to a dedicated descriptor can be captured alongside stdout and stderr.
+func (mux *Mux[T]) ExtraFile(cmd *exec.Cmd, tag T) (*os.File, int, error) {
+	file, err := mux.Tag(tag)
+	if err != nil {
+		return nil, 0, err
+	}
+	cmd.ExtraFiles = append(cmd.ExtraFiles, file)
+	return file, len(cmd.ExtraFiles) + 2, nil
+}
+
+// WrapCmd Run cmd with its stdout and stderr tagged Stdout and Stderr on a new Mux created with opts, returning the
+// output in the order it was written, and the error returned by cmd.Run. The Mux is closed before returning.
+func WrapCmd(cmd *exec.Cmd, opts ...Option[StdStream]) ([]*TaggedData[StdStream], error) {
+	mux := NewMux[StdStream](opts...)
+	defer mux.Close()
+	stdout, err := mux.Tag(Stdout)
+	if err != nil {
+		return nil, err
+	}
+	defer stdout.Close()
+	stderr, err := mux.Tag(Stderr)
+	if err != nil {
+		return nil, err
+	}
+	defer stderr.Close()
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return mux.ReadWhile(func() error {
+		return cmd.Run()
+	})
+}
+
+// CombinedOutputOrdered Run cmd and return its combined stdout and stderr like cmd.CombinedOutput, but keeping which
+// stream each write came from and the order they were written in.
+func CombinedOutputOrdered(cmd *exec.Cmd) ([]*TaggedData[StdStream], error) {
+	if cmd.Stdout != nil {
+		return nil, errors.New("exec: Stdout already set")
+	}
+	if cmd.Stderr != nil {
+		return nil, errors.New("exec: Stderr already set")
+	}
+	return WrapCmd(cmd)
+}
+
+// Result The output of a command run with Run.
+type Result struct {
+	// Data the command's stdout and stderr, in the order it was written
+	Data []*TaggedData[StdStream]
+	// ExitCode the command's exit code, or -1 if it didn't start or was terminated by a signal
+	ExitCode int
+	// Duration how long the command ran for
+	Duration time.Duration
+	// Start when the command was started
+	Start time.Time
+	// Args the command line, from cmd.Args
+	Args []string
+	// Dir the working directory of the command, or empty for the working directory of this process
+	Dir string
+	// Env the variables of cmd.Env that aren't inherited unchanged from this process, so the record of a run holds the
+	// environment it was given without everything else this process happens to have set
+	Env []string
+}
+
+// Run Run cmd like WrapCmd, returning the output along with its exit code and how long it ran. When ctx is done the
+// command is sent SIGTERM, then killed if it hasn't exited after cmd.WaitDelay, or 5 seconds if WaitDelay is unset. If
+// the command exits unsuccessfully after ctx is done, the error is ctx.Err(). If cmd.SysProcAttr puts the command in a
+// process group of its own, the whole group is terminated. See RunGroup to stop the command on Ctrl-C.
+func Run(ctx context.Context, cmd *exec.Cmd, opts ...Option[StdStream]) (Result, error) {
+	return run(ctx, cmd, false, opts)
+}
+
+// RunGroup Run cmd like Run, in its own process group, forwarding SIGINT and SIGTERM received while it runs to the
+// group rather than ending this process, so a Ctrl-C stops the command and RunGroup still returns all of its output.
+// A process group or session already set up by cmd.SysProcAttr is kept. Commands in a group of their own are stopped
+// by the terminal if they read from it, so cmd.Stdin shouldn't be the terminal. On Windows the console delivers
+// Ctrl-C to the command itself, and RunGroup only keeps this process running. RunGroup doesn't exit afterwards,
+// callers that should stop on Ctrl-C can check for the command having been interrupted.
+func RunGroup(ctx context.Context, cmd *exec.Cmd, opts ...Option[StdStream]) (Result, error) {
+	return run(ctx, cmd, true, opts)
+}
+
+func run(ctx context.Context, cmd *exec.Cmd, group bool, opts []Option[StdStream]) (Result, error) {
+	result := Result{ExitCode: -1}
+	mux := NewMux[StdStream](opts...)
+	defer mux.Close()
+	stdout, err := mux.Tag(Stdout)
+	if err != nil {
+		return result, err
+	}
+	defer stdout.Close()
+	stderr, err := mux.Tag(Stderr)
+	if err != nil {
+		return result, err
+	}
+	defer stderr.Close()
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	result.Args = cmd.Args
+	result.Dir = cmd.Dir
+	result.Env = envChanges(cmd.Env)
+	start := time.Now()
+	result.Start = start
+	result.Data, err = mux.ReadWhile(func() error {
+		if !group {
+			if err := cmd.Start(); err != nil {
+				return err
+			}
+			return Wait(ctx, cmd)
+		}
+		stop, err := startGroup(cmd)
+		if err != nil {
+			return err
+		}
+		defer stop()
+		return Wait(ctx, cmd)
+	})
+	result.Duration = time.Since(start)
+	if cmd.ProcessState != nil {
+		result.ExitCode = cmd.ProcessState.ExitCode()
+	}
+	return result, err
+}
+
+// RunPty Run cmd like Run, but with its stdout and stderr on a pseudo-terminal, so programs that check for a terminal
+// keep their colors and progress output. The terminal merges the two streams, so all output is tagged Stdout, and has
+// the terminal's line endings. Uses a ConPTY on Windows, elsewhere pseudo-terminals are supported as described by
+// TagPty. Like Run, Ctrl-C isn't forwarded to the command.
+func RunPty(ctx context.Context, cmd *exec.Cmd, opts ...Option[StdStream]) (Result, error) {
+	result := Result{ExitCode: -1}
+	mux := NewMux[StdStream](opts...)
+	defer mux.Close()
+	result.Args = cmd.Args
+	result.Dir = cmd.Dir
+	result.Env = envChanges(cmd.Env)
+	start := time.Now()
+	result.Start = start
+	closePty, err := startPty(mux, Stdout, cmd)
+	if err != nil {
+		return result, err
+	}
+	result.Data, err = mux.ReadWhile(func() error {
+		defer closePty()
+		return Wait(ctx, cmd)
+	})
+	result.Duration = time.Since(start)
+	if cmd.ProcessState != nil {
+		result.ExitCode = cmd.ProcessState.ExitCode()
+	}
+	return result, err
+}
+
+// Wait Wait for cmd to exit after it has been started, terminating it, or the process group it leads, when ctx is done
+// as described by Run. If the command exits unsuccessfully after ctx is done, the error is ctx.Err().
+func Wait(ctx context.Context, cmd *exec.Cmd) error {
+	exited := make(chan struct{})
+	defer close(exited)
+	go func() {
+		select {
+		case <-exited:
+			return
+		case <-ctx.Done():
+		}
+		// Signal isn't implemented on Windows, so kill straight away
+		if err := signalCmd(cmd, syscall.SIGTERM); err != nil {
+			killCmd(cmd)
+			return
+		}
+		delay := cmd.WaitDelay
+		if delay == 0 {
+			delay = runWaitDelay
+		}
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-exited:
+		case <-timer.C:
+			killCmd(cmd)
+		}
+	}()
+	err := cmd.Wait()
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
@@ -0,0 +1,65 @@
+// Command iomux works with recordings made by iomux.Recorder, and serves the output of commands live.
+//
+// Usage:
+//
+//	iomux replay [-fast] [-prefix] [-color] recording
+//	iomux merge [-o output] [-label] recording...
+//	iomux serve [-addr address] [-history n] command [args...]
+//
+// replay writes a recording to stdout, reproducing the original time between chunks unless -fast is set. merge
+// combines the recordings of several processes into one, ordered by the time each chunk was recorded. Recordings may
+// be compressed with gzip, and "-" reads stdin. serve runs a command, serving a web page showing its output live, see
+// the ui package, until interrupted.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+const usage = `usage:
+  iomux replay [-fast] [-prefix] [-color] recording
+  iomux merge [-o output] [-label] recording...
+  iomux serve [-addr address] [-history n] command [args...]
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs the command with args, returning the exit code.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		io.WriteString(stderr, usage)
+		return 2
+	}
+	var err error
+	switch args[0] {
+	case "replay":
+		err = replay(args[1:], stdin, stdout, stderr)
+	case "merge":
+		err = merge(args[1:], stdin, stdout, stderr)
+	case "serve":
+		err = serve(args[1:], stdin, stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		io.WriteString(stdout, usage)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "iomux: unknown command %q\n%s", args[0], usage)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "iomux %s: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+// open opens the recording at path, or stdin for "-".
+func open(path string, stdin io.Reader) (io.ReadCloser, error) {
+	if path == "-" {
+		return io.NopCloser(stdin), nil
+	}
+	return os.Open(path)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/netflix/go-iomux"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeRecording writes a recording of td to name in dir, compressed when gzip is set.
+func writeRecording(t *testing.T, dir, name string, gzip bool, td ...*iomux.TaggedData[string]) string {
+	var buf bytes.Buffer
+	rec := iomux.NewRecorder[string](&buf)
+	if gzip {
+		rec = iomux.NewGzipRecorder[string](&buf)
+	}
+	for _, d := range td {
+		assert.Nil(t, rec.Write(d))
+	}
+	assert.Nil(t, rec.Close())
+	path := filepath.Join(dir, name)
+	assert.Nil(t, os.WriteFile(path, buf.Bytes(), 0o600))
+	return path
+}
+
+func TestMergeReplay(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	web := writeRecording(t, dir, "web.jsonl", false,
+		&iomux.TaggedData[string]{Seq: 1, Time: start, Tag: "stdout", Data: []byte("listening\n")},
+		&iomux.TaggedData[string]{Seq: 2, Time: start.Add(3 * time.Millisecond), Tag: "stderr", Data: []byte("slow request\n")})
+	db := writeRecording(t, dir, "db.jsonl.gz", true,
+		&iomux.TaggedData[string]{Seq: 1, Time: start.Add(time.Millisecond), Tag: "stdout", Data: []byte("ready\n")})
+	merged := filepath.Join(dir, "merged.jsonl")
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 0, run([]string{"merge", "-label", "-o", merged, web, db}, nil, &stdout, &stderr), stderr.String())
+
+	recording, err := os.ReadFile(merged)
+	assert.Nil(t, err)
+	replayer := iomux.NewReplayer[string](bytes.NewReader(recording), false)
+	var got []string
+	for tag, data := range replayer.All(context.Background()) {
+		got = append(got, tag+"="+string(data))
+	}
+	assert.Equal(t, []string{"web:stdout=listening\n", "db:stdout=ready\n", "web:stderr=slow request\n"}, got)
+
+	stdout.Reset()
+	assert.Equal(t, 0, run([]string{"replay", "-prefix", merged}, nil, &stdout, &stderr), stderr.String())
+	assert.Equal(t, "[web:stdout] listening\n[db:stdout] ready\n[web:stderr] slow request\n", stdout.String())
+}
+
+func TestReplayStdin(t *testing.T) {
+	var recording bytes.Buffer
+	rec := iomux.NewRecorder[int](&recording)
+	assert.Nil(t, rec.Record(0, []byte("out ")))
+	assert.Nil(t, rec.Record(1, []byte("err\n")))
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 0, run([]string{"replay", "-fast", "-"}, &recording, &stdout, &stderr), stderr.String())
+	assert.Equal(t, "out err\n", stdout.String())
+}
+
+func TestUsage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 2, run(nil, nil, &stdout, &stderr))
+	assert.True(t, strings.HasPrefix(stderr.String(), "usage:"))
+	stderr.Reset()
+	assert.Equal(t, 2, run([]string{"bogus"}, nil, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), `unknown command "bogus"`)
+	stderr.Reset()
+	assert.Equal(t, 1, run([]string{"merge"}, nil, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "expected recordings to merge")
+	stderr.Reset()
+	assert.Equal(t, 1, run([]string{"replay", "missing.jsonl"}, nil, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "missing.jsonl")
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/netflix/go-iomux"
+)
+
+// source is a recording being merged, with its next chunk.
+type source struct {
+	name string
+	dec  *iomux.JSONLDecoder[any]
+	next *iomux.TaggedData[any]
+}
+
+// merge combines recordings into one ordered by time.
+func merge(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("merge", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	output := flags.String("o", "-", "write the merged recording to `file` rather than stdout")
+	label := flags.Bool("label", false, "label tags with the name of their recording, as name:tag")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return errors.New("expected recordings to merge")
+	}
+	var sources []*source
+	for _, path := range flags.Args() {
+		rd, err := open(path, stdin)
+		if err != nil {
+			return err
+		}
+		defer rd.Close()
+		name := strings.TrimSuffix(filepath.Base(path), ".gz")
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+		sources = append(sources, &source{name: name, dec: iomux.NewJSONLDecoder[any](iomux.Decompress(rd))})
+	}
+	w := stdout
+	if *output != "-" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	return mergeSources(iomux.NewRecorder[any](w), sources, *label)
+}
+
+// mergeSources writes the chunks of sources to rec in time order, numbering them in that order. Chunks recorded at the
+// same time are taken from the sources in the order given.
+func mergeSources(rec *iomux.Recorder[any], sources []*source, label bool) error {
+	for _, s := range sources {
+		if err := s.advance(); err != nil {
+			return err
+		}
+	}
+	var seq uint64
+	for {
+		var first *source
+		for _, s := range sources {
+			if s.next != nil && (first == nil || s.next.Time.Before(first.next.Time)) {
+				first = s
+			}
+		}
+		if first == nil {
+			return nil
+		}
+		td := first.next
+		seq++
+		td.Seq = seq
+		if label {
+			td.Tag = fmt.Sprintf("%s:%v", first.name, td.Tag)
+		}
+		if err := rec.Write(td); err != nil {
+			return err
+		}
+		if err := first.advance(); err != nil {
+			return err
+		}
+	}
+}
+
+// advance decodes the next chunk of the recording, setting next to nil at its end.
+func (s *source) advance() error {
+	td, err := s.dec.Decode()
+	if err == io.EOF {
+		s.next = nil
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", s.name, err)
+	}
+	s.next = td
+	return nil
+}
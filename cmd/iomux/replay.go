@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/netflix/go-iomux"
+)
+
+// replay writes a recording to stdout.
+func replay(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("replay", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	fast := flags.Bool("fast", false, "replay as fast as possible rather than in real time")
+	prefix := flags.Bool("prefix", false, "prefix each line with its tag")
+	color := flags.Bool("color", false, "color each line by its tag")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("expected one recording")
+	}
+	rd, err := open(flags.Arg(0), stdin)
+	if err != nil {
+		return err
+	}
+	defer rd.Close()
+
+	// tags are whatever the recording holds, as decoded by encoding/json
+	var opts iomux.RenderOptions[any]
+	if *prefix {
+		opts.Prefix = func(tag any) string {
+			return fmt.Sprintf("[%v] ", tag)
+		}
+	}
+	if *color {
+		opts.Color = iomux.AssignColors[any]()
+	}
+	r := iomux.NewRenderer(stdout, opts)
+	replayer := iomux.NewReplayer[any](rd, !*fast)
+	for {
+		data, tag, err := replayer.Read(context.Background())
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if err := r.Write(&iomux.TaggedData[any]{Tag: tag, Data: data}); err != nil {
+			return err
+		}
+	}
+}
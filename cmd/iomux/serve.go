@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+
+	"github.com/netflix/go-iomux"
+	"github.com/netflix/go-iomux/ui"
+)
+
+// serve runs a command, serving a live view of its output until interrupted.
+func serve(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	addr := flags.String("addr", "localhost:8080", "listen on `address`")
+	history := flags.Int("history", 10000, "keep the last `n` chunks for viewers joining late")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return errors.New("expected a command to run")
+	}
+	l, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	fmt.Fprintf(stderr, "serving output on http://%s/\n", l.Addr())
+	return serveCommand(ctx, l, *history, flags.Args(), stdin, stdout, stderr)
+}
+
+// serveCommand runs the command args, copying its output to stdout and stderr, while serving a live view of it on l
+// until ctx is done. The view keeps being served once the command has exited.
+func serveCommand(ctx context.Context, l net.Listener, history int, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	mux := iomux.NewMux[iomux.StdStream](iomux.WithTee(iomux.Stdout, stdout), iomux.WithTee(iomux.Stderr, stderr))
+	defer mux.Close()
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = stdin
+	var err error
+	if cmd.Stdout, err = mux.Tag(iomux.Stdout); err != nil {
+		return err
+	}
+	if cmd.Stderr, err = mux.Tag(iomux.Stderr); err != nil {
+		return err
+	}
+	b := mux.Broadcast()
+	b.SetHistory(history)
+	server := &http.Server{Handler: ui.Handler(b, strings.Join(args, " "))}
+	go server.Serve(l)
+	defer server.Close()
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	runCtx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- b.Run(runCtx)
+	}()
+	waitErr := cmd.Wait()
+	cancel()
+	if err := <-done; err != nil {
+		return err
+	}
+	if waitErr != nil {
+		fmt.Fprintf(stderr, "%v\n", waitErr)
+	}
+	<-ctx.Done()
+	return nil
+}
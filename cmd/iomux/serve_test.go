@@ -0,0 +1,37 @@
+//go:build !windows
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServeCommand(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	var stdout, stderr bytes.Buffer
+	done := make(chan error, 1)
+	go func() {
+		done <- serveCommand(ctx, l, 100, []string{"sh", "-c", "echo out && echo err 1>&2 && exit 3"}, nil, &stdout,
+			&stderr)
+	}()
+
+	resp, err := http.Get("http://" + l.Addr().String() + "/")
+	assert.Nil(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Contains(t, string(body), "<title>sh -c echo out &amp;&amp; echo err 1&gt;&amp;2 &amp;&amp; exit 3</title>")
+
+	cancel()
+	assert.Nil(t, <-done)
+	assert.Equal(t, "out\n", stdout.String())
+	assert.Equal(t, "err\nexit status 3\n", stderr.String())
+}
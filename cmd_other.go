@@ -0,0 +1,26 @@
+//go:build !unix && !windows
+
+package iomux
+
+import (
+	"os"
+	"os/exec"
+)
+
+// startGroup Start cmd. This platform has no signals to forward, so the returned function does nothing.
+func startGroup(cmd *exec.Cmd) (func(), error) {
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return func() {}, nil
+}
+
+// signalCmd Send sig to the started cmd.
+func signalCmd(cmd *exec.Cmd, sig os.Signal) error {
+	return cmd.Process.Signal(sig)
+}
+
+// killCmd Kill the started cmd.
+func killCmd(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
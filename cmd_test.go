@@ -0,0 +1,215 @@
+//go:build !windows
+
+package iomux
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrapCmd(t *testing.T) {
+	echo := fmt.Sprintf("echo out1 && sleep %f && echo err1 1>&2 && sleep %f && echo out2", sleepSecs, sleepSecs)
+	td, err := WrapCmd(exec.Command("sh", "-c", echo))
+	assert.Nil(t, err)
+
+	assert.Equal(t, 3, len(td))
+	assert.Equal(t, Stdout, td[0].Tag)
+	assert.Equal(t, "out1\n", string(td[0].Data))
+	assert.Equal(t, Stderr, td[1].Tag)
+	assert.Equal(t, "err1\n", string(td[1].Data))
+	assert.Equal(t, Stdout, td[2].Tag)
+	assert.Equal(t, "out2\n", string(td[2].Data))
+}
+
+func TestWrapCmdExitError(t *testing.T) {
+	td, err := WrapCmd(exec.Command("sh", "-c", "echo failed 1>&2 && exit 3"))
+	var exitErr *exec.ExitError
+	assert.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 3, exitErr.ExitCode())
+
+	assert.Equal(t, 1, len(td))
+	assert.Equal(t, Stderr, td[0].Tag)
+	assert.Equal(t, "failed\n", string(td[0].Data))
+}
+
+func TestWrapCmdSocket(t *testing.T) {
+	// the child writes to the socket directly rather than a pipe copied by exec
+	td, err := WrapCmd(exec.Command("sh", "-c", "if [ -S /dev/stdout ]; then echo socket; else echo pipe; fi"))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(td))
+	assert.Equal(t, "socket\n", string(td[0].Data))
+}
+
+func TestWrapCmdOptions(t *testing.T) {
+	td, err := WrapCmd(exec.Command("sh", "-c", "printf 'one\ntwo\n'"), WithLineSplitting[StdStream]())
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(td))
+	assert.Equal(t, "one\n", string(td[0].Data))
+	assert.Equal(t, "two\n", string(td[1].Data))
+}
+
+func TestCombinedOutputOrdered(t *testing.T) {
+	echo := fmt.Sprintf("echo out1 && sleep %f && echo err1 1>&2", sleepSecs)
+	td, err := CombinedOutputOrdered(exec.Command("sh", "-c", echo))
+	assert.Nil(t, err)
+
+	assert.Equal(t, 2, len(td))
+	assert.Equal(t, Stdout, td[0].Tag)
+	assert.Equal(t, "out1\n", string(td[0].Data))
+	assert.Equal(t, Stderr, td[1].Tag)
+	assert.Equal(t, "err1\n", string(td[1].Data))
+}
+
+func TestCombinedOutputOrderedAlreadySet(t *testing.T) {
+	cmd := exec.Command("true")
+	cmd.Stdout = io.Discard
+	_, err := CombinedOutputOrdered(cmd)
+	assert.EqualError(t, err, "exec: Stdout already set")
+
+	cmd = exec.Command("true")
+	cmd.Stderr = io.Discard
+	_, err = CombinedOutputOrdered(cmd)
+	assert.EqualError(t, err, "exec: Stderr already set")
+}
+
+func TestStdStreamString(t *testing.T) {
+	assert.Equal(t, "stdout", Stdout.String())
+	assert.Equal(t, "stderr", Stderr.String())
+	assert.Equal(t, "fd3", ExtraFd(3).String())
+	assert.Equal(t, "unknown", StdStream(-1).String())
+	assert.Equal(t, 1, Stdout.Fd())
+	assert.Equal(t, 4, ExtraFd(4).Fd())
+}
+
+func TestMuxExtraFile(t *testing.T) {
+	mux := NewMux[StdStream]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	cmd := exec.Command("sh", "-c", fmt.Sprintf("echo out && sleep %f && echo plan >&3 && sleep %f && echo cover >&4", sleepSecs, sleepSecs))
+	stdout, _ := mux.Tag(Stdout)
+	cmd.Stdout = stdout
+	_, fd, err := mux.ExtraFile(cmd, ExtraFd(3))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, fd)
+	_, fd, err = mux.ExtraFile(cmd, ExtraFd(4))
+	assert.Nil(t, err)
+	assert.Equal(t, 4, fd)
+
+	td, err := mux.ReadWhile(func() error {
+		return cmd.Run()
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(td))
+	assert.Equal(t, Stdout, td[0].Tag)
+	assert.Equal(t, ExtraFd(3), td[1].Tag)
+	assert.Equal(t, "plan\n", string(td[1].Data))
+	assert.Equal(t, ExtraFd(4), td[2].Tag)
+	assert.Equal(t, "cover\n", string(td[2].Data))
+}
+
+func TestRun(t *testing.T) {
+	echo := fmt.Sprintf("echo out1 && sleep %f && echo err1 1>&2 && exit 3", sleepSecs)
+	result, err := Run(context.Background(), exec.Command("sh", "-c", echo))
+	var exitErr *exec.ExitError
+	assert.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 3, result.ExitCode)
+	assert.Greater(t, result.Duration, time.Duration(0))
+
+	assert.Equal(t, 2, len(result.Data))
+	assert.Equal(t, Stdout, result.Data[0].Tag)
+	assert.Equal(t, "out1\n", string(result.Data[0].Data))
+	assert.Equal(t, Stderr, result.Data[1].Tag)
+	assert.Equal(t, "err1\n", string(result.Data[1].Data))
+}
+
+func TestRunCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	result, err := Run(ctx, exec.Command("sh", "-c", "echo started && exec sleep 10"))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, -1, result.ExitCode)
+	assert.Less(t, result.Duration, 5*time.Second)
+	assert.Equal(t, 1, len(result.Data))
+	assert.Equal(t, "started\n", string(result.Data[0].Data))
+}
+
+func TestRunKill(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	cmd := exec.Command("sh", "-c", "trap '' TERM && echo started && exec sleep 10")
+	cmd.WaitDelay = 200 * time.Millisecond
+	result, err := Run(ctx, cmd)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, -1, result.ExitCode)
+	assert.Less(t, result.Duration, 5*time.Second)
+	assert.Equal(t, 1, len(result.Data))
+}
+
+func TestRunInterrupt(t *testing.T) {
+	// keeps the test binary alive should the signal arrive outside of Run
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	defer signal.Stop(signals)
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		syscall.Kill(os.Getpid(), syscall.SIGINT)
+	}()
+	cmd := exec.Command("sh", "-c", "trap 'echo interrupted; exit 130' INT; echo started; while :; do sleep 0.05; done")
+	result, err := RunGroup(context.Background(), cmd)
+	assert.NotNil(t, err)
+	assert.Equal(t, 130, result.ExitCode)
+	assert.Less(t, result.Duration, 5*time.Second)
+	var out []byte
+	for _, td := range result.Data {
+		out = append(out, td.Data...)
+	}
+	assert.Equal(t, "started\ninterrupted\n", string(out))
+	assert.True(t, cmd.SysProcAttr.Setpgid)
+}
+
+func TestRunNoGroup(t *testing.T) {
+	cmd := exec.Command("true")
+	_, err := Run(context.Background(), cmd)
+	assert.Nil(t, err)
+	assert.Nil(t, cmd.SysProcAttr)
+}
+
+func TestRunGroupKeepsSession(t *testing.T) {
+	cmd := exec.Command("true")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	_, err := RunGroup(context.Background(), cmd)
+	assert.Nil(t, err)
+	assert.True(t, cmd.SysProcAttr.Setsid)
+	assert.False(t, cmd.SysProcAttr.Setpgid)
+}
+
+func TestRunCancelGroup(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	leaked := filepath.Join(t.TempDir(), "leaked")
+	cmd := exec.Command("sh", "-c", "(sleep 0.5; touch "+leaked+") & wait")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	_, err := Run(ctx, cmd)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	// the background subshell is terminated along with the shell
+	time.Sleep(time.Second)
+	assert.NoFileExists(t, leaked)
+}
+
+func TestRunStartError(t *testing.T) {
+	result, err := Run(context.Background(), exec.Command("/nonexistent"))
+	assert.NotNil(t, err)
+	assert.Equal(t, -1, result.ExitCode)
+	assert.Equal(t, 0, len(result.Data))
+}
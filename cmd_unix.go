@@ -0,0 +1,66 @@
+//go:build unix
+
+package iomux
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+)
+
+// startGroup Start cmd in a new process group, unless cmd.SysProcAttr already gives it a group or session of its own,
+// forwarding SIGINT and SIGTERM received by this process to the command until the returned function is called.
+// Signals are caught before cmd starts, so there is no moment where Ctrl-C would kill this process but not the command.
+func startGroup(cmd *exec.Cmd) (func(), error) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	if !cmd.SysProcAttr.Setsid && !cmd.SysProcAttr.Setpgid {
+		cmd.SysProcAttr.Setpgid = true
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	if err := cmd.Start(); err != nil {
+		signal.Stop(signals)
+		return nil, err
+	}
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				signalCmd(cmd, sig)
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}, nil
+}
+
+// leadsGroup Whether cmd.SysProcAttr makes the command the leader of a process group, which then has the command's
+// pid as its id.
+func leadsGroup(cmd *exec.Cmd) bool {
+	attr := cmd.SysProcAttr
+	return attr != nil && (attr.Setsid || attr.Setpgid && attr.Pgid == 0)
+}
+
+// signalCmd Send sig to the started cmd, or to its whole process group if it leads one.
+func signalCmd(cmd *exec.Cmd, sig os.Signal) error {
+	if leadsGroup(cmd) {
+		return syscall.Kill(-cmd.Process.Pid, sig.(syscall.Signal))
+	}
+	return cmd.Process.Signal(sig)
+}
+
+// killCmd Kill the started cmd, or its whole process group if it leads one.
+func killCmd(cmd *exec.Cmd) error {
+	if leadsGroup(cmd) {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	return cmd.Process.Kill()
+}
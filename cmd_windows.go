@@ -0,0 +1,34 @@
+//go:build windows
+
+package iomux
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+)
+
+// startGroup Start cmd, catching Ctrl-C until the returned function is called. The console already delivers Ctrl-C to
+// every process attached to it, so the command receives it without forwarding, while this process keeps running to
+// read the rest of its output.
+func startGroup(cmd *exec.Cmd) (func(), error) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	if err := cmd.Start(); err != nil {
+		signal.Stop(signals)
+		return nil, err
+	}
+	return func() {
+		signal.Stop(signals)
+	}, nil
+}
+
+// signalCmd Send sig to the started cmd, which fails for every signal but os.Kill on Windows.
+func signalCmd(cmd *exec.Cmd, sig os.Signal) error {
+	return cmd.Process.Signal(sig)
+}
+
+// killCmd Kill the started cmd.
+func killCmd(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
@@ -0,0 +1,91 @@
+// Package docker feeds the output of Docker containers into a mux, so container output joins the output of locally
+// spawned processes in one ordered capture. It doesn't depend on the Docker client, instead it reads the multiplexed
+// stream returned by ContainerAttach and ContainerLogs for containers without a TTY, the format written by stdcopy.
+package docker
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/netflix/go-iomux"
+)
+
+// Stream identifiers of the stdcopy frame header.
+const (
+	streamStdin = iota
+	streamStdout
+	streamStderr
+	streamSystemErr
+)
+
+// headerSize is the size of a frame header, the stream, three bytes of padding and the big endian size of the payload.
+const headerSize = 8
+
+// Tag tags the output of a container, see CopyContainer.
+type Tag struct {
+	Container string
+	Stream    iomux.StdStream
+}
+
+func (t Tag) String() string {
+	return t.Container + "/" + t.Stream.String()
+}
+
+// Copy Read the multiplexed stream r until io.EOF, writing the payload of stdout frames to the mux tagged stdout and
+// stderr frames tagged stderr. Returns an error with the message of a system error frame, which Docker sends when the
+// stream fails. Containers with a TTY aren't multiplexed, their output should be copied to a single tag instead. The
+// tags are created by Copy, so when it runs within ReadWhile the mux needs another tag to read before it starts.
+func Copy[T comparable](mux *iomux.Mux[T], r io.Reader, stdout, stderr T) error {
+	outw, err := mux.TagWriter(stdout)
+	if err != nil {
+		return err
+	}
+	defer outw.Close()
+	errw, err := mux.TagWriter(stderr)
+	if err != nil {
+		return err
+	}
+	defer errw.Close()
+	var header [headerSize]byte
+	for {
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				return fmt.Errorf("docker: truncated frame header")
+			}
+			return err
+		}
+		size := int64(binary.BigEndian.Uint32(header[4:]))
+		var w io.Writer
+		switch header[0] {
+		case streamStdin, streamStdout:
+			w = outw
+		case streamStderr:
+			w = errw
+		case streamSystemErr:
+			msg, err := io.ReadAll(io.LimitReader(r, size))
+			if err != nil {
+				return err
+			}
+			return fmt.Errorf("docker: %s", msg)
+		default:
+			return fmt.Errorf("docker: unknown stream %d", header[0])
+		}
+		n, err := io.CopyN(w, r, size)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return fmt.Errorf("docker: truncated frame, %d of %d bytes", n, size)
+			}
+			return err
+		}
+	}
+}
+
+// CopyContainer Copy the multiplexed stream r of container into mux, tagged with the container name and stream.
+func CopyContainer(mux *iomux.Mux[Tag], container string, r io.Reader) error {
+	return Copy(mux, r, Tag{Container: container, Stream: iomux.Stdout}, Tag{Container: container, Stream: iomux.Stderr})
+}
@@ -0,0 +1,84 @@
+//go:build !windows
+
+package docker
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os/exec"
+	"testing"
+
+	"github.com/netflix/go-iomux"
+	"github.com/stretchr/testify/assert"
+)
+
+func frame(stream byte, data string) []byte {
+	header := make([]byte, headerSize, headerSize+len(data))
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(data)))
+	return append(header, data...)
+}
+
+func stream(frames ...[]byte) *bytes.Reader {
+	return bytes.NewReader(bytes.Join(frames, nil))
+}
+
+func TestCopyContainer(t *testing.T) {
+	mux := iomux.NewMux[Tag]()
+	defer mux.Close()
+	local, _ := mux.Tag(Tag{Container: "local", Stream: iomux.Stdout})
+	r := stream(frame(streamStdout, "out1\n"), frame(streamStderr, "err1\n"), frame(streamStdout, "out2\n"))
+	td, err := mux.ReadWhile(func() error {
+		cmd := exec.Command("sh", "-c", "echo local")
+		cmd.Stdout = local
+		if err := cmd.Run(); err != nil {
+			return err
+		}
+		return CopyContainer(mux, "web", r)
+	})
+	assert.Nil(t, err)
+	var got []string
+	for _, d := range td {
+		got = append(got, d.Tag.String()+": "+string(d.Data))
+	}
+	assert.Equal(t, []string{"local/stdout: local\n", "web/stdout: out1\n", "web/stderr: err1\n", "web/stdout: out2\n"}, got)
+}
+
+func TestCopySystemError(t *testing.T) {
+	mux := iomux.NewMux[string]()
+	defer mux.Close()
+	mux.Tag("cmd")
+	r := stream(frame(streamStdout, "out\n"), frame(streamSystemErr, "container exited"))
+	_, err := mux.ReadWhile(func() error {
+		return Copy(mux, r, "out", "err")
+	})
+	assert.EqualError(t, err, "docker: container exited")
+}
+
+func TestCopyTruncated(t *testing.T) {
+	mux := iomux.NewMux[string]()
+	defer mux.Close()
+	mux.Tag("cmd")
+	r := bytes.NewReader(frame(streamStdout, "out\n")[:10])
+	_, err := mux.ReadWhile(func() error {
+		return Copy(mux, r, "out", "err")
+	})
+	assert.EqualError(t, err, "docker: truncated frame, 2 of 4 bytes")
+}
+
+func TestCopyLarge(t *testing.T) {
+	mux := iomux.NewMux[string]()
+	defer mux.Close()
+	mux.Tag("cmd")
+	data := string(bytes.Repeat([]byte("x"), 1<<20))
+	td, err := mux.ReadWhile(func() error {
+		return Copy(mux, stream(frame(streamStderr, data)), "out", "err")
+	})
+	assert.Nil(t, err)
+	var got []byte
+	for _, d := range td {
+		assert.Equal(t, "err", d.Tag)
+		got = append(got, d.Data...)
+	}
+	assert.Equal(t, len(data), len(got))
+}
@@ -0,0 +1,137 @@
+// Package grpcmux serves the output read from a Mux to remote consumers over the gRPC service defined in iomux.proto,
+// and reads it into a Mux on the consuming end. It doesn't depend on gRPC or protobuf, instead the streams generated
+// from iomux.proto by protoc-gen-go-grpc satisfy SubscribeServer and SubscribeClient as they are, given functions
+// converting between the generated Chunk message and Chunk:
+//
+//	func (h *handler) Subscribe(req *iomuxv1.SubscribeRequest, stream iomuxv1.Mux_SubscribeServer) error {
+//		return grpcmux.Subscribe(h.broadcaster, 256, req.Tags, stream, func(c grpcmux.Chunk) *iomuxv1.Chunk {
+//			return &iomuxv1.Chunk{Tag: c.Tag, Data: c.Data, Seq: c.Seq, TimeUnixNano: c.TimeUnixNano}
+//		})
+//	}
+package grpcmux
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/netflix/go-iomux"
+)
+
+// ErrFellBehind is returned by Subscribe when the consumer falls more than buffer chunks behind the broadcast.
+var ErrFellBehind = errors.New("grpcmux: consumer fell behind")
+
+// Chunk mirrors the Chunk message of iomux.proto.
+type Chunk struct {
+	Tag          string
+	Data         []byte
+	Seq          uint64
+	TimeUnixNano int64
+}
+
+// SubscribeServer is the server side of a Subscribe call, as generated for iomux.proto with M the Chunk message.
+type SubscribeServer[M any] interface {
+	Send(m M) error
+	Context() context.Context
+}
+
+// SubscribeClient is the client side of a Subscribe call, as generated for iomux.proto with M the Chunk message.
+type SubscribeClient[M any] interface {
+	Recv() (M, error)
+}
+
+// Subscribe Serve a Subscribe call by sending every chunk broadcast by b from now on whose tag, formatted by
+// fmt.Sprint, is in tags, or every chunk if tags is empty, converted to the message sent with convert. Up to buffer
+// chunks are held for a consumer that falls behind, after which the call fails with ErrFellBehind rather than holding
+// up the broadcast, see iomux.Broadcaster.SubscribeNoWait. Returns nil when the broadcast ends, the context error when
+// the call is cancelled, or the error of Send.
+func Subscribe[T comparable, M any](b *iomux.Broadcaster[T], buffer int, tags []string, stream SubscribeServer[M], convert func(c Chunk) M) error {
+	_, ch, unsubscribe := b.SubscribeNoWait(buffer)
+	defer unsubscribe()
+	ctx := stream.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case td, ok := <-ch:
+			if !ok {
+				if b.Ended() {
+					return nil
+				}
+				return ErrFellBehind
+			}
+			tag := fmt.Sprint(td.Tag)
+			if len(tags) > 0 && !slices.Contains(tags, tag) {
+				continue
+			}
+			err := stream.Send(convert(Chunk{
+				Tag:          tag,
+				Data:         td.Data,
+				Seq:          td.Seq,
+				TimeUnixNano: td.Time.UnixNano(),
+			}))
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Start Copy the chunks received from stream, converted from the received message with convert, to mux, each tagged
+// with the tag it was sent with, returning a function that waits until the stream ends and returns its error, or nil
+// if it ended with io.EOF. Writers for tags are created before Start returns, so the wait function can be given to
+// ReadWhile, and writers for other tags as their first chunk arrives.
+func Start[M any](mux *iomux.Mux[string], tags []string, stream SubscribeClient[M], convert func(m M) Chunk) (func() error, error) {
+	writers := make(map[string]*iomux.TaggedWriter)
+	closeAll := func() {
+		for _, w := range writers {
+			w.Close()
+		}
+	}
+	for _, tag := range tags {
+		w, err := mux.TagWriter(tag)
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+		writers[tag] = w
+	}
+	done := make(chan struct{})
+	var recvErr error
+	go func() {
+		defer close(done)
+		defer closeAll()
+		recvErr = copyChunks(mux, writers, stream, convert)
+	}()
+	return func() error {
+		<-done
+		return recvErr
+	}, nil
+}
+
+// copyChunks writes the chunks received from stream to writers, creating writers for tags without one, until the
+// stream ends.
+func copyChunks[M any](mux *iomux.Mux[string], writers map[string]*iomux.TaggedWriter, stream SubscribeClient[M], convert func(m M) Chunk) error {
+	for {
+		m, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		c := convert(m)
+		w, ok := writers[c.Tag]
+		if !ok {
+			if w, err = mux.TagWriter(c.Tag); err != nil {
+				return err
+			}
+			writers[c.Tag] = w
+		}
+		if _, err := w.Write(c.Data); err != nil {
+			return err
+		}
+	}
+}
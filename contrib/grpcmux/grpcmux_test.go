@@ -0,0 +1,160 @@
+//go:build !windows
+
+package grpcmux
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/netflix/go-iomux"
+	"github.com/stretchr/testify/assert"
+)
+
+// message stands in for the Chunk message generated from iomux.proto.
+type message struct {
+	tag  string
+	data string
+	seq  uint64
+}
+
+func toMessage(c Chunk) *message {
+	return &message{tag: c.Tag, data: string(c.Data), seq: c.Seq}
+}
+
+func fromMessage(m *message) Chunk {
+	return Chunk{Tag: m.tag, Data: []byte(m.data), Seq: m.seq}
+}
+
+// serverStream is a SubscribeServer recording what is sent, failing with err if set.
+type serverStream struct {
+	ctx  context.Context
+	sent []*message
+	err  error
+}
+
+func (s *serverStream) Send(m *message) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.sent = append(s.sent, m)
+	return nil
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// clientStream is a SubscribeClient receiving msgs, then err, or io.EOF if nil.
+type clientStream struct {
+	msgs []*message
+	err  error
+}
+
+func (s *clientStream) Recv() (*message, error) {
+	if len(s.msgs) == 0 {
+		if s.err != nil {
+			return nil, s.err
+		}
+		return nil, io.EOF
+	}
+	m := s.msgs[0]
+	s.msgs = s.msgs[1:]
+	return m, nil
+}
+
+var _ SubscribeServer[*message] = (*serverStream)(nil)
+var _ SubscribeClient[*message] = (*clientStream)(nil)
+
+func TestSubscribe(t *testing.T) {
+	mux := iomux.NewMux[iomux.StdStream]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	stdout, _ := mux.Tag(iomux.Stdout)
+	stderr, _ := mux.Tag(iomux.Stderr)
+	b := mux.Broadcast()
+
+	stream := &serverStream{ctx: context.Background()}
+	done := make(chan error)
+	go func() {
+		done <- Subscribe(b, 8, []string{"stderr"}, stream, toMessage)
+	}()
+	// let Subscribe subscribe before the broadcast starts
+	time.Sleep(50 * time.Millisecond)
+
+	io.WriteString(stdout, "out")
+	io.WriteString(stderr, "err")
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	assert.Nil(t, b.Run(ctx))
+	assert.Nil(t, <-done)
+	assert.Equal(t, []*message{{tag: "stderr", data: "err", seq: 2}}, stream.sent)
+}
+
+func TestSubscribeSendError(t *testing.T) {
+	mux := iomux.NewMux[string]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	taga, _ := mux.Tag("a")
+	b := mux.Broadcast()
+
+	sendErr := errors.New("client gone")
+	done := make(chan error)
+	go func() {
+		done <- Subscribe(b, 8, nil, &serverStream{ctx: context.Background(), err: sendErr}, toMessage)
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	io.WriteString(taga, "a")
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	go b.Run(ctx)
+	assert.Equal(t, sendErr, <-done)
+}
+
+func TestSubscribeCancel(t *testing.T) {
+	mux := iomux.NewMux[string]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := Subscribe(mux.Broadcast(), 8, nil, &serverStream{ctx: ctx}, toMessage)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestStart(t *testing.T) {
+	mux := iomux.NewMux[string]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	stream := &clientStream{msgs: []*message{
+		{tag: "stdout", data: "out", seq: 1},
+		{tag: "stderr", data: "err", seq: 2},
+	}}
+	wait, err := Start(mux, []string{"stdout"}, stream, fromMessage)
+	assert.Nil(t, err)
+	td, err := mux.ReadWhile(wait)
+	assert.Nil(t, err)
+	data := make(map[string]string)
+	for _, d := range td {
+		data[d.Tag] += string(d.Data)
+	}
+	assert.Equal(t, map[string]string{"stdout": "out", "stderr": "err"}, data)
+}
+
+func TestStartRecvError(t *testing.T) {
+	mux := iomux.NewMux[string]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	recvErr := errors.New("stream reset")
+	wait, err := Start(mux, []string{"stdout"}, &clientStream{err: recvErr}, fromMessage)
+	assert.Nil(t, err)
+	_, err = mux.ReadWhile(wait)
+	assert.ErrorIs(t, err, recvErr)
+}
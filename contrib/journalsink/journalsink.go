@@ -0,0 +1,79 @@
+// Package journalsink provides a Sink sending tagged data to systemd-journald with its native protocol, with the tag as
+// SYSLOG_IDENTIFIER.
+package journalsink
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/netflix/go-iomux"
+)
+
+// journalSocket is the address journald receives entries on.
+var journalSocket = "/run/systemd/journal/socket"
+
+// Sink is an iomux.Sink sending each chunk to systemd-journald as an entry, with the data as MESSAGE and the tag as
+// SYSLOG_IDENTIFIER. Entries must fit in a single datagram, so very large chunks are rejected by the socket.
+type Sink[T comparable] struct {
+	conn     *net.UnixConn
+	priority func(tag T) int
+	fields   func(tag T) map[string]string
+}
+
+// NewSink Create a Sink connected to journald. Entries have the syslog priority returned by priority for
+// each tag, or 6 (info) if priority is nil, and the extra fields returned by fields, if not nil. Field names must be
+// valid journal field names, upper case letters, digits and underscores.
+func NewSink[T comparable](priority func(tag T) int, fields func(tag T) map[string]string) (*Sink[T], error) {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: journalSocket, Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+	if priority == nil {
+		priority = func(T) int {
+			return 6
+		}
+	}
+	return &Sink[T]{
+		conn:     conn,
+		priority: priority,
+		fields:   fields,
+	}, nil
+}
+
+// Write Send td as an entry, trimming a trailing newline from the data.
+func (s *Sink[T]) Write(td *iomux.TaggedData[T]) error {
+	var entry []byte
+	entry = appendJournalField(entry, "MESSAGE", bytes.TrimSuffix(td.Data, []byte("\n")))
+	entry = appendJournalField(entry, "PRIORITY", []byte(strconv.Itoa(s.priority(td.Tag))))
+	entry = appendJournalField(entry, "SYSLOG_IDENTIFIER", []byte(fmt.Sprint(td.Tag)))
+	if s.fields != nil {
+		for k, v := range s.fields(td.Tag) {
+			entry = appendJournalField(entry, k, []byte(v))
+		}
+	}
+	_, err := s.conn.Write(entry)
+	return err
+}
+
+// Close Close the connection to journald.
+func (s *Sink[T]) Close() error {
+	return s.conn.Close()
+}
+
+// appendJournalField appends a field of journald's native protocol to entry, using the binary form for values
+// containing newlines.
+func appendJournalField(entry []byte, key string, value []byte) []byte {
+	entry = append(entry, key...)
+	if bytes.IndexByte(value, '\n') < 0 {
+		entry = append(entry, '=')
+		entry = append(entry, value...)
+		return append(entry, '\n')
+	}
+	entry = append(entry, '\n')
+	entry = binary.LittleEndian.AppendUint64(entry, uint64(len(value)))
+	entry = append(entry, value...)
+	return append(entry, '\n')
+}
@@ -0,0 +1,50 @@
+//go:build !windows
+
+package journalsink
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/netflix/go-iomux"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ iomux.Sink[string] = (*Sink[string])(nil)
+
+func listenUnixgram(t *testing.T, path string) *net.UnixConn {
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+	})
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	return conn
+}
+
+func TestSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.sock")
+	conn := listenUnixgram(t, path)
+	socket := journalSocket
+	journalSocket = path
+	t.Cleanup(func() {
+		journalSocket = socket
+	})
+	sink, err := NewSink(func(tag iomux.StdStream) int {
+		return 3
+	}, func(tag iomux.StdStream) map[string]string {
+		return map[string]string{"JOB": "build"}
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, sink.Write(&iomux.TaggedData[iomux.StdStream]{Tag: iomux.Stderr, Data: []byte("line 1\nline 2\n")}))
+	assert.Nil(t, sink.Close())
+
+	buf := make([]byte, 1024)
+	n, err := conn.Read(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, "MESSAGE\n\x0d\x00\x00\x00\x00\x00\x00\x00line 1\nline 2\nPRIORITY=3\nSYSLOG_IDENTIFIER=stderr\nJOB=build\n", string(buf[:n]))
+}
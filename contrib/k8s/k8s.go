@@ -0,0 +1,85 @@
+// Package k8s follows the logs of Kubernetes pods into a mux, tagged with the pod and container, so the output of the
+// pods of a distributed job can be read as one ordered view. It doesn't depend on client-go, instead logs are opened by
+// a Streamer, which is a small adapter around the client in use, for example:
+//
+//	k8s.StreamerFunc(func(ctx context.Context, pod, container string) (io.ReadCloser, error) {
+//		opts := &corev1.PodLogOptions{Container: container, Follow: true}
+//		return clientset.CoreV1().Pods(namespace).GetLogs(pod, opts).Stream(ctx)
+//	})
+package k8s
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/netflix/go-iomux"
+)
+
+// Tag tags the logs of a container of a pod.
+type Tag struct {
+	Pod       string
+	Container string
+}
+
+func (t Tag) String() string {
+	return t.Pod + "/" + t.Container
+}
+
+// Streamer opens the log stream of a container of a pod, following it until the container exits or ctx is done.
+type Streamer interface {
+	Stream(ctx context.Context, pod, container string) (io.ReadCloser, error)
+}
+
+// StreamerFunc adapts a function to a Streamer.
+type StreamerFunc func(ctx context.Context, pod, container string) (io.ReadCloser, error)
+
+// Stream Call f.
+func (f StreamerFunc) Stream(ctx context.Context, pod, container string) (io.ReadCloser, error) {
+	return f(ctx, pod, container)
+}
+
+// Follow Start copying the logs of each container in tags into mux, returning a function that waits until every
+// stream ends or ctx is done, and returns the errors of the streams that failed. The wait function can be given to
+// ReadWhile, as every container is tagged before Follow returns.
+func Follow(ctx context.Context, mux *iomux.Mux[Tag], streamer Streamer, tags ...Tag) (func() error, error) {
+	writers := make([]*iomux.TaggedWriter, 0, len(tags))
+	for _, tag := range tags {
+		w, err := mux.TagWriter(tag)
+		if err != nil {
+			for _, w := range writers {
+				w.Close()
+			}
+			return nil, err
+		}
+		writers = append(writers, w)
+	}
+	errs := make([]error, len(tags))
+	var wg sync.WaitGroup
+	for i, tag := range tags {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer writers[i].Close()
+			errs[i] = follow(ctx, streamer, tag, writers[i])
+		}()
+	}
+	return func() error {
+		wg.Wait()
+		return errors.Join(errs...)
+	}, nil
+}
+
+// follow copies the log stream of tag to w.
+func follow(ctx context.Context, streamer Streamer, tag Tag, w io.Writer) error {
+	r, err := streamer.Stream(ctx, tag.Pod, tag.Container)
+	if err != nil {
+		return &iomux.TagError[Tag]{Tag: tag, Op: "read", Err: err}
+	}
+	defer r.Close()
+	if _, err := io.Copy(w, r); err != nil && ctx.Err() == nil {
+		return &iomux.TagError[Tag]{Tag: tag, Op: "read", Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+//go:build !windows
+
+package k8s
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/netflix/go-iomux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFollow(t *testing.T) {
+	logs := map[Tag]string{
+		{Pod: "job-0", Container: "worker"}: "step 1\nstep 2\n",
+		{Pod: "job-1", Container: "worker"}: "step 1\n",
+	}
+	streamer := StreamerFunc(func(ctx context.Context, pod, container string) (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader(logs[Tag{Pod: pod, Container: container}])), nil
+	})
+	mux := iomux.NewMux[Tag]()
+	defer mux.Close()
+	wait, err := Follow(context.Background(), mux, streamer, Tag{Pod: "job-0", Container: "worker"}, Tag{Pod: "job-1", Container: "worker"})
+	assert.Nil(t, err)
+	td, err := mux.ReadWhile(wait)
+	assert.Nil(t, err)
+	byTag := map[string]string{}
+	for _, d := range td {
+		byTag[d.Tag.String()] += string(d.Data)
+	}
+	assert.Equal(t, map[string]string{"job-0/worker": "step 1\nstep 2\n", "job-1/worker": "step 1\n"}, byTag)
+}
+
+func TestFollowError(t *testing.T) {
+	streamer := StreamerFunc(func(ctx context.Context, pod, container string) (io.ReadCloser, error) {
+		if pod == "missing" {
+			return nil, errors.New("pod not found")
+		}
+		return io.NopCloser(strings.NewReader("ok\n")), nil
+	})
+	mux := iomux.NewMux[Tag]()
+	defer mux.Close()
+	wait, err := Follow(context.Background(), mux, streamer, Tag{Pod: "job", Container: "main"}, Tag{Pod: "missing", Container: "main"})
+	assert.Nil(t, err)
+	_, err = mux.ReadWhile(wait)
+	assert.EqualError(t, err, "read missing/main: pod not found")
+}
@@ -0,0 +1,67 @@
+// Package kafka provides a Sink publishing tagged data to Kafka, with the tag as the message key. It doesn't depend on
+// a Kafka client, instead the messages are handed to a Producer, which is a small adapter around the client in use.
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/netflix/go-iomux"
+)
+
+// Message is a Kafka message to publish.
+type Message struct {
+	Topic   string
+	Key     []byte
+	Value   []byte
+	Headers map[string][]byte
+	Time    time.Time
+}
+
+// Producer publishes messages, for example by calling WriteMessages of a kafka-go Writer, or Produce of a franz-go
+// client.
+type Producer interface {
+	Produce(ctx context.Context, msg Message) error
+}
+
+// Sink is an iomux.Sink publishing each chunk to a topic as a message, keyed by the tag formatted with fmt.Sprint so
+// that each tag's data stays in order on a single partition. The sequence number is sent in the "seq" header.
+type Sink[T comparable] struct {
+	producer Producer
+	topic    string
+	close    func() error
+}
+
+// NewSink Create a Sink publishing to topic with producer. close is called by Close, for example to flush the
+// producer, and may be nil.
+func NewSink[T comparable](producer Producer, topic string, close func() error) *Sink[T] {
+	return &Sink[T]{
+		producer: producer,
+		topic:    topic,
+		close:    close,
+	}
+}
+
+// Write Publish td as a message.
+func (s *Sink[T]) Write(td *iomux.TaggedData[T]) error {
+	return s.producer.Produce(context.Background(), Message{
+		Topic: s.topic,
+		Key:   []byte(fmt.Sprint(td.Tag)),
+		// the data can't be retained after Write returns, and producers often batch
+		Value: append([]byte(nil), td.Data...),
+		Headers: map[string][]byte{
+			"seq": []byte(strconv.FormatUint(td.Seq, 10)),
+		},
+		Time: td.Time,
+	})
+}
+
+// Close Call the close function given to NewSink, if any.
+func (s *Sink[T]) Close() error {
+	if s.close == nil {
+		return nil
+	}
+	return s.close()
+}
@@ -0,0 +1,53 @@
+//go:build !windows
+
+package kafka
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/netflix/go-iomux"
+	"github.com/stretchr/testify/assert"
+)
+
+type testProducer struct {
+	mutex    sync.Mutex
+	messages []Message
+	flushed  bool
+}
+
+func (p *testProducer) Produce(ctx context.Context, msg Message) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.messages = append(p.messages, msg)
+	return nil
+}
+
+func TestSink(t *testing.T) {
+	producer := &testProducer{}
+	sink := NewSink[iomux.StdStream](producer, "jobs", func() error {
+		producer.flushed = true
+		return nil
+	})
+	mux := iomux.NewMux(iomux.WithSink[iomux.StdStream](sink))
+	stdout, _ := mux.Tag(iomux.Stdout)
+	stderr, _ := mux.Tag(iomux.Stderr)
+	_, err := mux.ReadWhile(func() error {
+		io.WriteString(stdout, "out")
+		io.WriteString(stderr, "err")
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, mux.Close())
+
+	assert.True(t, producer.flushed)
+	assert.Equal(t, 2, len(producer.messages))
+	msg := producer.messages[1]
+	assert.Equal(t, "jobs", msg.Topic)
+	assert.Equal(t, "stderr", string(msg.Key))
+	assert.Equal(t, "err", string(msg.Value))
+	assert.Equal(t, "2", string(msg.Headers["seq"]))
+	assert.False(t, msg.Time.IsZero())
+}
@@ -0,0 +1,93 @@
+// Package nats provides a Sink publishing tagged data to NATS subjects derived from the tag. It doesn't depend on the
+// NATS client, a *nats.Conn can be used as the Publisher as it is.
+package nats
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/netflix/go-iomux"
+)
+
+// ErrInvalidSubject is returned by Write when the subject for a tag isn't one that can be published to, as it has an
+// empty token, or a token containing whitespace or the wildcards '*' and '>'.
+var ErrInvalidSubject = errors.New("nats: invalid subject")
+
+// Publisher publishes data to a subject, as *nats.Conn does.
+type Publisher interface {
+	Publish(subject string, data []byte) error
+}
+
+// flusher is implemented by publishers that buffer, such as *nats.Conn.
+type flusher interface {
+	Flush() error
+}
+
+// Sink is an iomux.Sink publishing each chunk to the subject for its tag.
+type Sink[T comparable] struct {
+	publisher Publisher
+	subject   func(tag T) (string, error)
+}
+
+// NewSink Create a Sink publishing with publisher to the subject prefix.tag, with the tag formatted by fmt.Sprint, such
+// as "jobs.build.stderr" for the prefix "jobs.build". The formatted tag must be a single token, so tags containing '.'
+// fail with ErrInvalidSubject, as do tags containing whitespace or wildcards.
+func NewSink[T comparable](publisher Publisher, prefix string) *Sink[T] {
+	return &Sink[T]{
+		publisher: publisher,
+		subject: func(tag T) (string, error) {
+			token := fmt.Sprint(tag)
+			if strings.Contains(token, ".") {
+				return "", fmt.Errorf("%w: tag %q isn't a single token", ErrInvalidSubject, token)
+			}
+			subject := prefix + "." + token
+			return subject, validSubject(subject)
+		},
+	}
+}
+
+// NewSinkFunc Create a Sink publishing with publisher to the subject returned by subject for each tag. Subjects that
+// can't be published to fail with ErrInvalidSubject.
+func NewSinkFunc[T comparable](publisher Publisher, subject func(tag T) string) *Sink[T] {
+	return &Sink[T]{
+		publisher: publisher,
+		subject: func(tag T) (string, error) {
+			s := subject(tag)
+			return s, validSubject(s)
+		},
+	}
+}
+
+// Write Publish the data of td to the subject for its tag, or return an error wrapping ErrInvalidSubject without
+// publishing if the subject isn't valid.
+func (s *Sink[T]) Write(td *iomux.TaggedData[T]) error {
+	subject, err := s.subject(td.Tag)
+	if err != nil {
+		return err
+	}
+	// the data can't be retained after Write returns, and publishers buffer
+	return s.publisher.Publish(subject, append([]byte(nil), td.Data...))
+}
+
+// validSubject checks that subject is made of non-empty tokens separated by '.', without whitespace or the wildcards '*'
+// and '>', which would subscribe to other subjects rather than publish to one.
+func validSubject(subject string) error {
+	for _, token := range strings.Split(subject, ".") {
+		if token == "" || strings.ContainsFunc(token, func(r rune) bool {
+			return unicode.IsSpace(r) || r == '*' || r == '>'
+		}) {
+			return fmt.Errorf("%w: %q", ErrInvalidSubject, subject)
+		}
+	}
+	return nil
+}
+
+// Close Flush the publisher if it buffers. The publisher itself isn't closed.
+func (s *Sink[T]) Close() error {
+	if f, ok := s.publisher.(flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
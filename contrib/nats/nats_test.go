@@ -0,0 +1,75 @@
+//go:build !windows
+
+package nats
+
+import (
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/netflix/go-iomux"
+	"github.com/stretchr/testify/assert"
+)
+
+type testPublisher struct {
+	subjects []string
+	data     []string
+	flushed  bool
+}
+
+func (p *testPublisher) Publish(subject string, data []byte) error {
+	p.subjects = append(p.subjects, subject)
+	p.data = append(p.data, string(data))
+	return nil
+}
+
+func (p *testPublisher) Flush() error {
+	p.flushed = true
+	return nil
+}
+
+func TestSink(t *testing.T) {
+	publisher := &testPublisher{}
+	mux := iomux.NewMux(iomux.WithSink[iomux.StdStream](NewSink[iomux.StdStream](publisher, "jobs.build")))
+	stdout, _ := mux.Tag(iomux.Stdout)
+	stderr, _ := mux.Tag(iomux.Stderr)
+	_, err := mux.ReadWhile(func() error {
+		io.WriteString(stdout, "out")
+		io.WriteString(stderr, "err")
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, mux.Close())
+
+	assert.True(t, publisher.flushed)
+	assert.Equal(t, []string{"jobs.build.stdout", "jobs.build.stderr"}, publisher.subjects)
+	assert.Equal(t, []string{"out", "err"}, publisher.data)
+}
+
+func TestSinkFunc(t *testing.T) {
+	publisher := &testPublisher{}
+	sink := NewSinkFunc(publisher, func(tag int) string {
+		return "tag" + string(rune('0'+tag))
+	})
+	assert.Nil(t, sink.Write(&iomux.TaggedData[int]{Tag: 1, Data: []byte("one")}))
+	assert.Equal(t, []string{"tag1"}, publisher.subjects)
+}
+
+func TestSinkInvalidSubject(t *testing.T) {
+	publisher := &testPublisher{}
+	sink := NewSink[string](publisher, "jobs")
+	for _, tag := range []string{"", "a.b", "a b", "*", ">", "a\n"} {
+		t.Run(fmt.Sprintf("%q", tag), func(t *testing.T) {
+			assert.ErrorIs(t, sink.Write(&iomux.TaggedData[string]{Tag: tag, Data: []byte("data")}), ErrInvalidSubject)
+		})
+	}
+	assert.Nil(t, sink.Write(&iomux.TaggedData[string]{Tag: "build-1", Data: []byte("data")}))
+	assert.Equal(t, []string{"jobs.build-1"}, publisher.subjects)
+
+	sink = NewSinkFunc(publisher, func(tag string) string {
+		return "jobs." + tag
+	})
+	assert.ErrorIs(t, sink.Write(&iomux.TaggedData[string]{Tag: "a.>", Data: []byte("data")}), ErrInvalidSubject)
+	assert.Nil(t, sink.Write(&iomux.TaggedData[string]{Tag: "a.b", Data: []byte("data")}))
+	assert.Equal(t, []string{"jobs.build-1", "jobs.a.b"}, publisher.subjects)
+}
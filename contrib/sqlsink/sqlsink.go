@@ -0,0 +1,42 @@
+// Package sqlsink provides a Sink inserting tagged data into a database table with database/sql, leaving the choice of
+// driver to the caller.
+package sqlsink
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/netflix/go-iomux"
+)
+
+// Sink is an iomux.Sink inserting each chunk as a row of a database table with the columns seq, time, tag and data, so a
+// capture can be queried afterwards, for example for all stderr output between two times with SQLite. Tags are stored
+// as text formatted by fmt.Sprint. The database driver is left to the caller, and must accept '?' placeholders.
+type Sink[T comparable] struct {
+	insert *sql.Stmt
+}
+
+// NewSink Create a Sink inserting into table of db, creating the table if it doesn't exist. table is used in SQL
+// as it is, so must not come from untrusted input.
+func NewSink[T comparable](db *sql.DB, table string) (*Sink[T], error) {
+	_, err := db.Exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (seq INTEGER, time TIMESTAMP, tag TEXT, data BLOB)", table))
+	if err != nil {
+		return nil, err
+	}
+	insert, err := db.Prepare(fmt.Sprintf("INSERT INTO %s (seq, time, tag, data) VALUES (?, ?, ?, ?)", table))
+	if err != nil {
+		return nil, err
+	}
+	return &Sink[T]{insert: insert}, nil
+}
+
+// Write Insert td as a row.
+func (s *Sink[T]) Write(td *iomux.TaggedData[T]) error {
+	_, err := s.insert.Exec(int64(td.Seq), td.Time, fmt.Sprint(td.Tag), td.Data)
+	return err
+}
+
+// Close Close the prepared insert. The database itself isn't closed.
+func (s *Sink[T]) Close() error {
+	return s.insert.Close()
+}
@@ -0,0 +1,97 @@
+package sqlsink
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/netflix/go-iomux"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ iomux.Sink[string] = (*Sink[string])(nil)
+
+// recordingDriver is a database/sql driver recording the statements executed and their arguments. It is its own
+// connector, so it can be opened with sql.OpenDB rather than being registered once per test run.
+type recordingDriver struct {
+	mutex sync.Mutex
+	execs []string
+	rows  [][]driver.Value
+}
+
+func (d *recordingDriver) Open(name string) (driver.Conn, error) {
+	return &recordingConn{d}, nil
+}
+
+func (d *recordingDriver) Connect(ctx context.Context) (driver.Conn, error) {
+	return d.Open("")
+}
+
+func (d *recordingDriver) Driver() driver.Driver {
+	return d
+}
+
+type recordingConn struct {
+	d *recordingDriver
+}
+
+func (c *recordingConn) Prepare(query string) (driver.Stmt, error) {
+	return &recordingStmt{c.d, query}, nil
+}
+
+func (c *recordingConn) Close() error {
+	return nil
+}
+
+func (c *recordingConn) Begin() (driver.Tx, error) {
+	return nil, errors.ErrUnsupported
+}
+
+type recordingStmt struct {
+	d     *recordingDriver
+	query string
+}
+
+func (s *recordingStmt) Close() error {
+	return nil
+}
+
+func (s *recordingStmt) NumInput() int {
+	return -1
+}
+
+func (s *recordingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.mutex.Lock()
+	defer s.d.mutex.Unlock()
+	s.d.execs = append(s.d.execs, s.query)
+	if len(args) > 0 {
+		s.d.rows = append(s.d.rows, args)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *recordingStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func TestSink(t *testing.T) {
+	d := &recordingDriver{}
+	db := sql.OpenDB(d)
+	defer db.Close()
+
+	sink, err := NewSink[iomux.StdStream](db, "capture")
+	assert.Nil(t, err)
+	now := time.Now()
+	assert.Nil(t, sink.Write(&iomux.TaggedData[iomux.StdStream]{Tag: iomux.Stderr, Data: []byte("err"), Seq: 2, Time: now}))
+	assert.Nil(t, sink.Close())
+
+	assert.Equal(t, []string{
+		"CREATE TABLE IF NOT EXISTS capture (seq INTEGER, time TIMESTAMP, tag TEXT, data BLOB)",
+		"INSERT INTO capture (seq, time, tag, data) VALUES (?, ?, ?, ?)",
+	}, d.execs)
+	assert.Equal(t, [][]driver.Value{{int64(2), now, "stderr", []byte("err")}}, d.rows)
+}
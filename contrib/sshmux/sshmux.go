@@ -0,0 +1,83 @@
+// Package sshmux captures the output of commands run over SSH into a mux, so remote and local command output are read
+// with the same API and ordering. It doesn't depend on golang.org/x/crypto/ssh, instead a *ssh.Session satisfies
+// Session as is:
+//
+//	session, err := client.NewSession()
+//	if err != nil {
+//		return err
+//	}
+//	defer session.Close()
+//	td, err := sshmux.Run(session, "make test")
+package sshmux
+
+import (
+	"io"
+	"sync"
+
+	"github.com/netflix/go-iomux"
+)
+
+// Session is the part of *ssh.Session used to run a command.
+type Session interface {
+	StdoutPipe() (io.Reader, error)
+	StderrPipe() (io.Reader, error)
+	Start(cmd string) error
+	Wait() error
+}
+
+// Start Start cmd on session with its stdout and stderr tagged stdout and stderr on mux, returning a function that
+// waits until the output has been copied and the command has exited, and returns the error of session.Wait. The wait
+// function can be given to ReadWhile, as both tags are created before Start returns. SSH carries stdout and stderr in
+// the same channel, but the session buffers them separately, so their order is only kept as well as the copies
+// keep up with the remote command.
+func Start[T comparable](mux *iomux.Mux[T], session Session, cmd string, stdout, stderr T) (func() error, error) {
+	outr, err := session.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	errr, err := session.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	outw, err := mux.TagWriter(stdout)
+	if err != nil {
+		return nil, err
+	}
+	errw, err := mux.TagWriter(stderr)
+	if err != nil {
+		outw.Close()
+		return nil, err
+	}
+	if err := session.Start(cmd); err != nil {
+		outw.Close()
+		errw.Close()
+		return nil, err
+	}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go copyOutput(&wg, outw, outr)
+	go copyOutput(&wg, errw, errr)
+	return func() error {
+		wg.Wait()
+		return session.Wait()
+	}, nil
+}
+
+// copyOutput copies r to w until io.EOF, then closes w.
+func copyOutput(wg *sync.WaitGroup, w *iomux.TaggedWriter, r io.Reader) {
+	defer wg.Done()
+	defer w.Close()
+	io.Copy(w, r)
+}
+
+// Run Run cmd on session with its stdout and stderr tagged Stdout and Stderr on a new Mux created with opts, like
+// iomux.WrapCmd, returning the output and the error of session.Wait. The Mux is closed before returning.
+func Run(session Session, cmd string, opts ...iomux.Option[iomux.StdStream]) ([]*iomux.TaggedData[iomux.StdStream], error) {
+	mux := iomux.NewMux[iomux.StdStream](opts...)
+	defer mux.Close()
+	wait, err := Start(mux, session, cmd, iomux.Stdout, iomux.Stderr)
+	if err != nil {
+		return nil, err
+	}
+	return mux.ReadWhile(wait)
+}
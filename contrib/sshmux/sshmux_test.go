@@ -0,0 +1,70 @@
+//go:build !windows
+
+package sshmux
+
+import (
+	"errors"
+	"io"
+	"os/exec"
+	"testing"
+
+	"github.com/netflix/go-iomux"
+	"github.com/stretchr/testify/assert"
+)
+
+// execSession runs commands locally with sh, standing in for a remote session.
+type execSession struct {
+	cmd *exec.Cmd
+}
+
+func newExecSession() *execSession {
+	return &execSession{cmd: exec.Command("sh")}
+}
+
+func (s *execSession) StdoutPipe() (io.Reader, error) {
+	return s.cmd.StdoutPipe()
+}
+
+func (s *execSession) StderrPipe() (io.Reader, error) {
+	return s.cmd.StderrPipe()
+}
+
+func (s *execSession) Start(cmd string) error {
+	s.cmd.Args = append(s.cmd.Args, "-c", cmd)
+	return s.cmd.Start()
+}
+
+func (s *execSession) Wait() error {
+	return s.cmd.Wait()
+}
+
+func TestRun(t *testing.T) {
+	td, err := Run(newExecSession(), "echo out; sleep 0.05; echo err >&2; sleep 0.05; echo out")
+	assert.Nil(t, err)
+	var got []string
+	for _, d := range td {
+		got = append(got, d.Tag.String()+": "+string(d.Data))
+	}
+	assert.Equal(t, []string{"stdout: out\n", "stderr: err\n", "stdout: out\n"}, got)
+}
+
+func TestRunExitError(t *testing.T) {
+	td, err := Run(newExecSession(), "echo failed >&2; exit 3")
+	var exitErr *exec.ExitError
+	assert.True(t, errors.As(err, &exitErr))
+	assert.Equal(t, 3, exitErr.ExitCode())
+	assert.Len(t, td, 1)
+	assert.Equal(t, "failed\n", string(td[0].Data))
+}
+
+func TestStart(t *testing.T) {
+	mux := iomux.NewMux[string]()
+	defer mux.Close()
+	wait, err := Start(mux, newExecSession(), "echo remote", "host/stdout", "host/stderr")
+	assert.Nil(t, err)
+	td, err := mux.ReadWhile(wait)
+	assert.Nil(t, err)
+	assert.Len(t, td, 1)
+	assert.Equal(t, "host/stdout", td[0].Tag)
+	assert.Equal(t, "remote\n", string(td[0].Data))
+}
@@ -0,0 +1,70 @@
+//go:build !windows && !plan9
+
+// Package syslogsink provides a Sink forwarding tagged data to syslog, with the tag as the identifier.
+package syslogsink
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/syslog"
+	"sync"
+
+	"github.com/netflix/go-iomux"
+)
+
+// Sink is an iomux.Sink forwarding each chunk to syslog as a message, with the tag as the identifier.
+type Sink[T comparable] struct {
+	network  string
+	raddr    string
+	priority func(tag T) syslog.Priority
+	mutex    sync.Mutex
+	writers  map[T]*syslog.Writer
+}
+
+// NewSink Create a Sink connecting to the syslog daemon at raddr on network, or the local daemon if
+// network is empty, see syslog.Dial. Messages are logged at the priority returned by priority for each tag, or
+// LOG_INFO|LOG_USER if priority is nil.
+func NewSink[T comparable](network, raddr string, priority func(tag T) syslog.Priority) *Sink[T] {
+	if priority == nil {
+		priority = func(T) syslog.Priority {
+			return syslog.LOG_INFO | syslog.LOG_USER
+		}
+	}
+	return &Sink[T]{
+		network:  network,
+		raddr:    raddr,
+		priority: priority,
+		writers:  make(map[T]*syslog.Writer),
+	}
+}
+
+// Write Log td as a message, trimming a trailing newline from the data. Connects to syslog for a tag the first time it
+// is written.
+func (s *Sink[T]) Write(td *iomux.TaggedData[T]) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	w, ok := s.writers[td.Tag]
+	if !ok {
+		var err error
+		w, err = syslog.Dial(s.network, s.raddr, s.priority(td.Tag), fmt.Sprint(td.Tag))
+		if err != nil {
+			return err
+		}
+		s.writers[td.Tag] = w
+	}
+	_, err := w.Write(bytes.TrimSuffix(td.Data, []byte("\n")))
+	return err
+}
+
+// Close Close the connection of every tag.
+func (s *Sink[T]) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var errs []error
+	for _, w := range s.writers {
+		errs = append(errs, w.Close())
+	}
+	clear(s.writers)
+	return errors.Join(errs...)
+}
@@ -0,0 +1,56 @@
+//go:build !windows && !plan9
+
+package syslogsink
+
+import (
+	"log/syslog"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/netflix/go-iomux"
+	"github.com/stretchr/testify/assert"
+)
+
+func listenUnixgram(t *testing.T, path string) *net.UnixConn {
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+	})
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	return conn
+}
+
+var _ iomux.Sink[string] = (*Sink[string])(nil)
+
+func TestSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "syslog.sock")
+	conn := listenUnixgram(t, path)
+	sink := NewSink("unixgram", path, func(tag iomux.StdStream) syslog.Priority {
+		if tag == iomux.Stderr {
+			return syslog.LOG_ERR | syslog.LOG_DAEMON
+		}
+		return syslog.LOG_INFO | syslog.LOG_DAEMON
+	})
+	assert.Nil(t, sink.Write(&iomux.TaggedData[iomux.StdStream]{Tag: iomux.Stderr, Data: []byte("failed\n")}))
+	assert.Nil(t, sink.Write(&iomux.TaggedData[iomux.StdStream]{Tag: iomux.Stdout, Data: []byte("ok\n")}))
+	assert.Nil(t, sink.Close())
+
+	buf := make([]byte, 1024)
+	n, err := conn.Read(buf)
+	assert.Nil(t, err)
+	msg := string(buf[:n])
+	assert.True(t, strings.HasPrefix(msg, "<27>"), msg)
+	assert.Contains(t, msg, " stderr[")
+	assert.True(t, strings.HasSuffix(msg, ": failed\n"), msg)
+	n, err = conn.Read(buf)
+	assert.Nil(t, err)
+	msg = string(buf[:n])
+	assert.True(t, strings.HasPrefix(msg, "<30>"), msg)
+	assert.Contains(t, msg, " stdout[")
+}
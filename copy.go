@@ -0,0 +1,84 @@
+package iomux
+
+import (
+	"context"
+	"io"
+	"os"
+)
+
+// Colors The ANSI foreground colors AssignColors assigns to tags, in the order tags are first seen.
+var Colors = []string{"\x1b[36m", "\x1b[33m", "\x1b[32m", "\x1b[35m", "\x1b[34m", "\x1b[31m"}
+
+const colorReset = "\x1b[0m"
+
+// Copy Read until ctx is done, writing each line to w prefixed with prefix(tag), such as "[stderr] ", or unprefixed
+// if prefix is nil. Partial lines are held back until completed so lines of different tags never interleave, and are
+// written with a newline added once there is no more data to read.
+func (mux *Mux[T]) Copy(ctx context.Context, w io.Writer, prefix func(tag T) string) error {
+	return mux.copyLines(ctx, w, prefix, nil)
+}
+
+// CopyColor Copy like Copy, writing each line in the ANSI color returned by color(tag), or assigned from Colors if
+// color is nil. Colors are only written when w is a terminal, so output redirected to a file or pipe stays plain.
+func (mux *Mux[T]) CopyColor(ctx context.Context, w io.Writer, prefix func(tag T) string, color func(tag T) string) error {
+	if !isTerminal(w) {
+		return mux.copyLines(ctx, w, prefix, nil)
+	}
+	if color == nil {
+		color = AssignColors[T]()
+	}
+	return mux.copyLines(ctx, w, prefix, color)
+}
+
+// copyLines implements Copy, coloring lines with color if not nil.
+func (mux *Mux[T]) copyLines(ctx context.Context, w io.Writer, prefix func(tag T) string, color func(tag T) string) error {
+	var ls lineSplitter[T]
+	write := func(td *TaggedData[T]) error {
+		var line []byte
+		if color != nil {
+			line = append(line, color(td.Tag)...)
+		}
+		if prefix != nil {
+			line = append(line, prefix(td.Tag)...)
+		}
+		line = append(line, td.Data...)
+		if len(line) > 0 && line[len(line)-1] == '\n' {
+			line = line[:len(line)-1]
+		}
+		if color != nil {
+			line = append(line, colorReset...)
+		}
+		line = append(line, '\n')
+		_, err := w.Write(line)
+		return err
+	}
+	for {
+		data, tag, err := mux.Read(ctx)
+		if err != nil {
+			if err != io.EOF {
+				return err
+			}
+			for _, td := range ls.flushAll() {
+				if err := write(td); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		for _, td := range ls.split(tag, data) {
+			if err := write(td); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// isTerminal reports whether w is a terminal, or any other character device.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,100 @@
+//go:build !windows
+
+package iomux
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMuxCopy(t *testing.T) {
+	mux := NewMux[StdStream]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	stdout, err := mux.Tag(Stdout)
+	assert.Nil(t, err)
+	stderr, _ := mux.Tag(Stderr)
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	go func() {
+		io.WriteString(stdout, "out1\nou")
+		time.Sleep(sleepDuration)
+		io.WriteString(stderr, "err1\n")
+		time.Sleep(sleepDuration)
+		io.WriteString(stdout, "t2\npartial")
+		time.Sleep(sleepDuration)
+		cancelFn()
+	}()
+	var buf bytes.Buffer
+	err = mux.Copy(ctx, &buf, func(tag StdStream) string {
+		return "[" + tag.String() + "] "
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "[stdout] out1\n[stderr] err1\n[stdout] out2\n[stdout] partial\n", buf.String())
+}
+
+func TestMuxCopyNilPrefix(t *testing.T) {
+	mux := NewMux[StdStream]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	stdout, _ := mux.Tag(Stdout)
+	io.WriteString(stdout, "out1\nout2")
+	ctx, cancelFn := context.WithCancel(context.Background())
+	cancelFn()
+	var buf bytes.Buffer
+	assert.Nil(t, mux.Copy(ctx, &buf, nil))
+	assert.Equal(t, "out1\nout2\n", buf.String())
+}
+
+func TestMuxCopyColor(t *testing.T) {
+	mux := NewMux[StdStream]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	stdout, _ := mux.Tag(Stdout)
+	stderr, _ := mux.Tag(Stderr)
+	ctx, cancelFn := context.WithCancel(context.Background())
+	go func() {
+		io.WriteString(stderr, "err1\n")
+		time.Sleep(sleepDuration)
+		io.WriteString(stdout, "out1\n")
+		time.Sleep(sleepDuration)
+		cancelFn()
+	}()
+	// not a terminal, so no colors
+	var buf bytes.Buffer
+	err := mux.CopyColor(ctx, &buf, func(tag StdStream) string {
+		return tag.String() + ": "
+	}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "stderr: err1\nstdout: out1\n", buf.String())
+}
+
+func TestMuxCopyLinesColor(t *testing.T) {
+	mux := NewMux[string]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	taga, _ := mux.Tag("a")
+	ctx, cancelFn := context.WithCancel(context.Background())
+	go func() {
+		io.WriteString(taga, "one\ntwo")
+		time.Sleep(sleepDuration)
+		cancelFn()
+	}()
+	var buf bytes.Buffer
+	err := mux.copyLines(ctx, &buf, func(tag string) string {
+		return ""
+	}, func(tag string) string {
+		return Colors[0]
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "\x1b[36mone\x1b[0m\n\x1b[36mtwo\x1b[0m\n", buf.String())
+}
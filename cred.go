@@ -0,0 +1,22 @@
+package iomux
+
+// Cred identifies the process that wrote data, see WithCredentials.
+type Cred struct {
+	Pid int32
+	Uid uint32
+	Gid uint32
+}
+
+// LastCred Return the credentials of the process that wrote the data last returned by Read, or nil if unknown, see
+// WithCredentials.
+func (mux *Mux[T]) LastCred() *Cred {
+	return mux.readsrc.cred
+}
+
+// sameCred reports whether a and b identify the same writer, treating unknown writers as the same.
+func sameCred(a, b *Cred) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
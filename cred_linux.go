@@ -0,0 +1,41 @@
+package iomux
+
+import (
+	"net"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// credOOBSize is the size of the out of band data holding the credentials of a message.
+var credOOBSize = unix.CmsgSpace(unix.SizeofUcred)
+
+// setPassCred enables SO_PASSCRED on conn, so the credentials of the writer are received with each message.
+func setPassCred(conn *net.UnixConn) error {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var sockErr error
+	err = raw.Control(func(fd uintptr) {
+		sockErr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_PASSCRED, 1)
+	})
+	if err != nil {
+		return err
+	}
+	return os.NewSyscallError("setsockopt", sockErr)
+}
+
+// parseCred returns the credentials in the out of band data of a message, or nil if there are none.
+func parseCred(oob []byte) *Cred {
+	msgs, err := unix.ParseSocketControlMessage(oob)
+	if err != nil {
+		return nil
+	}
+	for _, msg := range msgs {
+		if ucred, err := unix.ParseUnixCredentials(&msg); err == nil {
+			return &Cred{Pid: ucred.Pid, Uid: ucred.Uid, Gid: ucred.Gid}
+		}
+	}
+	return nil
+}
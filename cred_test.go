@@ -0,0 +1,71 @@
+//go:build linux
+
+package iomux
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMuxCredentials(t *testing.T) {
+	for _, network := range networks {
+		t.Run(network, func(t *testing.T) {
+			mux := newMux(network, []Option[string]{WithCredentials[string]()})
+			t.Cleanup(func() {
+				mux.Close()
+			})
+			out, err := mux.Tag("out")
+			if err != nil {
+				skipIfProtocolNotSupported(t, err)
+				assert.Nil(t, err)
+			}
+
+			// two processes share the tag, so their output is kept apart by pid
+			first := exec.Command("sh", "-c", "echo first")
+			first.Stdout = out
+			second := exec.Command("sh", "-c", "echo second")
+			second.Stdout = out
+			td, err := mux.ReadWhile(func() error {
+				if err := first.Run(); err != nil {
+					return err
+				}
+				if err := second.Run(); err != nil {
+					return err
+				}
+				return out.Close()
+			})
+			assert.Nil(t, err)
+			assert.Len(t, td, 2)
+			pids := map[string]int32{}
+			for _, d := range td {
+				if assert.NotNil(t, d.Cred) {
+					pids[string(d.Data)] = d.Cred.Pid
+					assert.Equal(t, uint32(os.Getuid()), d.Cred.Uid)
+				}
+			}
+			assert.Equal(t, map[string]int32{
+				"first\n":  int32(first.Process.Pid),
+				"second\n": int32(second.Process.Pid),
+			}, pids)
+		})
+	}
+}
+
+func TestMuxLastCred(t *testing.T) {
+	mux := NewMux[string](WithCredentials[string]())
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	taga, err := mux.Tag("a")
+	assert.Nil(t, err)
+	assert.Nil(t, mux.LastCred())
+
+	_, _ = taga.Write([]byte("hello"))
+	_, _, err = mux.Read(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, &Cred{Pid: int32(os.Getpid()), Uid: uint32(os.Getuid()), Gid: uint32(os.Getgid())}, mux.LastCred())
+}
@@ -0,0 +1,51 @@
+package iomux
+
+import (
+	"errors"
+	"fmt"
+	"net"
+)
+
+// TagError records an error and the operation and tag that caused it, so a failure of one writer can be told apart
+// from a failure of the whole Mux. Use errors.Is to check for the underlying error, and errors.As with the tag type to
+// get the tag, for example:
+//
+//	var tagErr *iomux.TagError[string]
+//	if errors.As(err, &tagErr) {
+//		log.Printf("writer for %s failed: %v", tagErr.Tag, tagErr.Err)
+//	}
+type TagError[T comparable] struct {
+	Tag T
+	// Op is the operation that failed, "tag" or "read".
+	Op  string
+	Err error
+}
+
+func (e *TagError[T]) Error() string {
+	return fmt.Sprintf("%s %v: %v", e.Op, e.Tag, e.Err)
+}
+
+func (e *TagError[T]) Unwrap() error {
+	return e.Err
+}
+
+// failed reports whether err only fails the connection of a writer rather than the read, when handled by
+// WithErrorHandler, returning the tag of the writer, which should then be treated as closed.
+func (mux *Mux[T]) failed(err error) (T, bool) {
+	var tagErr *TagError[T]
+	if mux.onError == nil || !errors.As(err, &tagErr) {
+		var zeroTag T
+		return zeroTag, false
+	}
+	mux.onError(tagErr.Tag, tagErr.Err)
+	return tagErr.Tag, true
+}
+
+// tagError wraps err with the tag of the writer connected to conn, if known.
+func (mux *Mux[T]) tagError(op string, conn *net.UnixConn, err error) error {
+	tag, ok := mux.tagOf(nil, conn)
+	if !ok {
+		return err
+	}
+	return &TagError[T]{Tag: tag, Op: op, Err: err}
+}
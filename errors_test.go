@@ -0,0 +1,66 @@
+//go:build !windows
+
+package iomux
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagError(t *testing.T) {
+	err := error(&TagError[string]{Tag: "a", Op: "tag", Err: MuxDuplicateTag})
+	assert.Equal(t, "tag a: mux tag already has a writer", err.Error())
+	assert.ErrorIs(t, err, MuxDuplicateTag)
+	var tagErr *TagError[string]
+	assert.True(t, errors.As(err, &tagErr))
+	assert.Equal(t, "a", tagErr.Tag)
+}
+
+func TestMuxTagError(t *testing.T) {
+	mux := NewMuxUnix[string]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	taga, _ := mux.Tag("a")
+	defer taga.Close()
+
+	conn := mux.receivers()[0].conn
+	err := mux.tagError("read", conn, syscall.ECONNRESET)
+	var tagErr *TagError[string]
+	assert.True(t, errors.As(err, &tagErr))
+	assert.Equal(t, "a", tagErr.Tag)
+	assert.Equal(t, "read", tagErr.Op)
+	assert.ErrorIs(t, err, syscall.ECONNRESET)
+
+	// errors not caused by a particular writer aren't wrapped
+	mux.Untag("a")
+	assert.Equal(t, syscall.ECONNRESET, mux.tagError("read", conn, syscall.ECONNRESET))
+}
+
+func TestMuxErrorHandler(t *testing.T) {
+	var tags []string
+	var errs []error
+	mux := NewMuxUnix[string](WithErrorHandler(func(tag string, err error) {
+		tags = append(tags, tag)
+		errs = append(errs, err)
+	}))
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	taga, _ := mux.Tag("a")
+	defer taga.Close()
+
+	// fail reads by closing the connection behind the mux's back
+	mux.receivers()[0].conn.Close()
+	_, _, err := mux.Read(context.Background())
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, []string{"a"}, tags)
+	assert.Equal(t, 1, len(errs))
+	assert.ErrorIs(t, errs[0], net.ErrClosed)
+}
@@ -0,0 +1,71 @@
+package iomux
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// TaggedEvent is a line of a tag, decoded into a structured event of type E when the tag has a format, see
+// EventReader.
+type TaggedEvent[T comparable, E any] struct {
+	Tag T
+	// Line is the line the event was decoded from, including its trailing newline.
+	Line []byte
+	// Event is decoded from Line when Decoded is set. Lines of tags without a format are passed through with Decoded
+	// unset, as are lines that fail to decode, with Err set.
+	Event   E
+	Decoded bool
+	Err     error
+}
+
+// EventReader splits the data read from a mux into lines, decoding those of the tags given a format into events of
+// type E, such as a map[string]any or a struct matching the log records of a tool.
+type EventReader[T comparable, E any] struct {
+	read     func(context.Context) ([]byte, T, error)
+	splitter lineSplitter[T]
+	lines    []*TaggedData[T]
+	formats  map[T]func(line []byte, event *E) error
+}
+
+// NewEventReader Create an EventReader of the data returned by read, typically the Read method of a mux.
+func NewEventReader[T comparable, E any](read func(context.Context) ([]byte, T, error)) *EventReader[T, E] {
+	return &EventReader[T, E]{read: read, formats: make(map[T]func([]byte, *E) error)}
+}
+
+// Format Decode the lines of tag with decode, such as DecodeJSON or DecodeLogfmt.
+func (r *EventReader[T, E]) Format(tag T, decode func(line []byte, event *E) error) *EventReader[T, E] {
+	r.formats[tag] = decode
+	return r
+}
+
+// Next Read the next line, decoding it if its tag has a format. Partial lines are returned once there is no more data
+// to read. Returns the error of read once no lines are left, such as io.EOF when ctx is done.
+func (r *EventReader[T, E]) Next(ctx context.Context) (*TaggedEvent[T, E], error) {
+	for len(r.lines) == 0 {
+		data, tag, err := r.read(ctx)
+		if err != nil {
+			if r.lines = r.splitter.flushAll(); len(r.lines) == 0 {
+				return nil, err
+			}
+			break
+		}
+		r.lines = r.splitter.split(tag, data)
+	}
+	td := r.lines[0]
+	r.lines[0] = nil
+	r.lines = r.lines[1:]
+	event := &TaggedEvent[T, E]{Tag: td.Tag, Line: td.Data}
+	if decode, ok := r.formats[td.Tag]; ok {
+		if event.Err = decode(td.Data, &event.Event); event.Err != nil {
+			var zero E
+			event.Event = zero
+		}
+		event.Decoded = event.Err == nil
+	}
+	return event, nil
+}
+
+// DecodeJSON Decode a line of JSON, for use with EventReader.Format.
+func DecodeJSON[E any](line []byte, event *E) error {
+	return json.Unmarshal(line, event)
+}
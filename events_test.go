@@ -0,0 +1,68 @@
+package iomux
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventReader(t *testing.T) {
+	mux := NewMemoryMux[string]()
+	app, _ := mux.Tag("app")
+	raw, _ := mux.Tag("raw")
+	io.WriteString(app, `{"level":"info","msg":"sta`)
+	io.WriteString(raw, "plain text\n")
+	io.WriteString(app, `rted"}`+"\nnot json\n")
+	io.WriteString(app, `{"level":"error","msg":"no newline"}`)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	r := NewEventReader[string, map[string]any](mux.Read).Format("app", DecodeJSON)
+
+	e, err := r.Next(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "raw", e.Tag)
+	assert.Equal(t, "plain text\n", string(e.Line))
+	assert.False(t, e.Decoded)
+	assert.Nil(t, e.Err)
+
+	e, err = r.Next(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "app", e.Tag)
+	assert.True(t, e.Decoded)
+	assert.Equal(t, map[string]any{"level": "info", "msg": "started"}, e.Event)
+
+	e, err = r.Next(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "not json\n", string(e.Line))
+	assert.False(t, e.Decoded)
+	assert.NotNil(t, e.Err)
+	assert.Nil(t, e.Event)
+
+	// the partial line is returned once there is nothing left to read
+	e, err = r.Next(ctx)
+	assert.Nil(t, err)
+	assert.True(t, e.Decoded)
+	assert.Equal(t, "no newline", e.Event["msg"])
+
+	_, err = r.Next(ctx)
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestEventReaderStruct(t *testing.T) {
+	type record struct {
+		Level string `json:"level"`
+		Msg   string `json:"msg"`
+	}
+	mux := NewMemoryMux[string]()
+	app, _ := mux.Tag("app")
+	io.WriteString(app, `{"level":"warn","msg":"low disk"}`+"\n")
+
+	r := NewEventReader[string, record](mux.Read).Format("app", DecodeJSON)
+	e, err := r.Next(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, record{Level: "warn", Msg: "low disk"}, e.Event)
+}
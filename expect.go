@@ -0,0 +1,84 @@
+package iomux
+
+import (
+	"context"
+	"regexp"
+	"slices"
+)
+
+// Match is data of a tag matching a pattern, see Expect.
+type Match[T comparable] struct {
+	Tag T
+	// Before is the data of Tag preceding the match.
+	Before []byte
+	// Text is the matching data, and Groups the text of the match and of its parenthesized subexpressions, as
+	// returned by regexp.Regexp.FindSubmatch.
+	Text   []byte
+	Groups [][]byte
+}
+
+// Expect Read until pattern matches the data of tag, returning the match and the data of tag before it. Data of other
+// tags, and of tag after the match, is left to be returned by the following reads. When ctx is done or reading fails
+// before a match, everything read is left to be read again, and the error is ctx.Err() or that of Read. Useful for
+// waiting on output such as "listening on :8080" in integration tests.
+func (mux *Mux[T]) Expect(ctx context.Context, tag T, pattern *regexp.Regexp) (*Match[T], error) {
+	var read []*TaggedData[T]
+	var buf []byte
+	for {
+		data, t, err := mux.Read(ctx)
+		if err != nil {
+			mux.unread(read)
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, err
+		}
+		td := &TaggedData[T]{Tag: t, Data: data, ConnID: mux.readsrc.conn, Cred: mux.readsrc.cred, Level: mux.readlevel}
+		if t != tag {
+			read = append(read, td)
+			continue
+		}
+		buf = append(buf, data...)
+		loc := pattern.FindSubmatchIndex(buf)
+		if loc == nil {
+			read = append(read, td)
+			continue
+		}
+		// the earlier data of tag is returned in the match
+		read = slices.DeleteFunc(read, func(d *TaggedData[T]) bool {
+			return d.Tag == tag
+		})
+		if rest := buf[loc[1]:]; len(rest) > 0 {
+			td.Data = rest
+			read = append(read, td)
+		}
+		mux.unread(read)
+		return newMatch(tag, buf, loc), nil
+	}
+}
+
+// newMatch returns the match of buf at loc, as returned by regexp.Regexp.FindSubmatchIndex.
+func newMatch[T comparable](tag T, buf []byte, loc []int) *Match[T] {
+	match := &Match[T]{Tag: tag, Before: buf[:loc[0]], Text: buf[loc[0]:loc[1]]}
+	for i := 0; i < len(loc); i += 2 {
+		if loc[i] >= 0 {
+			match.Groups = append(match.Groups, buf[loc[i]:loc[i+1]])
+		} else {
+			match.Groups = append(match.Groups, nil)
+		}
+	}
+	return match
+}
+
+// ExpectString Read until s appears in the data of tag, see Expect.
+func (mux *Mux[T]) ExpectString(ctx context.Context, tag T, s string) (*Match[T], error) {
+	return mux.Expect(ctx, tag, regexp.MustCompile(regexp.QuoteMeta(s)))
+}
+
+// unread queues td to be returned by the following reads, before any data already queued.
+func (mux *Mux[T]) unread(td []*TaggedData[T]) {
+	mux.pending = slices.Insert(mux.pending, 0, td...)
+	for _, d := range td {
+		mux.stats.buffer(len(d.Data))
+	}
+}
@@ -0,0 +1,65 @@
+package iomux
+
+import (
+	"context"
+	"io"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMuxExpect(t *testing.T) {
+	mux := NewMux[string]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	server, _ := mux.Tag("server")
+	client, _ := mux.Tag("client")
+
+	io.WriteString(server, "starting\nlistening on :80")
+	io.WriteString(client, "connecting")
+	io.WriteString(server, "80\nready\n")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	match, err := mux.Expect(ctx, "server", regexp.MustCompile(`listening on :(\d+)\n`))
+	assert.Nil(t, err)
+	assert.Equal(t, "server", match.Tag)
+	assert.Equal(t, "starting\n", string(match.Before))
+	assert.Equal(t, "listening on :8080\n", string(match.Text))
+	assert.Len(t, match.Groups, 2)
+	assert.Equal(t, "8080", string(match.Groups[1]))
+
+	// the data of other tags and after the match is still read, in order
+	data, tag, err := mux.Read(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "client", tag)
+	assert.Equal(t, "connecting", string(data))
+	data, tag, err = mux.Read(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "server", tag)
+	assert.Equal(t, "ready\n", string(data))
+}
+
+func TestMuxExpectTimeout(t *testing.T) {
+	mux := NewMux[string]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	server, _ := mux.Tag("server")
+	io.WriteString(server, "starting\n")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := mux.ExpectString(ctx, "server", "listening")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// nothing read while waiting is lost
+	io.WriteString(server, "listening\n")
+	match, err := mux.ExpectString(context.Background(), "server", "listening")
+	assert.Nil(t, err)
+	assert.Equal(t, "starting\n", string(match.Before))
+	assert.Equal(t, [][]byte{[]byte("listening")}, match.Groups)
+}
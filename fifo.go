@@ -0,0 +1,89 @@
+package iomux
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FIFOMux provides the same Tag and read API as Mux using a named pipe per writer rather than sockets, for
+// environments where unix sockets are blocked by security policy but named pipes are allowed. Each pipe is read
+// separately, like the connection oriented networks of Mux, so only the order for each tag is kept. Not supported on
+// Windows.
+type FIFOMux[T comparable] struct {
+	pipes *PipeMux[T]
+	dir   string
+	mutex sync.Mutex
+	num   int
+}
+
+// NewFIFOMux Create a new FIFOMux, creating its pipes in a new temporary directory.
+func NewFIFOMux[T comparable]() (*FIFOMux[T], error) {
+	dir, err := os.MkdirTemp("", "mux")
+	if err != nil {
+		return nil, err
+	}
+	return &FIFOMux[T]{pipes: NewPipeMux[T](), dir: dir}, nil
+}
+
+// Tag Create a named pipe to receive data tagged with tag T. Returns its write end as an *os.File, or an error.
+func (mux *FIFOMux[T]) Tag(tag T) (*os.File, error) {
+	mux.mutex.Lock()
+	defer mux.mutex.Unlock()
+	if mux.pipes.mem.isClosed() {
+		return nil, MuxClosed
+	}
+	mux.num++
+	path := filepath.Join(mux.dir, fmt.Sprintf("send_%d.fifo", mux.num))
+	if err := mkfifo(path); err != nil {
+		return nil, &TagError[T]{Tag: tag, Op: "tag", Err: err}
+	}
+	r, err := openFifo(path)
+	if err != nil {
+		return nil, &TagError[T]{Tag: tag, Op: "tag", Err: err}
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		r.Close()
+		return nil, &TagError[T]{Tag: tag, Op: "tag", Err: err}
+	}
+	if err := mux.pipes.copy(tag, r); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+// Read perform a read, blocking until data is available or ctx.Done. Returns io.EOF error when ctx is done and there
+// is no data remaining to be read.
+func (mux *FIFOMux[T]) Read(ctx context.Context) ([]byte, T, error) {
+	return mux.pipes.Read(ctx)
+}
+
+// ReadWhile Read until waitFn returns, returning the read data.
+func (mux *FIFOMux[T]) ReadWhile(waitFn func() error) ([]*TaggedData[T], error) {
+	return mux.pipes.ReadWhile(waitFn)
+}
+
+// ReadUntil Read until ctx is done, returning the read data.
+func (mux *FIFOMux[T]) ReadUntil(ctx context.Context) ([]*TaggedData[T], error) {
+	return mux.pipes.ReadUntil(ctx)
+}
+
+// All Read until ctx is done, yielding each chunk and its tag as it arrives, for use with range.
+func (mux *FIFOMux[T]) All(ctx context.Context) iter.Seq2[T, []byte] {
+	return mux.pipes.All(ctx)
+}
+
+// Close closes the FIFOMux, closing the read end of every pipe and removing them. Prevents reuse.
+func (mux *FIFOMux[T]) Close() error {
+	mux.mutex.Lock()
+	defer mux.mutex.Unlock()
+	if err := mux.pipes.Close(); err != nil {
+		return err
+	}
+	return os.RemoveAll(mux.dir)
+}
@@ -0,0 +1,63 @@
+//go:build !windows
+
+package iomux
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFIFOMux(t *testing.T) {
+	mux, err := NewFIFOMux[string]()
+	assert.Nil(t, err)
+	a, err := mux.Tag("a")
+	assert.Nil(t, err)
+	b, err := mux.Tag("b")
+	assert.Nil(t, err)
+
+	_, _ = a.Write([]byte("hello"))
+	_, _ = b.Write([]byte("world"))
+	a.Close()
+	b.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	td, err := mux.ReadUntil(ctx)
+	assert.Nil(t, err)
+	got := map[string]string{}
+	for _, d := range td {
+		got[d.Tag] += string(d.Data)
+	}
+	assert.Equal(t, map[string]string{"a": "hello", "b": "world"}, got)
+	assert.Nil(t, mux.Close())
+}
+
+func TestFIFOMuxCommand(t *testing.T) {
+	mux, err := NewFIFOMux[string]()
+	assert.Nil(t, err)
+	defer mux.Close()
+	stdout, err := mux.Tag("out")
+	assert.Nil(t, err)
+
+	cmd := exec.Command("sh", "-c", "echo hello")
+	cmd.Stdout = stdout
+	assert.Nil(t, cmd.Start())
+	stdout.Close()
+
+	td, err := mux.ReadWhile(cmd.Wait)
+	assert.Nil(t, err)
+	assert.Len(t, td, 1)
+	assert.Equal(t, "hello\n", string(td[0].Data))
+}
+
+func TestFIFOMuxClosed(t *testing.T) {
+	mux, err := NewFIFOMux[string]()
+	assert.Nil(t, err)
+	assert.Nil(t, mux.Close())
+	_, err = mux.Tag("a")
+	assert.ErrorIs(t, err, MuxClosed)
+}
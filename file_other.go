@@ -0,0 +1,51 @@
+//go:build !unix && !windows
+
+package iomux
+
+import (
+	"errors"
+	"net"
+	"os"
+)
+
+// defaultNetwork is only a placeholder, as sockets can't be created on this platform.
+const defaultNetwork = "unix"
+
+// senderFile is not supported, as sockets can't be converted to files on this platform.
+func senderFile(conn *net.UnixConn) (*os.File, error) {
+	conn.Close()
+	return nil, errors.ErrUnsupported
+}
+
+// msgTrunc is never set, as this platform has no 'unixgram' network.
+const msgTrunc = 0
+
+// readConn is never called as this platform has no poller, see newPoller.
+func readConn(conn *net.UnixConn, buf, oob []byte) (int, int, bool, error) {
+	return 0, 0, false, errors.ErrUnsupported
+}
+
+// connReadable is never called, as sockets can't be created on this platform.
+func connReadable(conn *net.UnixConn) bool {
+	return true
+}
+
+// socketpair is not supported, as this platform has no AF_UNIX socket pairs.
+func socketpair(network string) (*net.UnixConn, *os.File, error) {
+	return nil, nil, errors.ErrUnsupported
+}
+
+// processAlive reports whether the process pid exists, which is assumed, as this platform can't check.
+func processAlive(pid int) bool {
+	return true
+}
+
+// mkfifo is not supported, as this platform has no named pipes in the file system.
+func mkfifo(path string) error {
+	return errors.ErrUnsupported
+}
+
+// openFifo is never called, as mkfifo always fails.
+func openFifo(path string) (*os.File, error) {
+	return nil, errors.ErrUnsupported
+}
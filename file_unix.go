@@ -0,0 +1,125 @@
+//go:build unix
+
+package iomux
+
+import (
+	"net"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+const defaultNetwork = "unixgram"
+
+// senderFile returns a file sharing the socket of conn, so writes go straight to the receiver. conn is closed, leaving
+// the file as the only reference to the socket.
+func senderFile(conn *net.UnixConn) (*os.File, error) {
+	defer conn.Close()
+	return conn.File()
+}
+
+// connFd returns the file descriptor of conn, which remains valid until conn is closed.
+func connFd(conn *net.UnixConn) (int, error) {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+	var fd int
+	err = raw.Control(func(f uintptr) {
+		fd = int(f)
+	})
+	return fd, err
+}
+
+// msgTrunc is set in the flags of a read when a datagram was larger than the buffer.
+const msgTrunc = unix.MSG_TRUNC
+
+// readConn performs a single non-blocking read from conn, returning errWouldBlock if nothing was ready, and a zero
+// count once the sender has closed. Returns the size of the out of band data read into oob, and reports whether a
+// datagram was truncated by buf being too small.
+func readConn(conn *net.UnixConn, buf, oob []byte) (int, int, bool, error) {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return 0, 0, false, err
+	}
+	var n, oobn, flags int
+	var readErr error
+	// Control rather than Read, as readiness comes from the poller, so the read deadline of conn doesn't apply
+	err = raw.Control(func(fd uintptr) {
+		n, oobn, flags, _, readErr = unix.Recvmsg(int(fd), buf, oob, 0)
+	})
+	if err != nil {
+		return 0, 0, false, err
+	}
+	if readErr == unix.EAGAIN {
+		return 0, 0, false, errWouldBlock
+	}
+	if readErr != nil {
+		return 0, 0, false, os.NewSyscallError("recvmsg", readErr)
+	}
+	return n, oobn, flags&msgTrunc != 0, nil
+}
+
+// connReadable reports whether conn has data to read, or has been closed by the sender, polling it without waiting.
+func connReadable(conn *net.UnixConn) bool {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return true
+	}
+	ready := true
+	_ = raw.Control(func(fd uintptr) {
+		fds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}
+		n, err := unix.Poll(fds, 0)
+		ready = err != nil || (n > 0 && fds[0].Revents != 0)
+	})
+	return ready
+}
+
+// socketpair creates a connected pair of sockets of the type for network, returning the receive end as a conn, and
+// the send end as a file.
+func socketpair(network string) (*net.UnixConn, *os.File, error) {
+	var sotype int
+	switch network {
+	case "unix":
+		sotype = unix.SOCK_STREAM
+	case "unixgram":
+		sotype = unix.SOCK_DGRAM
+	case "unixpacket":
+		sotype = unix.SOCK_SEQPACKET
+	default:
+		return nil, nil, net.UnknownNetworkError(network)
+	}
+	fds, err := unix.Socketpair(unix.AF_UNIX, sotype, 0)
+	if err != nil {
+		return nil, nil, os.NewSyscallError("socketpair", err)
+	}
+	unix.CloseOnExec(fds[0])
+	unix.CloseOnExec(fds[1])
+	_ = unix.Shutdown(fds[0], unix.SHUT_WR)
+	_ = unix.Shutdown(fds[1], unix.SHUT_RD)
+	recv := os.NewFile(uintptr(fds[0]), "recv")
+	defer recv.Close()
+	conn, err := net.FileConn(recv)
+	if err != nil {
+		unix.Close(fds[1])
+		return nil, nil, err
+	}
+	return conn.(*net.UnixConn), os.NewFile(uintptr(fds[1]), "send"), nil
+}
+
+// processAlive reports whether the process pid exists.
+func processAlive(pid int) bool {
+	err := unix.Kill(pid, 0)
+	return err == nil || err == unix.EPERM
+}
+
+// mkfifo creates a named pipe at path.
+func mkfifo(path string) error {
+	return unix.Mkfifo(path, 0o600)
+}
+
+// openFifo opens the read end of the named pipe at path without blocking, so opening the write end doesn't wait for a
+// reader.
+func openFifo(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_RDONLY|unix.O_NONBLOCK, 0)
+}
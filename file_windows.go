@@ -0,0 +1,67 @@
+//go:build windows
+
+package iomux
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+)
+
+// Windows only supports connection oriented AF_UNIX sockets.
+const defaultNetwork = "unix"
+
+// senderFile returns a file for writing to conn. Sockets cannot be converted to files on Windows, so writes go through
+// a pipe that is copied to conn until every copy of the write end has been closed, and conn is then closed.
+func senderFile(conn *net.UnixConn) (*os.File, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	go func() {
+		_, _ = io.Copy(conn, r)
+		r.Close()
+		conn.Close()
+	}()
+	return w, nil
+}
+
+// msgTrunc is never set, as Windows has no 'unixgram' network.
+const msgTrunc = 0
+
+// readConn is never called as Windows has no poller, see newPoller.
+func readConn(conn *net.UnixConn, buf, oob []byte) (int, int, bool, error) {
+	return 0, 0, false, errors.ErrUnsupported
+}
+
+// connReadable reports true, as readiness can't be checked on Windows, so TryRead reads with a short deadline instead.
+func connReadable(conn *net.UnixConn) bool {
+	return true
+}
+
+// socketpair is not supported, as Windows has no AF_UNIX socket pairs.
+func socketpair(network string) (*net.UnixConn, *os.File, error) {
+	return nil, nil, errors.ErrUnsupported
+}
+
+// processAlive reports whether the process pid exists.
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	p.Release()
+	return true
+}
+
+// mkfifo is not supported, as Windows has no named pipes in the file system.
+func mkfifo(path string) error {
+	return errors.ErrUnsupported
+}
+
+// openFifo is never called, as mkfifo always fails.
+func openFifo(path string) (*os.File, error) {
+	return nil, errors.ErrUnsupported
+}
@@ -0,0 +1,39 @@
+//go:build windows
+
+package iomux
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMuxReadWindows(t *testing.T) {
+	mux := &Mux[string]{}
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	taga, err := mux.Tag("a")
+	assert.Nil(t, err)
+	tagb, err := mux.Tag("b")
+	assert.Nil(t, err)
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	io.WriteString(taga, "hello taga")
+	bytes, tag, err := mux.Read(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "a", tag)
+	assert.Equal(t, "hello taga", string(bytes))
+
+	io.WriteString(tagb, "hello tagb")
+	bytes, tag, err = mux.Read(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "b", tag)
+	assert.Equal(t, "hello tagb", string(bytes))
+
+	cancelFn()
+	_, _, err = mux.Read(ctx)
+	assert.Equal(t, io.EOF, err)
+}
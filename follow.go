@@ -0,0 +1,33 @@
+package iomux
+
+import (
+	"context"
+	"io"
+	"iter"
+	"time"
+)
+
+// Follow Read until ctx is done, yielding each chunk and its tag as it arrives like All, but rather than ending once
+// every writer has been closed, wait for new writers from Tag, like tail -f. For streaming the output of processes
+// that are restarted, where the Mux outlives each set of writers.
+func (mux *Mux[T]) Follow(ctx context.Context) iter.Seq2[T, []byte] {
+	return all(ctx, mux.follow)
+}
+
+// follow reads like Read, waiting for Tag to add writers when there are none left to read.
+func (mux *Mux[T]) follow(ctx context.Context) ([]byte, T, error) {
+	for {
+		gen := mux.recvgen.Load()
+		data, tag, err := mux.Read(ctx)
+		if (err != io.EOF && err != MuxNoConnections) || ctx.Err() != nil {
+			return data, tag, err
+		}
+		for gen == mux.recvgen.Load() {
+			select {
+			case <-ctx.Done():
+				return nil, tag, io.EOF
+			case <-time.After(deadlineDuration):
+			}
+		}
+	}
+}
@@ -0,0 +1,53 @@
+//go:build !windows
+
+package iomux
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMuxFollow(t *testing.T) {
+	for _, network := range networks {
+		t.Run(network, func(t *testing.T) {
+			mux := &Mux[string]{network: network}
+			t.Cleanup(func() {
+				mux.Close()
+			})
+			taga, err := mux.Tag("a")
+			if err != nil {
+				skipIfProtocolNotSupported(t, err)
+				assert.Nil(t, err)
+			}
+			io.WriteString(taga, "hello taga")
+			taga.Close()
+
+			ctx, cancelFn := context.WithCancel(context.Background())
+			defer cancelFn()
+			received := make(chan string)
+			go func() {
+				for tag, data := range mux.Follow(ctx) {
+					received <- tag + ":" + string(data)
+				}
+				close(received)
+			}()
+			assert.Equal(t, "a:hello taga", <-received)
+
+			// every writer has been closed, but following continues with writers created later
+			time.Sleep(sleepDuration)
+			tagb, err := mux.Tag("b")
+			assert.Nil(t, err)
+			io.WriteString(tagb, "hello tagb")
+			tagb.Close()
+			assert.Equal(t, "b:hello tagb", <-received)
+
+			cancelFn()
+			_, ok := <-received
+			assert.False(t, ok)
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package iomux
+
+import (
+	"io"
+	"os"
+)
+
+// Each fragment starts with a header byte marking whether more of the same write follows.
+const (
+	fragmentFinal byte = iota
+	fragmentMore
+)
+
+// fragmented reports whether writers fragment their writes, see WithFragmentation.
+func (mux *Mux[T]) fragmented() bool {
+	return mux.fragment && mux.network != "unix"
+}
+
+// fragmentFile returns the write end of a pipe, copying what is written to w in messages of at most size bytes,
+// including a header so reassemble can join them back up. w is closed once every copy of the write end has been
+// closed.
+func fragmentFile(w io.WriteCloser, size int) (*os.File, error) {
+	r, pw, err := os.Pipe()
+	if err != nil {
+		w.Close()
+		return nil, err
+	}
+	go func() {
+		defer w.Close()
+		defer r.Close()
+		buf := make([]byte, 65536)
+		frame := make([]byte, size)
+		for {
+			n, err := r.Read(buf)
+			for data := buf[:n]; len(data) > 0; {
+				m := copy(frame[1:], data)
+				data = data[m:]
+				frame[0] = fragmentFinal
+				if len(data) > 0 {
+					frame[0] = fragmentMore
+				}
+				if _, err := w.Write(frame[:m+1]); err != nil {
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return pw, nil
+}
+
+// reassemble joins fragments received from sender, returning the data once the final fragment of a write arrives.
+func (mux *Mux[T]) reassemble(sender any, frame []byte) ([]byte, bool) {
+	if len(frame) == 0 {
+		return nil, false
+	}
+	partial := mux.fragments[sender]
+	if frame[0] == fragmentMore {
+		if mux.fragments == nil {
+			mux.fragments = make(map[any][]byte)
+		}
+		mux.fragments[sender] = append(partial, frame[1:]...)
+		return nil, false
+	}
+	if partial == nil {
+		return mux.copyData(frame[1:], nil), true
+	}
+	delete(mux.fragments, sender)
+	return append(partial, frame[1:]...), true
+}
@@ -0,0 +1,62 @@
+//go:build !windows
+
+package iomux
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMuxFragmentation(t *testing.T) {
+	for _, network := range networks {
+		t.Run(network, func(t *testing.T) {
+			mux := newMux(network, []Option[string]{WithFragmentation[string]()})
+			t.Cleanup(func() {
+				mux.Close()
+			})
+			taga, err := mux.Tag("a")
+			if err != nil {
+				skipIfProtocolNotSupported(t, err)
+				assert.Nil(t, err)
+			}
+			tagb, err := mux.Tag("b")
+			assert.Nil(t, err)
+
+			large := bytes.Repeat([]byte("0123456789"), 50000)
+			go func() {
+				taga.Write(large)
+				tagb.WriteString("hello tagb")
+				taga.Close()
+				tagb.Close()
+			}()
+			// writes are copied to the socket in the background, so drain rather than racing them with ReadWhile
+			td, err := mux.Drain(100 * time.Millisecond)
+			assert.Nil(t, err)
+			received := make(map[string][]byte)
+			for _, d := range td {
+				received[d.Tag] = append(received[d.Tag], d.Data...)
+			}
+			assert.Equal(t, large, received["a"])
+			assert.Equal(t, "hello tagb", string(received["b"]))
+			assert.Zero(t, mux.Stats().Truncated)
+		})
+	}
+}
+
+func TestMuxReassemble(t *testing.T) {
+	mux := NewMux[string]()
+	data, ok := mux.reassemble("a", []byte{fragmentMore, 'a', 'b'})
+	assert.False(t, ok)
+	data, ok = mux.reassemble("b", []byte{fragmentFinal, 'x'})
+	assert.True(t, ok)
+	assert.Equal(t, "x", string(data))
+	data, ok = mux.reassemble("a", []byte{fragmentMore, 'c'})
+	assert.False(t, ok)
+	data, ok = mux.reassemble("a", []byte{fragmentFinal, 'd'})
+	assert.True(t, ok)
+	assert.Equal(t, "abcd", string(data))
+	assert.Empty(t, mux.fragments)
+}
@@ -0,0 +1,115 @@
+package iomux
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sync"
+)
+
+// GroupTag tags the output of a command in a Group with the name it was added with and the stream it was written to.
+type GroupTag struct {
+	Name   string
+	Stream StdStream
+}
+
+func (t GroupTag) String() string {
+	return t.Name + ":" + t.Stream.String()
+}
+
+// Group Run several commands concurrently, capturing the stdout and stderr of all of them in the order they were
+// written, tagged by command name and stream.
+type Group struct {
+	opts  []Option[GroupTag]
+	limit int
+	names map[string]bool
+	cmds  []groupCmd
+}
+
+type groupCmd struct {
+	name string
+	cmd  *exec.Cmd
+}
+
+// NewGroup Create a new Group, with opts applied to the Mux its commands write to.
+func NewGroup(opts ...Option[GroupTag]) *Group {
+	return &Group{opts: opts, names: make(map[string]bool)}
+}
+
+// SetLimit Limit the number of commands running at once to n, a limit of 0 or less runs every command at once.
+func (g *Group) SetLimit(n int) {
+	g.limit = n
+}
+
+// Add Add cmd to the group under name, which must be unique within the group. The command's stdout and stderr must
+// not already be set.
+func (g *Group) Add(name string, cmd *exec.Cmd) error {
+	if g.names[name] {
+		return fmt.Errorf("iomux: command %q already added", name)
+	}
+	if cmd.Stdout != nil {
+		return errors.New("exec: Stdout already set")
+	}
+	if cmd.Stderr != nil {
+		return errors.New("exec: Stderr already set")
+	}
+	g.names[name] = true
+	g.cmds = append(g.cmds, groupCmd{name, cmd})
+	return nil
+}
+
+// Run Run every command, waiting for them all to exit, and return their output in the order it was written. Commands
+// are terminated as described by Run when ctx is done. The error joins the error of each command that failed, prefixed
+// with its name.
+func (g *Group) Run(ctx context.Context) ([]*TaggedData[GroupTag], error) {
+	mux := NewMux[GroupTag](g.opts...)
+	defer mux.Close()
+	for _, c := range g.cmds {
+		stdout, err := mux.Tag(GroupTag{c.name, Stdout})
+		if err != nil {
+			return nil, err
+		}
+		defer stdout.Close()
+		stderr, err := mux.Tag(GroupTag{c.name, Stderr})
+		if err != nil {
+			return nil, err
+		}
+		defer stderr.Close()
+		c.cmd.Stdout = stdout
+		c.cmd.Stderr = stderr
+	}
+	return mux.ReadWhile(func() error {
+		limit := g.limit
+		if limit <= 0 {
+			limit = len(g.cmds)
+		}
+		sem := make(chan struct{}, limit)
+		errs := make([]error, len(g.cmds))
+		var wg sync.WaitGroup
+		for i, c := range g.cmds {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				select {
+				case sem <- struct{}{}:
+				case <-ctx.Done():
+					errs[i] = fmt.Errorf("%s: %w", c.name, ctx.Err())
+					return
+				}
+				defer func() {
+					<-sem
+				}()
+				if err := c.cmd.Start(); err != nil {
+					errs[i] = fmt.Errorf("%s: %w", c.name, err)
+					return
+				}
+				if err := Wait(ctx, c.cmd); err != nil {
+					errs[i] = fmt.Errorf("%s: %w", c.name, err)
+				}
+			}()
+		}
+		wg.Wait()
+		return errors.Join(errs...)
+	})
+}
@@ -0,0 +1,87 @@
+//go:build !windows
+
+package iomux
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroup(t *testing.T) {
+	g := NewGroup()
+	assert.Nil(t, g.Add("a", exec.Command("sh", "-c", "echo a1")))
+	assert.Nil(t, g.Add("b", exec.Command("sh", "-c", "sleep 0.1 && echo b1 1>&2")))
+	assert.Nil(t, g.Add("c", exec.Command("sh", "-c", "sleep 0.2 && echo c1")))
+
+	td, err := g.Run(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(td))
+	assert.Equal(t, GroupTag{"a", Stdout}, td[0].Tag)
+	assert.Equal(t, "a1\n", string(td[0].Data))
+	assert.Equal(t, GroupTag{"b", Stderr}, td[1].Tag)
+	assert.Equal(t, "b1\n", string(td[1].Data))
+	assert.Equal(t, GroupTag{"c", Stdout}, td[2].Tag)
+	assert.Equal(t, "c1\n", string(td[2].Data))
+	assert.Equal(t, "b:stderr", td[1].Tag.String())
+}
+
+func TestGroupAdd(t *testing.T) {
+	g := NewGroup()
+	assert.Nil(t, g.Add("a", exec.Command("true")))
+	assert.EqualError(t, g.Add("a", exec.Command("true")), `iomux: command "a" already added`)
+	cmd := exec.Command("true")
+	cmd.Stdout = io.Discard
+	assert.EqualError(t, g.Add("b", cmd), "exec: Stdout already set")
+}
+
+func TestGroupErrors(t *testing.T) {
+	g := NewGroup()
+	assert.Nil(t, g.Add("ok", exec.Command("true")))
+	assert.Nil(t, g.Add("fail", exec.Command("sh", "-c", "exit 2")))
+
+	_, err := g.Run(context.Background())
+	var exitErr *exec.ExitError
+	assert.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 2, exitErr.ExitCode())
+	assert.EqualError(t, err, "fail: exit status 2")
+}
+
+func TestGroupLimit(t *testing.T) {
+	g := NewGroup()
+	g.SetLimit(1)
+	for i := range 3 {
+		assert.Nil(t, g.Add(fmt.Sprint(i), exec.Command("sh", "-c", "echo start && sleep 0.05 && echo end")))
+	}
+	td, err := g.Run(context.Background())
+	assert.Nil(t, err)
+	// with a limit of 1 each command's output is contiguous
+	var out []string
+	for i, d := range td {
+		if i > 0 && td[i-1].Tag == d.Tag {
+			out[len(out)-1] += string(d.Data)
+		} else {
+			out = append(out, string(d.Data))
+		}
+	}
+	assert.Equal(t, []string{"start\nend\n", "start\nend\n", "start\nend\n"}, out)
+}
+
+func TestGroupCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	g := NewGroup()
+	g.SetLimit(1)
+	assert.Nil(t, g.Add("a", exec.Command("sleep", "10")))
+	assert.Nil(t, g.Add("b", exec.Command("sleep", "10")))
+
+	start := time.Now()
+	_, err := g.Run(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
@@ -0,0 +1,86 @@
+//go:build unix
+
+package iomux
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// SendTag Create a writer for tag like Tag, and send it to the process at the other end of conn with SendWriter,
+// named by formatting tag with fmt.Sprint. The local copy of the writer is closed, unless shared due to
+// DuplicateTagExisting, so closing the writer in the other process is reported to WithCloseHandler. Lets a supervisor
+// hand out tags to processes it didn't start, such as existing daemons.
+func (mux *Mux[T]) SendTag(conn *net.UnixConn, tag T) error {
+	file, err := mux.Tag(tag)
+	if err != nil {
+		return err
+	}
+	err = SendWriter(conn, file, fmt.Sprint(tag))
+	if mux.duplicatePolicy != DuplicateTagExisting {
+		file.Close()
+	}
+	return err
+}
+
+// SendWriter Send the file descriptor of w, typically a writer returned by Tag, and name to the process at the other end
+// of conn with SCM_RIGHTS, to be received with ReceiveWriter. The caller still owns w.
+func SendWriter(conn *net.UnixConn, w *os.File, name string) error {
+	raw, err := w.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var rights []byte
+	if err := raw.Control(func(fd uintptr) {
+		rights = unix.UnixRights(int(fd))
+	}); err != nil {
+		return err
+	}
+	// at least one byte of data must be sent with the rights
+	_, _, err = conn.WriteMsgUnix(append([]byte{0}, name...), rights, nil)
+	return err
+}
+
+// ReceiveWriter Receive a writer sent with SendWriter or SendTag over conn, returning it and its name. Writes to the
+// returned file are read by the Mux of the sending process, tagged with the tag it was sent for.
+func ReceiveWriter(conn *net.UnixConn) (*os.File, string, error) {
+	buf := make([]byte, 4096)
+	oob := make([]byte, unix.CmsgSpace(4))
+	n, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+	if err != nil {
+		return nil, "", err
+	}
+	if n == 0 && oobn == 0 {
+		return nil, "", io.EOF
+	}
+	if oobn == 0 && buf[0] != 0 {
+		// an error sent in place of a writer, see ServeChildren
+		return nil, "", fmt.Errorf("iomux: %s", strings.TrimSpace(string(buf[:n])))
+	}
+	msgs, err := unix.ParseSocketControlMessage(oob[:oobn])
+	if err != nil {
+		return nil, "", os.NewSyscallError("parse socket control message", err)
+	}
+	for _, msg := range msgs {
+		fds, err := unix.ParseUnixRights(&msg)
+		if err != nil || len(fds) == 0 {
+			continue
+		}
+		for _, fd := range fds[1:] {
+			unix.Close(fd)
+		}
+		unix.CloseOnExec(fds[0])
+		var name string
+		if n > 1 {
+			name = string(buf[1:n])
+		}
+		return os.NewFile(uintptr(fds[0]), name), name, nil
+	}
+	return nil, "", errors.New("iomux: no file descriptor received")
+}
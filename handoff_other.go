@@ -0,0 +1,24 @@
+//go:build !unix
+
+package iomux
+
+import (
+	"errors"
+	"net"
+	"os"
+)
+
+// SendTag is not supported, as only unix platforms have SCM_RIGHTS.
+func (mux *Mux[T]) SendTag(conn *net.UnixConn, tag T) error {
+	return errors.ErrUnsupported
+}
+
+// SendWriter is not supported, as only unix platforms have SCM_RIGHTS.
+func SendWriter(conn *net.UnixConn, w *os.File, name string) error {
+	return errors.ErrUnsupported
+}
+
+// ReceiveWriter is not supported, as only unix platforms have SCM_RIGHTS.
+func ReceiveWriter(conn *net.UnixConn) (*os.File, string, error) {
+	return nil, "", errors.ErrUnsupported
+}
@@ -0,0 +1,64 @@
+//go:build !windows
+
+package iomux
+
+import (
+	"io"
+	"net"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sys/unix"
+)
+
+// connPair returns both ends of a connected unix socket pair.
+func connPair(t *testing.T) (*net.UnixConn, *net.UnixConn) {
+	fds, err := unix.Socketpair(unix.AF_UNIX, unix.SOCK_STREAM, 0)
+	assert.Nil(t, err)
+	conns := make([]*net.UnixConn, 2)
+	for i, fd := range fds {
+		file := os.NewFile(uintptr(fd), "pair")
+		conn, err := net.FileConn(file)
+		assert.Nil(t, err)
+		file.Close()
+		conns[i] = conn.(*net.UnixConn)
+		t.Cleanup(func() {
+			conns[i].Close()
+		})
+	}
+	return conns[0], conns[1]
+}
+
+func TestMuxSendTag(t *testing.T) {
+	var closed []string
+	mux := NewMuxUnix[string](WithCloseHandler(func(tag string) {
+		closed = append(closed, tag)
+	}))
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	supervisor, daemon := connPair(t)
+
+	assert.Nil(t, mux.SendTag(supervisor, "daemon"))
+	w, name, err := ReceiveWriter(daemon)
+	assert.Nil(t, err)
+	assert.Equal(t, "daemon", name)
+
+	td, err := mux.ReadWhile(func() error {
+		io.WriteString(w, "hello from the daemon")
+		return w.Close()
+	})
+	assert.Nil(t, err)
+	assert.Len(t, td, 1)
+	assert.Equal(t, "daemon", td[0].Tag)
+	assert.Equal(t, "hello from the daemon", string(td[0].Data))
+	assert.Equal(t, []string{"daemon"}, closed)
+}
+
+func TestReceiveWriterClosed(t *testing.T) {
+	supervisor, daemon := connPair(t)
+	supervisor.Close()
+	_, _, err := ReceiveWriter(daemon)
+	assert.ErrorIs(t, err, io.EOF)
+}
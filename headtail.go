@@ -0,0 +1,63 @@
+package iomux
+
+import (
+	"fmt"
+	"slices"
+)
+
+// headTail keeps the first head and last tail bytes of each tag, replacing the data in between with a marker.
+type headTail[T comparable] struct {
+	head, tail int
+	sizes      map[T]int
+	markers    map[T]*TaggedData[T]
+}
+
+func newHeadTail[T comparable](head, tail int) *headTail[T] {
+	return &headTail[T]{
+		head:    head,
+		tail:    tail,
+		sizes:   make(map[T]int),
+		markers: make(map[T]*TaggedData[T]),
+	}
+}
+
+// add accounts for n bytes just added to td for tag, discarding data past the head that is no longer in the tail,
+// reporting it to dropped if not nil. Returns td and the number of bytes discarded.
+func (h *headTail[T]) add(td []*TaggedData[T], tag T, n int, dropped func(tag T, n int)) ([]*TaggedData[T], int) {
+	h.sizes[tag] += n
+	over := h.sizes[tag] - h.head - h.tail
+	if over <= 0 {
+		return td, 0
+	}
+	h.sizes[tag] -= over
+	marker, ok := h.markers[tag]
+	if !ok {
+		marker = &TaggedData[T]{Tag: tag}
+		h.markers[tag] = marker
+		td = insertMarker(td, marker, h.head)
+	}
+	i := slices.Index(td, marker)
+	td = append(td[:i+1], dropOldestTag(td[i+1:], tag, over, dropped)...)
+	marker.Truncated += over
+	marker.Data = []byte(fmt.Sprintf("[... %d bytes truncated ...]\n", marker.Truncated))
+	return td, over
+}
+
+// insertMarker inserts marker after the first head bytes for the tag of marker, splitting data if needed.
+func insertMarker[T comparable](td []*TaggedData[T], marker *TaggedData[T], head int) []*TaggedData[T] {
+	for i, d := range td {
+		if d.Tag != marker.Tag {
+			continue
+		}
+		if head == 0 {
+			return slices.Insert(td, i, marker)
+		}
+		if len(d.Data) > head {
+			rest := &TaggedData[T]{Tag: d.Tag, Data: d.Data[head:], Seq: d.Seq, Time: d.Time}
+			d.Data = d.Data[:head:head]
+			return slices.Insert(td, i+1, marker, rest)
+		}
+		head -= len(d.Data)
+	}
+	return append(td, marker)
+}
@@ -0,0 +1,58 @@
+//go:build !windows
+
+package iomux
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeadTail(t *testing.T) {
+	ht := newHeadTail[string](3, 2)
+	var td []*TaggedData[string]
+	add := func(tag, data string) int {
+		td = append(td, &TaggedData[string]{Tag: tag, Data: []byte(data)})
+		var over int
+		td, over = ht.add(td, tag, len(data), nil)
+		return over
+	}
+	assert.Equal(t, 0, add("a", "ab"))
+	assert.Equal(t, 0, add("b", "xyz"))
+	assert.Equal(t, 0, add("a", "cde"))
+	assert.Equal(t, 2, add("a", "fg"))
+	assert.Equal(t, 3, add("a", "hij"))
+
+	var data []string
+	for _, d := range td {
+		data = append(data, d.Tag+":"+string(d.Data))
+	}
+	assert.Equal(t, []string{"a:ab", "b:xyz", "a:c", "a:[... 5 bytes truncated ...]\n", "a:ij"}, data)
+	assert.Equal(t, 5, td[3].Truncated)
+}
+
+func TestMuxHeadTail(t *testing.T) {
+	mux := NewMuxUnixGram[string](WithHeadTail[string](7, 7))
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	taga, _ := mux.Tag("a")
+	tagb, _ := mux.Tag("b")
+
+	td, err := mux.ReadWhile(func() error {
+		io.WriteString(taga, "line 1\n")
+		io.WriteString(tagb, "b\n")
+		io.WriteString(taga, "line 2\n")
+		io.WriteString(taga, "line 3\n")
+		io.WriteString(taga, "line 4\n")
+		return nil
+	})
+	assert.Nil(t, err)
+	var data []string
+	for _, d := range td {
+		data = append(data, d.Tag+":"+string(d.Data))
+	}
+	assert.Equal(t, []string{"a:line 1\n", "b:b\n", "a:[... 14 bytes truncated ...]\n", "a:line 4\n"}, data)
+	assert.Equal(t, uint64(14), mux.Stats().Dropped)
+}
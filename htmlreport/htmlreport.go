@@ -0,0 +1,108 @@
+// Package htmlreport renders captured output as standalone HTML pages, for attaching to CI runs as artifacts.
+package htmlreport
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"time"
+
+	"github.com/netflix/go-iomux"
+)
+
+// htmlColors are the colors of tags in reports, in the order tags are first seen, matching iomux.Colors.
+var htmlColors = []string{"#0aa", "#b80", "#2a2", "#a3a", "#35c", "#c33"}
+
+type htmlChunk struct {
+	Tag     string
+	Color   string
+	Time    string
+	Elapsed string
+	Data    string
+}
+
+type htmlTag struct {
+	Name  string
+	Color string
+	Data  string
+	Count int
+}
+
+type htmlReport struct {
+	Title  string
+	Chunks []htmlChunk
+	Tags   []htmlTag
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 1em; }
+pre { margin: 0; white-space: pre-wrap; word-break: break-all; }
+table { border-collapse: collapse; width: 100%; }
+td { vertical-align: top; padding: 1px 6px; font-family: monospace; }
+td.time { color: #888; white-space: nowrap; }
+td.tag { font-weight: bold; white-space: nowrap; }
+tr.hidden, details.hidden { display: none; }
+details { margin-top: 1em; }
+summary { cursor: pointer; font-weight: bold; }
+#search { width: 30em; margin-bottom: 1em; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<input id="search" type="search" placeholder="Search" oninput="search(this.value)">
+<details open>
+<summary>All output ({{len .Chunks}} chunks)</summary>
+<table>
+{{range .Chunks}}<tr class="chunk"><td class="time" title="{{.Time}}">{{.Elapsed}}</td><td class="tag" style="color: {{.Color}}">{{.Tag}}</td><td style="color: {{.Color}}"><pre>{{.Data}}</pre></td></tr>
+{{end}}</table>
+</details>
+{{range .Tags}}<details class="tag">
+<summary style="color: {{.Color}}">{{.Name}} ({{.Count}} chunks)</summary>
+<pre style="color: {{.Color}}">{{.Data}}</pre>
+</details>
+{{end}}<script>
+function search(query) {
+	query = query.toLowerCase();
+	document.querySelectorAll("tr.chunk, details.tag").forEach(function (el) {
+		el.classList.toggle("hidden", query !== "" && !el.textContent.toLowerCase().includes(query));
+	});
+}
+</script>
+</body>
+</html>
+`))
+
+// Write Write td as a standalone HTML page titled title, for attaching captures to CI runs. The page shows all
+// output in the order it was captured with the time of each chunk since the first, and a collapsible section per tag,
+// each tag in its own color, with a search box filtering both.
+func Write[T comparable](w io.Writer, td []*iomux.TaggedData[T], title string) error {
+	report := htmlReport{Title: title}
+	tags := make(map[T]int)
+	var start time.Time
+	for _, d := range td {
+		i, ok := tags[d.Tag]
+		if !ok {
+			i = len(report.Tags)
+			tags[d.Tag] = i
+			report.Tags = append(report.Tags, htmlTag{Name: fmt.Sprint(d.Tag), Color: htmlColors[i%len(htmlColors)]})
+		}
+		tag := &report.Tags[i]
+		tag.Data += string(d.Data)
+		tag.Count++
+		chunk := htmlChunk{Tag: tag.Name, Color: tag.Color, Data: string(d.Data)}
+		if !d.Time.IsZero() {
+			if start.IsZero() {
+				start = d.Time
+			}
+			chunk.Time = d.Time.Format(time.RFC3339Nano)
+			chunk.Elapsed = fmt.Sprintf("+%.3fs", d.Time.Sub(start).Seconds())
+		}
+		report.Chunks = append(report.Chunks, chunk)
+	}
+	return htmlTemplate.Execute(w, report)
+}
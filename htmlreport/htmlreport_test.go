@@ -0,0 +1,36 @@
+package htmlreport
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/netflix/go-iomux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrite(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	td := []*iomux.TaggedData[iomux.StdStream]{
+		{Tag: iomux.Stdout, Data: []byte("<b>building</b>\n"), Time: start},
+		{Tag: iomux.Stderr, Data: []byte("warning: x & y\n"), Time: start.Add(1500 * time.Millisecond)},
+		{Tag: iomux.Stdout, Data: []byte("done\n")},
+	}
+	var sb strings.Builder
+	assert.Nil(t, Write(&sb, td, "build <42>"))
+	html := sb.String()
+
+	assert.Contains(t, html, "<title>build &lt;42&gt;</title>")
+	// output is escaped
+	assert.Contains(t, html, "&lt;b&gt;building&lt;/b&gt;")
+	assert.NotContains(t, html, "<b>building")
+	assert.Contains(t, html, "warning: x &amp; y")
+	// timestamps since the first chunk
+	assert.Contains(t, html, `title="2024-01-02T03:04:05Z">&#43;0.000s`)
+	assert.Contains(t, html, ">&#43;1.500s<")
+	// a section per tag with its output concatenated
+	assert.Contains(t, html, "stdout (2 chunks)</summary>")
+	assert.Contains(t, html, "stderr (1 chunks)</summary>")
+	assert.Contains(t, html, "&lt;b&gt;building&lt;/b&gt;\ndone\n</pre>")
+	assert.Contains(t, html, `style="color: #b80"`)
+}
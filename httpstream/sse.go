@@ -0,0 +1,78 @@
+// Package httpstream serves the chunks broadcast by an iomux.Broadcaster over HTTP, so the live output of a job can be
+// followed from a web page or another machine.
+package httpstream
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/netflix/go-iomux"
+)
+
+// writeTimeout is how long a write to a client can take before the client is taken to have stopped reading and is
+// disconnected.
+var writeTimeout = 10 * time.Second
+
+// SSEHandler Return an http.Handler streaming the chunks broadcast by b as server-sent events, with the tag formatted
+// by fmt.Sprint as the event name, the chunk as the data and its sequence number as the id, for example to show the
+// live output of a job on a web page. When history is set each request first receives the chunks kept by
+// iomux.Broadcaster.SetHistory. Each request subscribes with buffer, see iomux.Broadcaster.SubscribeNoWait, and the
+// response ends when the broadcast does, or when the client falls more than buffer chunks behind. Writes taking longer
+// than 10 seconds also end the response, so a client that stops reading can't hold up the handler.
+func SSEHandler[T comparable](b *iomux.Broadcaster[T], buffer int, history bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		past, ch, unsubscribe := b.SubscribeNoWait(buffer)
+		defer unsubscribe()
+		if !history {
+			past = nil
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		rc := http.NewResponseController(w)
+		// bound every write, returning closes the connection of a client that stopped reading
+		deadline := func() {
+			_ = rc.SetWriteDeadline(time.Now().Add(writeTimeout))
+		}
+		for _, td := range past {
+			deadline()
+			if err := writeEvent(w, td); err != nil {
+				return
+			}
+		}
+		for {
+			deadline()
+			if err := rc.Flush(); err != nil {
+				return
+			}
+			select {
+			case <-r.Context().Done():
+				return
+			case td, ok := <-ch:
+				if !ok {
+					return
+				}
+				deadline()
+				if err := writeEvent(w, td); err != nil {
+					return
+				}
+			}
+		}
+	})
+}
+
+// writeEvent writes td as a server-sent event, with a data field per line so newlines are kept.
+func writeEvent[T comparable](w http.ResponseWriter, td *iomux.TaggedData[T]) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "id: %d\nevent: %v\n", td.Seq, td.Tag)
+	for _, line := range bytes.Split(td.Data, []byte("\n")) {
+		buf.WriteString("data: ")
+		buf.Write(bytes.TrimSuffix(line, []byte("\r")))
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\n')
+	_, err := w.Write(buf.Bytes())
+	return err
+}
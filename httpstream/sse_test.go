@@ -0,0 +1,114 @@
+//go:build !windows
+
+package httpstream
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/netflix/go-iomux"
+	"github.com/stretchr/testify/assert"
+)
+
+// sleepDuration is long enough for written data to be read by a running broadcast.
+const sleepDuration = 10 * time.Millisecond
+
+func TestSSEHandler(t *testing.T) {
+	mux := iomux.NewMux[iomux.StdStream]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	stdout, _ := mux.Tag(iomux.Stdout)
+	stderr, _ := mux.Tag(iomux.Stderr)
+	b := mux.Broadcast()
+	b.SetHistory(10)
+	server := httptest.NewServer(SSEHandler(b, 8, true))
+	t.Cleanup(server.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ran := make(chan error)
+	go func() {
+		ran <- b.Run(ctx)
+	}()
+	io.WriteString(stdout, "before connecting\n")
+	time.Sleep(sleepDuration)
+
+	resp, err := http.Get(server.URL)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	io.WriteString(stderr, "two\nlines")
+	time.Sleep(sleepDuration)
+	cancel()
+	assert.Nil(t, <-ran)
+
+	var events []string
+	var event strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if scanner.Text() == "" {
+			events = append(events, event.String())
+			event.Reset()
+			continue
+		}
+		event.WriteString(scanner.Text() + "|")
+	}
+	assert.Equal(t, []string{
+		"id: 1|event: stdout|data: before connecting|data: |",
+		"id: 2|event: stderr|data: two|data: lines|",
+	}, events)
+}
+
+func TestSSEHandlerWriteTimeout(t *testing.T) {
+	timeout := writeTimeout
+	writeTimeout = 50 * time.Millisecond
+	t.Cleanup(func() {
+		writeTimeout = timeout
+	})
+	mux := iomux.NewMux[iomux.StdStream]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	stdout, _ := mux.Tag(iomux.Stdout)
+	b := mux.Broadcast()
+	b.SetHistory(1000)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go b.Run(ctx)
+	// more history than the socket buffers hold
+	chunk := strings.Repeat("x", 1<<16)
+	for i := 0; i < 200; i++ {
+		io.WriteString(stdout, chunk)
+	}
+	time.Sleep(sleepDuration)
+
+	done := make(chan struct{})
+	handler := SSEHandler(b, 8, true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(done)
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	// a client that never reads the response
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer conn.Close()
+	io.WriteString(conn, "GET / HTTP/1.1\r\nHost: localhost\r\n\r\n")
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler still writing to a client that stopped reading")
+	}
+}
@@ -0,0 +1,247 @@
+package httpstream
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/netflix/go-iomux"
+)
+
+// websocketGUID is appended to the client's key to compute the handshake's accept key.
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+const (
+	opText  = 0x1
+	opClose = 0x8
+	opPing  = 0x9
+	opPong  = 0xa
+)
+
+// maxControlPayload is the largest payload of a WebSocket control frame.
+const maxControlPayload = 125
+
+// WebSocketHandler Return an http.Handler streaming the chunks broadcast by b over a WebSocket, as a text message of
+// JSON per chunk with the same fields as JSON Lines, see iomux.JSONLEncoder. Clients choose the tags to receive when
+// connecting with one or more "tag" query parameters, compared with the tag formatted by fmt.Sprint, such as
+// "?tag=stderr", or receive every tag without them. history and buffer are as for SSEHandler, and a client that falls
+// behind is closed with status 1013, try again later. Clients are disconnected when a write takes longer than 10
+// seconds, as for SSEHandler.
+//
+// Browsers let any page open a WebSocket, so connections are refused with 403 Forbidden unless checkOrigin accepts the
+// request. If checkOrigin is nil, only requests without an Origin header, which don't come from browsers, and requests
+// from the same origin as the handler are accepted.
+func WebSocketHandler[T comparable](b *iomux.Broadcaster[T], buffer int, history bool, checkOrigin func(r *http.Request) bool) http.Handler {
+	if checkOrigin == nil {
+		checkOrigin = sameOrigin
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Sec-WebSocket-Key")
+		if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") ||
+			r.Header.Get("Sec-WebSocket-Version") != "13" || key == "" {
+			http.Error(w, "expected a WebSocket upgrade", http.StatusBadRequest)
+			return
+		}
+		if !checkOrigin(r) {
+			http.Error(w, "origin not allowed", http.StatusForbidden)
+			return
+		}
+		tags := r.URL.Query()["tag"]
+		conn, rw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer conn.Close()
+		ws := &websocket{conn: conn, rw: rw}
+		// the handler returns once a write fails, closing the connection
+		conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+		accept := sha1.Sum([]byte(key + websocketGUID))
+		fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+			"Sec-WebSocket-Accept: %s\r\n\r\n", base64.StdEncoding.EncodeToString(accept[:]))
+		if rw.Flush() != nil {
+			return
+		}
+
+		past, ch, unsubscribe := b.SubscribeNoWait(buffer)
+		defer unsubscribe()
+		if !history {
+			past = nil
+		}
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			ws.readControl()
+		}()
+		var msg bytes.Buffer
+		enc := iomux.NewJSONLEncoder[T](&msg)
+		send := func(td *iomux.TaggedData[T]) error {
+			if len(tags) > 0 && !slices.Contains(tags, fmt.Sprint(td.Tag)) {
+				return nil
+			}
+			msg.Reset()
+			if err := enc.Encode(td); err != nil {
+				return err
+			}
+			return ws.write(opText, bytes.TrimSuffix(msg.Bytes(), []byte("\n")))
+		}
+		for _, td := range past {
+			if send(td) != nil {
+				return
+			}
+		}
+		for {
+			select {
+			case <-closed:
+				return
+			case td, ok := <-ch:
+				if !ok {
+					if b.Ended() {
+						// 1000 is a normal closure
+						_ = ws.write(opClose, []byte{0x03, 0xe8})
+					} else {
+						// the client fell behind, 1013 is try again later
+						_ = ws.write(opClose, []byte{0x03, 0xf5})
+					}
+					return
+				}
+				if send(td) != nil {
+					return
+				}
+			}
+		}
+	})
+}
+
+// sameOrigin reports whether r has no Origin header, or one with the same host as r.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+// headerContains reports whether the comma separated values of the header key include value, ignoring case.
+func headerContains(h http.Header, key, value string) bool {
+	for _, v := range h.Values(key) {
+		for _, s := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(s), value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// websocket is the server end of a WebSocket connection.
+type websocket struct {
+	conn  net.Conn
+	rw    *bufio.ReadWriter
+	mutex sync.Mutex
+}
+
+// write sends payload as a single unmasked frame.
+func (ws *websocket) write(op byte, payload []byte) error {
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+	header := []byte{0x80 | op}
+	switch n := len(payload); {
+	case n < 126:
+		header = append(header, byte(n))
+	case n <= 0xffff:
+		header = binary.BigEndian.AppendUint16(append(header, 126), uint16(n))
+	default:
+		header = binary.BigEndian.AppendUint64(append(header, 127), uint64(n))
+	}
+	if err := ws.conn.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+		return err
+	}
+	if _, err := ws.rw.Write(header); err != nil {
+		return err
+	}
+	if _, err := ws.rw.Write(payload); err != nil {
+		return err
+	}
+	return ws.rw.Flush()
+}
+
+// readControl reads frames from the client, answering pings, until the client closes the connection or it fails.
+// Other messages are discarded.
+func (ws *websocket) readControl() {
+	for {
+		op, payload, err := ws.readFrame()
+		if err != nil {
+			return
+		}
+		switch op {
+		case opClose:
+			_ = ws.write(opClose, payload)
+			return
+		case opPing:
+			_ = ws.write(opPong, payload)
+		}
+	}
+}
+
+// readFrame reads a frame, unmasking its payload. Control frame payloads are kept, others are discarded.
+func (ws *websocket) readFrame() (byte, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(ws.rw, header[:]); err != nil {
+		return 0, nil, err
+	}
+	op := header[0] & 0x0f
+	if header[1]&0x80 == 0 {
+		return 0, nil, errors.New("websocket: client frame not masked")
+	}
+	n := uint64(header[1] & 0x7f)
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(ws.rw, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(ws.rw, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+	var mask [4]byte
+	if _, err := io.ReadFull(ws.rw, mask[:]); err != nil {
+		return 0, nil, err
+	}
+	if op < opClose {
+		_, err := io.CopyN(io.Discard, ws.rw, int64(n))
+		return op, nil, err
+	}
+	if n > maxControlPayload {
+		return 0, nil, errors.New("websocket: control frame too large")
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(ws.rw, payload); err != nil {
+		return 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return op, payload, nil
+}
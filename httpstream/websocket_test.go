@@ -0,0 +1,212 @@
+//go:build !windows
+
+package httpstream
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/netflix/go-iomux"
+	"github.com/stretchr/testify/assert"
+)
+
+// dialWebSocket connects to the WebSocket at path of server, returning the connection and a reader positioned after the
+// handshake.
+func dialWebSocket(t *testing.T, server *httptest.Server, path string) (net.Conn, *bufio.Reader) {
+	return dialWebSocketOrigin(t, server, path, "")
+}
+
+// dialWebSocketOrigin connects like dialWebSocket, sending origin as the Origin header if set.
+func dialWebSocketOrigin(t *testing.T, server *httptest.Server, path, origin string) (net.Conn, *bufio.Reader) {
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+	})
+	header := ""
+	if origin != "" {
+		header = "Origin: " + origin + "\r\n"
+	}
+	fmt.Fprintf(conn, "GET %s HTTP/1.1\r\nHost: test\r\nUpgrade: websocket\r\nConnection: keep-alive, Upgrade\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n%s\r\n", path, header)
+	rd := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(rd, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", resp.Header.Get("Sec-WebSocket-Accept"))
+	return conn, rd
+}
+
+// readTestFrame reads an unmasked frame with a payload shorter than 126 bytes.
+func readTestFrame(t *testing.T, rd *bufio.Reader) (byte, string) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(rd, header); err != nil {
+		t.Fatal(err)
+	}
+	payload := make([]byte, header[1])
+	if _, err := io.ReadFull(rd, payload); err != nil {
+		t.Fatal(err)
+	}
+	return header[0], string(payload)
+}
+
+func TestWebSocketHandler(t *testing.T) {
+	mux := iomux.NewMux[iomux.StdStream]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	stdout, _ := mux.Tag(iomux.Stdout)
+	stderr, _ := mux.Tag(iomux.Stderr)
+	b := mux.Broadcast()
+	server := httptest.NewServer(WebSocketHandler(b, 8, false, nil))
+	t.Cleanup(server.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ran := make(chan error)
+	go func() {
+		ran <- b.Run(ctx)
+	}()
+	_, rd := dialWebSocket(t, server, "/?tag=stderr")
+	time.Sleep(sleepDuration)
+	io.WriteString(stdout, "out")
+	io.WriteString(stderr, "err")
+	time.Sleep(sleepDuration)
+	cancel()
+	assert.Nil(t, <-ran)
+
+	op, payload := readTestFrame(t, rd)
+	assert.Equal(t, byte(0x81), op)
+	td, err := iomux.NewJSONLDecoder[iomux.StdStream](strings.NewReader(payload)).Decode()
+	assert.Nil(t, err)
+	assert.Equal(t, iomux.Stderr, td.Tag)
+	assert.Equal(t, "err", string(td.Data))
+	op, payload = readTestFrame(t, rd)
+	assert.Equal(t, byte(0x88), op)
+	assert.Equal(t, "\x03\xe8", payload)
+}
+
+func TestWebSocketHandlerPingClose(t *testing.T) {
+	mux := iomux.NewMux[string]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	server := httptest.NewServer(WebSocketHandler(mux.Broadcast(), 8, false, nil))
+	t.Cleanup(server.Close)
+
+	conn, rd := dialWebSocket(t, server, "/")
+	mask := []byte{1, 2, 3, 4}
+	masked := func(op byte, payload string) []byte {
+		frame := append([]byte{0x80 | op, 0x80 | byte(len(payload))}, mask...)
+		for i := range len(payload) {
+			frame = append(frame, payload[i]^mask[i%4])
+		}
+		return frame
+	}
+	conn.Write(masked(0x1, "ignored"))
+	conn.Write(masked(0x9, "ping"))
+	op, payload := readTestFrame(t, rd)
+	assert.Equal(t, byte(0x8a), op)
+	assert.Equal(t, "ping", payload)
+
+	conn.Write(masked(0x8, "\x03\xe8"))
+	op, _ = readTestFrame(t, rd)
+	assert.Equal(t, byte(0x88), op)
+	_, err := rd.ReadByte()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestWebSocketHandlerNotUpgrade(t *testing.T) {
+	mux := iomux.NewMux[string]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	server := httptest.NewServer(WebSocketHandler(mux.Broadcast(), 8, false, nil))
+	t.Cleanup(server.Close)
+	resp, err := http.Get(server.URL)
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.True(t, headerContains(http.Header{"Connection": {"keep-alive, Upgrade"}}, "Connection", "upgrade"))
+	assert.False(t, headerContains(http.Header{}, "Connection", "upgrade"))
+}
+
+func TestWebSocketHandlerOrigin(t *testing.T) {
+	mux := iomux.NewMux[string]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	b := mux.Broadcast()
+	server := httptest.NewServer(WebSocketHandler(b, 8, false, nil))
+	t.Cleanup(server.Close)
+
+	// the Host of dialWebSocket is "test"
+	dialWebSocketOrigin(t, server, "/", "http://test")
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Origin", "http://evil.example")
+	resp, err := http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	allowed := httptest.NewServer(WebSocketHandler(b, 8, false, func(r *http.Request) bool {
+		return r.Header.Get("Origin") == "http://evil.example"
+	}))
+	t.Cleanup(allowed.Close)
+	dialWebSocketOrigin(t, allowed, "/", "http://evil.example")
+}
+
+func TestWebSocketHandlerWriteTimeout(t *testing.T) {
+	timeout := writeTimeout
+	writeTimeout = 50 * time.Millisecond
+	t.Cleanup(func() {
+		writeTimeout = timeout
+	})
+	mux := iomux.NewMux[iomux.StdStream]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	stdout, _ := mux.Tag(iomux.Stdout)
+	b := mux.Broadcast()
+	b.SetHistory(1000)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go b.Run(ctx)
+	// more history than the socket buffers hold
+	chunk := strings.Repeat("x", 1<<16)
+	for i := 0; i < 200; i++ {
+		io.WriteString(stdout, chunk)
+	}
+	time.Sleep(sleepDuration)
+
+	done := make(chan struct{})
+	handler := WebSocketHandler(b, 8, true, nil)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(done)
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	// a client that stops reading after the handshake
+	dialWebSocket(t, server, "/")
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler still writing to a client that stopped reading")
+	}
+}
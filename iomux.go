@@ -1,47 +1,161 @@
 package iomux
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
+	"iter"
+	"log/slog"
 	"net"
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // Mux provides a single receive and multiple send ends using unix domain networking.
 type Mux[T comparable] struct {
-	network   string
-	dir       string
-	recvonce  sync.Once
-	recvaddr  *net.UnixAddr
-	recvconns []*net.UnixConn
-	recvbufs  [][]byte
-	recvchan  chan *taggedData[T]
-	recvmutex []sync.Mutex
-	recvstate map[recvKey]*recvState
-	acceptFn  func() error
-	senders   map[T]*net.UnixConn
-	closed    bool
-	closers   []io.Closer
+	network         string
+	dir             string
+	recvonce        sync.Once
+	recvaddr        *net.UnixAddr
+	recvlock        sync.RWMutex
+	recvconns       []*receiver
+	untagged        []*net.UnixConn
+	untaggedTags    []T
+	tagmutex        sync.Mutex
+	writers         map[T]*os.File
+	tagged          []T
+	duplicatePolicy DuplicateTagPolicy
+	onError         func(T, error)
+	ringBytes       int
+	headBytes       int
+	tailBytes       int
+	recvgen         atomic.Uint64
+	recvchanged     chan struct{}
+	recvchan        chan *taggedData[T]
+	recvstate       map[recvKey]*recvState
+	poller          poller
+	pollbuf         []byte
+	pollbatch       *recvBatch
+	polloob         []byte
+	pollconn        *net.UnixConn
+	readBatch       int
+	pollmutex       sync.Mutex
+	ready           []*net.UnixConn
+	acceptFn        func() error
+	sendmutex       sync.RWMutex
+	sendnum         int
+	tags            map[string]tagSender[T]
+	open            map[T]int
+	recvclosed      map[*net.UnixConn]bool
+	socketpair      bool
+	abstract        bool
+	namespace       string
+	sockDir         string
+	reap            bool
+	strict          bool
+	stats           stats[T]
+	fragment        bool
+	fragments       map[any][]byte
+	conntags        map[*net.UnixConn]tagSender[T]
+	onClose         func(T)
+	closemutex      sync.Mutex
+	closed          bool
+	closers         []io.Closer
+	demuxonce       sync.Once
+	demux           *demux[T]
+	pending         []*TaggedData[T]
+	lines           bool
+	splitter        lineSplitter[T]
+	idleTimeout     time.Duration
+	readDeadline    time.Time
+	maxBuffered     int
+	bufferPolicy    BufferPolicy
+	spillThreshold  int
+	spillDir        string
+	metrics         Metrics[T]
+	submutex        sync.Mutex
+	subscribers     map[T]*subscription
+	watchers        map[T][]*watcher[T]
+	tees            map[T]io.Writer
+	transforms      []func(tag T, data []byte) []byte
+	sinks           []*sinkQueue[T]
+	sinkseq         uint64
+	pooled          bool
+	into            []byte
+	trying          bool
+	auto            bool
+	creds           bool
+	recvsrc         source
+	readsrc         source
+	classify        func(tag T, data []byte) slog.Level
+	minLevel        slog.Level
+	filterLevel     bool
+	readlevel       slog.Level
 }
 
 type TaggedData[T comparable] struct {
 	Tag  T
 	Data []byte
+	// Seq numbers chunks from 1 in the order they were read, and Time is when they were read. Both are set when
+	// collected by ReadUntil, ReadWhile or Stream, and are otherwise zero.
+	Seq  uint64
+	Time time.Time
+	// Truncated is set on the marker inserted by WithHeadTail in place of discarded data, counting the bytes
+	// discarded, with Data describing the truncation.
+	Truncated int
+	// ConnID identifies the writer returned by Tag that sent Data, numbering writers from 1 in the order they were
+	// created, so producers sharing a tag through separate writers can be told apart. Processes that inherit the same
+	// writer share its ConnID. Zero when unknown.
+	ConnID uint64
+	// Cred identifies the process that wrote Data when read WithCredentials, and is otherwise nil.
+	Cred *Cred
+	// Level is the severity of Data assigned by WithClassifier, and is otherwise slog.LevelInfo.
+	Level slog.Level
+	// spilled locates Data once written to disk by WithSpill, until it is read back by Load
+	spilled *spilled
+}
+
+// source identifies the writer of received data.
+type source struct {
+	conn uint64
+	cred *Cred
+}
+
+// tagSender is a writer created by Tag, identified by its tag and connection ID.
+type tagSender[T comparable] struct {
+	tag T
+	id  uint64
 }
 
 type taggedData[T comparable] struct {
 	tag  T
 	data []byte
 	conn *net.UnixConn
+	src  source
 	err  error
 }
 
+// receiver is a connection that data is received on, with the buffer and lock for reading it.
+type receiver struct {
+	conn  *net.UnixConn
+	buf   []byte
+	batch *recvBatch
+	// oob receives the credentials of the writer of the last read, see WithCredentials
+	oob []byte
+	// src identifies the writer of the last read
+	src   source
+	mutex sync.Mutex
+}
+
 type recvKey struct {
 	ctx  context.Context
 	conn *net.UnixConn
@@ -54,29 +168,77 @@ type recvState struct {
 var (
 	MuxClosed        = errors.New("mux has been closed")
 	MuxNoConnections = errors.New("no senders have been connected")
+	MuxBufferFull    = errors.New("mux buffer limit exceeded")
+	MuxUnordered     = errors.New("mux network does not order data across tags")
+	MuxDuplicateTag  = errors.New("mux tag already has a writer")
+	MuxBufferBlocks  = errors.New("mux BufferBlock would block the writers ReadWhile waits for")
+
+	errSenderClosed     = errors.New("sender closed")
+	errReceiversChanged = errors.New("receivers changed")
+	errUntagged         = errors.New("receiver untagged")
 )
 
 const deadlineDuration = 100 * time.Millisecond
 
+// drainDuration is how long a read waits for data once ctx is done. Much shorter than this and the deadline can pass
+// before the read is even attempted, leaving data that was already sent behind.
+const drainDuration = 10 * time.Millisecond
+
+// tryReadTimeout bounds the read TryRead makes once a connection is readable, like drainDuration long enough for the
+// read to be attempted, while data that turns out not to be returned, such as that of an untagged writer, can't block
+// it for long.
+const tryReadTimeout = drainDuration
+
+// defaultReadBatch is how many datagrams or packets are received per syscall where supported, see WithReadBatch.
+const defaultReadBatch = 8
+
+// NewMux Create a new Mux using the default network for the OS, see createReceiver.
+func NewMux[T comparable](opts ...Option[T]) *Mux[T] {
+	return newMux("", opts)
+}
+
+// NewMuxAuto Create a new Mux using the first network the OS supports, trying 'unixpacket', then 'unixgram', then
+// 'unix', so message boundaries are kept wherever possible. See autoNetworks for the choices per OS, and Network for the
+// network chosen.
+func NewMuxAuto[T comparable](opts ...Option[T]) *Mux[T] {
+	mux := newMux("", opts)
+	mux.auto = true
+	return mux
+}
+
 // NewMuxUnix Create a new Mux using 'unix' network.
-func NewMuxUnix[T comparable]() *Mux[T] {
-	return &Mux[T]{network: "unix"}
+func NewMuxUnix[T comparable](opts ...Option[T]) *Mux[T] {
+	return newMux("unix", opts)
 }
 
 // NewMuxUnixGram Create a new Mux using 'unixgram' network.
-func NewMuxUnixGram[T comparable]() *Mux[T] {
-	return &Mux[T]{network: "unixgram"}
+func NewMuxUnixGram[T comparable](opts ...Option[T]) *Mux[T] {
+	return newMux("unixgram", opts)
 }
 
 // NewMuxUnixPacket Create a new Mux using 'unixpacket' network.
-func NewMuxUnixPacket[T comparable]() *Mux[T] {
-	return &Mux[T]{network: "unixpacket"}
+func NewMuxUnixPacket[T comparable](opts ...Option[T]) *Mux[T] {
+	return newMux("unixpacket", opts)
+}
+
+func newMux[T comparable](network string, opts []Option[T]) *Mux[T] {
+	mux := &Mux[T]{network: network}
+	for _, opt := range opts {
+		opt(mux)
+	}
+	return mux
 }
 
 // Tag Create a file to receive data tagged with tag T. Returns an *os.File ready for writing, or an error. If an error
-// occurs when creating the receive end of the connection, the Mux will be closed.
+// occurs when creating the receive end of the connection, the Mux will be closed. Each call creates a new writer, so
+// several processes can share a tag with a writer each, told apart by the ConnID of the data, and on connection
+// oriented networks closing every writer for a tag is reported to WithCloseHandler. Tag can be called
+// while another goroutine is reading, and Untag stops receiving for a tag. Calling Tag again for the same tag is
+// decided by WithDuplicateTagPolicy. The file is the sending socket itself, so when set as exec.Cmd Stdout, Stderr or
+// ExtraFiles it is passed to the child directly, without a pipe and copying goroutine, and its SyscallConn can be used to
+// set socket options such as SO_SNDBUF per tag.
 func (mux *Mux[T]) Tag(tag T) (*os.File, error) {
-	if mux.closed {
+	if mux.isClosed() {
 		return nil, MuxClosed
 	}
 	err := mux.createReceiver()
@@ -84,260 +246,1033 @@ func (mux *Mux[T]) Tag(tag T) (*os.File, error) {
 		mux.Close()
 		return nil, err
 	}
+	mux.tagmutex.Lock()
+	defer mux.tagmutex.Unlock()
+	if existing, ok := mux.writers[tag]; ok {
+		switch mux.duplicatePolicy {
+		case DuplicateTagExisting:
+			return existing, nil
+		case DuplicateTagError:
+			return nil, &TagError[T]{Tag: tag, Op: "tag", Err: MuxDuplicateTag}
+		}
+	}
 	sender, err := mux.createSender(tag)
 	if err != nil {
-		return nil, err
+		return nil, &TagError[T]{Tag: tag, Op: "tag", Err: err}
+	}
+	if _, ok := mux.writers[tag]; !ok {
+		if mux.writers == nil {
+			mux.writers = make(map[T]*os.File)
+		}
+		mux.writers[tag] = sender
+		mux.tagged = append(mux.tagged, tag)
 	}
 	return sender, nil
 }
 
+// Tags Return the tags that have writers, in the order they were first passed to Tag.
+func (mux *Mux[T]) Tags() []T {
+	mux.tagmutex.Lock()
+	defer mux.tagmutex.Unlock()
+	return slices.Clone(mux.tagged)
+}
+
+// Writer Return the first writer created by Tag for tag, or false if tag has no writers.
+func (mux *Mux[T]) Writer(tag T) (io.Writer, bool) {
+	mux.tagmutex.Lock()
+	defer mux.tagmutex.Unlock()
+	w, ok := mux.writers[tag]
+	if !ok {
+		return nil, false
+	}
+	return w, true
+}
+
 // Read perform a read, blocking until data is available or ctx.Done. For connection oriented networks, Read
 // concurrently reads all connections buffering in order received for consecutive calls to Read. Returns io.EOF error
-// when there is no data remaining to be read.
+// when there is no data remaining to be read, or on connection oriented networks once every writer has been closed.
+// Cancelling ctx unblocks a pending Read within deadlineDuration, even if no writer ever sends, so a context with a
+// deadline can be used to bound how long a Read waits.
 func (mux *Mux[T]) Read(ctx context.Context) ([]byte, T, error) {
+	return mux.readIdle(ctx, mux.idleTimeout)
+}
+
+// ReadContext Read like Read, but return ctx.Err() rather than io.EOF when ctx is done before data arrives, so a
+// request deadline can be told apart from the end of the data.
+func (mux *Mux[T]) ReadContext(ctx context.Context) ([]byte, T, error) {
+	data, tag, err := mux.Read(ctx)
+	if err == io.EOF && ctx.Err() != nil {
+		return nil, tag, ctx.Err()
+	}
+	return data, tag, err
+}
+
+// TryRead perform a read without blocking, returning ok false when there is no data ready to be read, for integrating
+// the Mux into event loops polling other sources. Otherwise returns like Read. On Windows, where readiness can't be
+// checked, TryRead waits briefly for data instead.
+func (mux *Mux[T]) TryRead() (data []byte, tag T, ok bool, err error) {
+	mux.trying = true
+	data, tag, err = mux.readIdle(context.Background(), tryReadTimeout)
+	mux.trying = false
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return nil, tag, false, nil
+	}
+	return data, tag, err == nil, err
+}
+
+// ReadInto perform a read like Read, copying the data into buf rather than allocating, for consumers that reuse their
+// own buffers. Data that doesn't fit in buf is returned by the following reads. Chunks that are kept by the Mux, such
+// as partial lines of WithLineSplitting, or handed to sinks and subscribers, are still copied.
+func (mux *Mux[T]) ReadInto(ctx context.Context, buf []byte) (int, T, error) {
+	if !mux.lines {
+		// the splitter holds on to partial lines
+		mux.into = buf
+	}
+	data, tag, err := mux.Read(ctx)
+	mux.into = nil
+	if err != nil {
+		return 0, tag, err
+	}
+	n := copy(buf, data)
+	if n < len(data) {
+		mux.unread([]*TaggedData[T]{{Tag: tag, Data: data[n:], ConnID: mux.readsrc.conn, Cred: mux.readsrc.cred,
+			Level: mux.readlevel}})
+	} else if mux.pooled && (n == 0 || &data[0] != &buf[0]) {
+		Release(data)
+	}
+	return n, tag, nil
+}
+
+// LastConnID Return the ConnID of the writer of the data last returned by Read, or zero if unknown, see TaggedData.
+func (mux *Mux[T]) LastConnID() uint64 {
+	return mux.readsrc.conn
+}
+
+// readIdle reads like Read, failing with os.ErrDeadlineExceeded when no data arrives for idle, if positive.
+func (mux *Mux[T]) readIdle(ctx context.Context, idle time.Duration) ([]byte, T, error) {
 	var zeroTag T
-	if mux.closed {
+	if mux.isClosed() {
+		return nil, zeroTag, MuxClosed
+	}
+	for {
+		mux.forgetUntagged()
+		if len(mux.pending) > 0 {
+			td := mux.pending[0]
+			mux.pending[0] = nil
+			mux.pending = mux.pending[1:]
+			mux.stats.buffer(-len(td.Data))
+			mux.readsrc = source{conn: td.ConnID, cred: td.Cred}
+			mux.readlevel = td.Level
+			return td.Data, td.Tag, nil
+		}
+		if mux.trying && !mux.readable() {
+			return nil, zeroTag, os.ErrDeadlineExceeded
+		}
+		start := time.Now()
+		data, tag, err := mux.receive(ctx, mux.deadline(idle))
+		if err == nil {
+			mux.stats.received(tag, len(data))
+			if mux.metrics != nil {
+				mux.metrics.Received(tag, len(data), time.Since(start))
+			}
+		}
+		if err != nil {
+			if err == errSenderClosed || err == errReceiversChanged || (err == io.EOF && mux.flush()) {
+				continue
+			}
+			return nil, zeroTag, err
+		}
+		mux.process(tag, data, mux.recvsrc)
+	}
+}
+
+// readable reports whether a read can return without waiting for data, as a receive connection is ready or has data
+// left from the last batch, or whether it fails straight away, as there are no connections.
+func (mux *Mux[T]) readable() bool {
+	receivers := mux.receivers()
+	if len(receivers) == 0 || len(mux.ready) > 0 || mux.pollbatch.pending() {
+		return true
+	}
+	for _, r := range receivers {
+		if r.batch.pending() || connReadable(r.conn) {
+			return true
+		}
+	}
+	return false
+}
+
+// process hands data received for tag from the writer src on to Read. With WithLineSplitting the data is split into
+// lines first, so transforms, tees, sinks and watchers see whole lines however a write was received.
+func (mux *Mux[T]) process(tag T, data []byte, src source) {
+	if mux.lines {
+		lines := mux.detach(mux.splitter.split(tag, data))
+		for _, td := range lines {
+			td.ConnID, td.Cred = src.conn, src.cred
+		}
+		mux.emit(lines...)
+		return
+	}
+	mux.emit(&TaggedData[T]{Tag: tag, Data: data, ConnID: src.conn, Cred: src.cred})
+}
+
+// emit applies the transforms to td, hands the result to tees, sinks and watchers, and queues it to be returned by
+// Read, discarding data the transforms return empty.
+func (mux *Mux[T]) emit(td ...*TaggedData[T]) {
+	for _, d := range td {
+		if d.Data = mux.transform(d.Tag, d.Data); len(d.Data) == 0 {
+			continue
+		}
+		if w, ok := mux.tees[d.Tag]; ok {
+			_, _ = w.Write(d.Data)
+		}
+		mux.sink(d)
+		mux.watch(d.Tag, d.Data)
+		mux.queue(d)
+	}
+}
+
+// flush queues any data held back by process once there is nothing left to receive, reporting whether there was any.
+func (mux *Mux[T]) flush() bool {
+	if mux.lines {
+		mux.emit(mux.detach(mux.splitter.flushAll())...)
+	}
+	return len(mux.pending) > 0
+}
+
+// detach copies the lines split from a pooled read buffer into buffers of their own, so that releasing one doesn't
+// return memory to the pool that is still used by the other lines, or by the partial line held back.
+func (mux *Mux[T]) detach(td []*TaggedData[T]) []*TaggedData[T] {
+	if mux.pooled {
+		for _, d := range td {
+			d.Data = mux.copyData(d.Data, nil)
+		}
+	}
+	return td
+}
+
+// queue hands td to the subscribers of their tags, queueing the rest to be returned by Read.
+func (mux *Mux[T]) queue(td ...*TaggedData[T]) {
+	for _, d := range td {
+		if !mux.classifyLevel(d) {
+			continue
+		}
+		if fn := mux.subscriber(d.Tag); fn != nil {
+			if mux.into != nil {
+				// the buffer of ReadInto is reused by the next read
+				fn(bytes.Clone(d.Data))
+				continue
+			}
+			fn(d.Data)
+			continue
+		}
+		mux.pending = append(mux.pending, d)
+		mux.stats.buffer(len(d.Data))
+	}
+}
+
+func (mux *Mux[T]) receive(ctx context.Context, deadline time.Time) ([]byte, T, error) {
+	var zeroTag T
+	if mux.isClosed() {
 		return nil, zeroTag, MuxClosed
 	}
-	if len(mux.recvconns) == 0 {
+	gen := mux.recvgen.Load()
+	receivers := mux.receivers()
+	if len(receivers) == 0 {
 		return nil, zeroTag, MuxNoConnections
 	}
 
-	if len(mux.recvconns) == 1 {
-		return mux.read(ctx, mux.recvconns[0], mux.recvbufs[0])
+	if len(receivers) == 1 {
+		conn := receivers[0].conn
+		if mux.recvclosed[conn] {
+			return nil, zeroTag, io.EOF
+		}
+		data, tag, err := mux.read(ctx, receivers[0], mux.into, deadline, gen)
+		mux.recvsrc = receivers[0].src
+		if err == errUntagged {
+			return nil, zeroTag, errSenderClosed
+		}
+		if failedTag, ok := mux.failed(err); ok {
+			tag, err = failedTag, errSenderClosed
+		}
+		if err == errSenderClosed {
+			mux.senderClosed(conn, tag)
+			return nil, zeroTag, io.EOF
+		}
+		return data, tag, err
+	}
+
+	if mux.poller != nil {
+		return mux.poll(ctx, deadline)
 	}
 
 	if mux.recvstate == nil {
 		mux.recvstate = make(map[recvKey]*recvState)
 	}
-	for i, c := range mux.recvconns {
+	for _, r := range receivers {
+		c := r.conn
 		key := recvKey{ctx: ctx, conn: c}
 		if _, ok := mux.recvstate[key]; !ok {
 			mux.recvstate[key] = &recvState{}
-		} else if mux.recvstate[key].eof {
+		}
+		if mux.recvclosed[c] {
+			mux.recvstate[key].eof = true
+		}
+		if mux.recvstate[key].eof {
 			// avoid spinning up another read, we're done
 			continue
 		}
-		conn := c
-		buf := mux.recvbufs[i]
-		mutex := &mux.recvmutex[i]
-		if mutex.TryLock() {
+		if r.mutex.TryLock() {
 			go func() {
-				data, tag, err := mux.read(ctx, conn, buf)
+				data, tag, err := mux.read(ctx, r, nil, time.Time{}, gen)
 				mux.recvchan <- &taggedData[T]{
 					data: data,
 					tag:  tag,
-					conn: conn,
+					conn: r.conn,
+					src:  r.src,
 					err:  err,
 				}
-				mutex.Unlock()
+				r.mutex.Unlock()
 			}()
 		}
 	}
 
-	sleepDuration := 1 * time.Millisecond
+	if mux.sendersClosed() {
+		mux.recvDelete(ctx, receivers)
+		return nil, zeroTag, io.EOF
+	}
+
+	var expired <-chan time.Time
+	if !deadline.IsZero() {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		expired = timer.C
+	}
+	done := ctx.Done()
 	for {
 		select {
 		case td := <-mux.recvchan:
-			if td.err != nil {
-				if td.err == io.EOF {
-					key := recvKey{ctx: ctx, conn: td.conn}
-					mux.recvstate[key].eof = true
-					continue
-				}
-				return nil, zeroTag, td.err
+			if td.err == errReceiversChanged || !mux.hasReceiver(td.conn) {
+				// the read was abandoned, or the connection untagged
+				continue
 			}
-			return td.data, td.tag, td.err
-		case <-ctx.Done():
-			done := true
-			for _, c := range mux.recvconns {
-				key := recvKey{ctx: ctx, conn: c}
-				if state, ok := mux.recvstate[key]; ok {
-					if !state.eof {
-						done = false
-					}
-				} else {
-					panic("no state")
-				}
+			if failedTag, ok := mux.failed(td.err); ok {
+				td.tag, td.err = failedTag, errSenderClosed
 			}
-			if done {
-				for _, c := range mux.recvconns {
-					key := recvKey{ctx: ctx, conn: c}
-					delete(mux.recvstate, key)
+			if td.err != nil {
+				if td.err != io.EOF && td.err != errSenderClosed {
+					return nil, zeroTag, td.err
 				}
-				return nil, zeroTag, io.EOF
+				key := recvKey{ctx: ctx, conn: td.conn}
+				mux.recvstate[key].eof = true
+				if td.err == errSenderClosed {
+					// give Read the chance to return data flushed for the closed tag
+					mux.senderClosed(td.conn, td.tag)
+					return nil, zeroTag, errSenderClosed
+				}
+			} else {
+				mux.recvsrc = td.src
+				return td.data, td.tag, td.err
 			}
-		default:
+		case <-done:
+			// readers notice the cancellation at their next deadline and report io.EOF, so stop selecting on done and
+			// wait for them to drain
+			done = nil
+		case <-expired:
+			return nil, zeroTag, os.ErrDeadlineExceeded
+		case <-mux.recvchanged:
+			// start reading connections added since
+			return nil, zeroTag, errReceiversChanged
+		}
+		if ctx.Err() != nil && mux.recvDone(ctx, receivers) {
+			mux.recvDelete(ctx, receivers)
+			return nil, zeroTag, io.EOF
 		}
+	}
+}
 
-		time.Sleep(sleepDuration)
-		sleepDuration += sleepDuration
-		if sleepDuration > deadlineDuration {
-			sleepDuration = deadlineDuration
+// recvDone reports whether every connection has reached io.EOF for ctx.
+func (mux *Mux[T]) recvDone(ctx context.Context, receivers []*receiver) bool {
+	for _, r := range receivers {
+		key := recvKey{ctx: ctx, conn: r.conn}
+		state, ok := mux.recvstate[key]
+		if !ok {
+			panic("no state")
+		}
+		if !state.eof {
+			return false
 		}
 	}
+	return true
 }
 
-func (mux *Mux[T]) read(ctx context.Context, conn *net.UnixConn, buf []byte) ([]byte, T, error) {
+// recvDelete forgets the read state for ctx.
+func (mux *Mux[T]) recvDelete(ctx context.Context, receivers []*receiver) {
+	for _, r := range receivers {
+		key := recvKey{ctx: ctx, conn: r.conn}
+		delete(mux.recvstate, key)
+	}
+}
+
+// deadline returns the time by which the next receive must produce data, or the zero time if there is no limit.
+func (mux *Mux[T]) deadline(idle time.Duration) time.Time {
+	deadline := mux.readDeadline
+	if idle > 0 {
+		idleDeadline := time.Now().Add(idle)
+		if deadline.IsZero() || idleDeadline.Before(deadline) {
+			deadline = idleDeadline
+		}
+	}
+	return deadline
+}
+
+// read reads from the connection of r, into dst if it is big enough, returning errReceiversChanged if the connections
+// being received from are no longer those of generation gen.
+func (mux *Mux[T]) read(ctx context.Context, r *receiver, dst []byte, deadline time.Time, gen uint64) ([]byte, T, error) {
 	var zeroTag T
+	conn := r.conn
 	for {
-		_ = conn.SetDeadline(time.Now().Add(deadlineDuration))
-		n, addr, err := conn.ReadFrom(buf)
+		done := ctx.Err() != nil
+		readDeadline := time.Now().Add(deadlineDuration)
+		if done {
+			// only wait briefly once ctx is done, so any data already sent is drained before reporting io.EOF
+			readDeadline = time.Now().Add(drainDuration)
+		}
+		if !deadline.IsZero() && deadline.Before(readDeadline) {
+			readDeadline = deadline
+		}
+		_ = conn.SetDeadline(readDeadline)
+		buf, addr, err := mux.readFrom(r)
 		if err != nil {
+			if err == io.EOF {
+				tag, _ := mux.tagOf(nil, conn)
+				return nil, tag, errSenderClosed
+			}
+			if !mux.hasReceiver(conn) {
+				return nil, zeroTag, errUntagged
+			}
 			if errors.Unwrap(err) != os.ErrDeadlineExceeded {
-				return nil, zeroTag, err
+				return nil, zeroTag, mux.tagError("read", conn, err)
 			}
-			select {
-			case <-ctx.Done():
+			if done {
 				return nil, zeroTag, io.EOF
-			default:
-				continue
 			}
+			if !deadline.IsZero() && !time.Now().Before(deadline) {
+				return nil, zeroTag, os.ErrDeadlineExceeded
+			}
+			if gen != mux.recvgen.Load() {
+				return nil, zeroTag, errReceiversChanged
+			}
+			continue
 		}
-		data := make([]byte, n)
-		copy(data, buf[0:n])
-		var tag T
-		for t, c := range mux.senders {
-			localAddr := c.LocalAddr().String()
-			remoteAddr := conn.RemoteAddr()
+		s, ok := mux.senderOf(addr, conn)
+		if !ok {
+			// sent by an untagged writer
+			continue
+		}
+		tag := s.tag
+		r.src.conn = s.id
+		if mux.fragmented() {
+			var sender any = conn
 			if addr != nil {
-				if addr.String() == localAddr {
-					tag = t
-					break
-				}
-			} else if remoteAddr != nil && remoteAddr.String() == localAddr {
-				tag = t
-				break
+				sender = addr.String()
 			}
+			data, ok := mux.reassemble(sender, buf)
+			if !ok {
+				continue
+			}
+			return data, tag, nil
 		}
-		return data, tag, nil
+		return mux.copyData(buf, dst), tag, nil
+	}
+}
+
+// copyData copies buf out of a receive buffer so it can be returned by Read, into dst if it is big enough.
+func (mux *Mux[T]) copyData(buf, dst []byte) []byte {
+	if dst != nil && len(buf) <= len(dst) {
+		return dst[:copy(dst, buf)]
+	}
+	var data []byte
+	if mux.pooled {
+		data = getBuffer(len(buf))
+	} else {
+		data = make([]byte, len(buf))
+	}
+	copy(data, buf)
+	return data
+}
+
+// tagOf returns the tag of the sender that data was received from, identified by conn itself for socket pairs, by addr
+// for datagrams, or by the remote address of conn otherwise. Reports false if the sender has no tag, because it was
+// untagged.
+func (mux *Mux[T]) tagOf(addr net.Addr, conn *net.UnixConn) (T, bool) {
+	s, ok := mux.senderOf(addr, conn)
+	return s.tag, ok
+}
+
+// senderOf returns the sender that data was received from like tagOf.
+func (mux *Mux[T]) senderOf(addr net.Addr, conn *net.UnixConn) (tagSender[T], bool) {
+	mux.sendmutex.RLock()
+	defer mux.sendmutex.RUnlock()
+	if s, ok := mux.conntags[conn]; ok {
+		return s, true
+	}
+	if addr == nil {
+		addr = conn.RemoteAddr()
+	}
+	if addr != nil {
+		s, ok := mux.tags[addr.String()]
+		return s, ok
+	}
+	return tagSender[T]{}, false
+}
+
+// sendersClosed reports whether every sender has been closed.
+func (mux *Mux[T]) sendersClosed() bool {
+	receivers := mux.receivers()
+	for _, r := range receivers {
+		if !mux.recvclosed[r.conn] {
+			return false
+		}
+	}
+	return len(receivers) > 0
+}
+
+// senderClosed records that the sender connected to conn has been closed, notifying onClose once every sender for tag
+// has been closed.
+func (mux *Mux[T]) senderClosed(conn *net.UnixConn, tag T) {
+	if mux.recvclosed == nil {
+		mux.recvclosed = make(map[*net.UnixConn]bool)
+	}
+	if mux.recvclosed[conn] {
+		return
+	}
+	mux.recvclosed[conn] = true
+	mux.sendmutex.Lock()
+	mux.open[tag]--
+	last := mux.open[tag] == 0
+	mux.sendmutex.Unlock()
+	if mux.metrics != nil {
+		mux.metrics.Disconnected(tag)
+	}
+	if !last {
+		return
+	}
+	if mux.lines {
+		mux.emit(mux.detach(mux.splitter.flush(tag))...)
 	}
+	if mux.onClose != nil {
+		mux.onClose(tag)
+	}
+}
+
+// SetReadDeadline Set the time after which Read fails with os.ErrDeadlineExceeded when no data has arrived, like
+// net.Conn. A zero value for t means Read will not time out.
+func (mux *Mux[T]) SetReadDeadline(t time.Time) {
+	mux.readDeadline = t
 }
 
-// ReadWhile Read until waitFn returns, returning the read data.
+// ReadWhile Read until waitFn returns, returning the read data. Reading happens concurrently with waitFn, so a writer
+// producing more output than the socket buffers can hold is drained as it writes rather than blocking. If waitFn or
+// reading fails, the data read so far is returned along with the error, as it is often what explains the failure. If
+// both fail, the errors are joined. Fails with MuxBufferBlocks, without calling waitFn, when WithMaxBufferedBytes uses
+// BufferBlock.
 func (mux *Mux[T]) ReadWhile(waitFn func() error) ([]*TaggedData[T], error) {
-	if mux.closed {
+	if mux.isClosed() {
+		return nil, MuxClosed
+	}
+	if mux.maxBuffered > 0 && mux.bufferPolicy == BufferBlock {
+		return nil, MuxBufferBlocks
+	}
+	return readWhile(mux.ReadUntil, waitFn)
+}
+
+// Drain Read until every writer has been closed, or no data arrives for grace, returning the read data. Unlike
+// ReadWhile, this doesn't race against data still in flight from a writer that has just finished. Only connection
+// oriented networks can detect writers being closed, so on 'unixgram' Drain always waits for grace to pass.
+func (mux *Mux[T]) Drain(grace time.Duration) ([]*TaggedData[T], error) {
+	if mux.isClosed() {
+		return nil, MuxClosed
+	}
+	return readUntil(context.Background(), func(ctx context.Context) ([]byte, T, error) {
+		data, tag, err := mux.readIdle(ctx, grace)
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			err = io.EOF
+		}
+		return data, tag, err
+	}, mux.collect())
+}
+
+// ReadUntil Read the receiver until done receives true. If reading fails, the data read so far is returned along with
+// the error.
+func (mux *Mux[T]) ReadUntil(ctx context.Context) ([]*TaggedData[T], error) {
+	if mux.isClosed() {
 		return nil, MuxClosed
 	}
+	return readUntil(ctx, mux.Read, mux.collect())
+}
+
+func (mux *Mux[T]) collect() collect[T] {
+	c := collect[T]{
+		merge:  !mux.lines,
+		max:    mux.maxBuffered,
+		policy: mux.bufferPolicy,
+		ring:   mux.ringBytes,
+		head:   mux.headBytes,
+		tail:   mux.tailBytes,
+	}
+	c.held = mux.stats.hold
+	c.unread = func(tag T, data []byte) {
+		mux.unread([]*TaggedData[T]{{Tag: tag, Data: data, ConnID: mux.readsrc.conn, Cred: mux.readsrc.cred,
+			Level: mux.readlevel}})
+	}
+	c.released = mux.stats.release
+	c.dropped = func(tag T, n int) {
+		mux.stats.drop(tag, n)
+		if mux.metrics != nil {
+			mux.metrics.Dropped(tag, n)
+		}
+	}
+	c.source = func() source {
+		return mux.readsrc
+	}
+	c.level = func() slog.Level {
+		return mux.readlevel
+	}
+	if c.max > 0 && c.policy == BufferDropOldestMarker {
+		c.markers = &dropMarkers[T]{index: make(map[T]*TaggedData[T])}
+	}
+	if mux.spillThreshold > 0 && c.max == 0 && c.ring == 0 && c.head == 0 && c.tail == 0 {
+		c.spill = newSpill[T](mux.spillThreshold, mux.spillDir)
+	}
+	return c
+}
+
+func readWhile[T comparable](readUntil func(context.Context) ([]*TaggedData[T], error), waitFn func() error) ([]*TaggedData[T], error) {
 	ctx, cancelFn := context.WithCancel(context.Background())
 	var waitErr error
 	go func() {
 		waitErr = waitFn()
 		cancelFn()
 	}()
-	td, err := mux.ReadUntil(ctx)
+	td, err := readUntil(ctx)
 	if err != nil {
-		return nil, err
+		// waitFn may be blocked on writes that will now never be read, so only report its error if it has returned
+		select {
+		case <-ctx.Done():
+			return td, errors.Join(waitErr, err)
+		default:
+			return td, err
+		}
 	}
+	// reading can finish first once every writer has been closed
+	<-ctx.Done()
 	return td, waitErr
 }
 
-// ReadUntil Read the receiver until done receives true
-func (mux *Mux[T]) ReadUntil(ctx context.Context) ([]*TaggedData[T], error) {
-	if mux.closed {
-		return nil, MuxClosed
+// collect configures how readUntil accumulates data.
+type collect[T comparable] struct {
+	// merge consecutive data with the same tag
+	merge bool
+	// max bytes to accumulate, or no limit if 0, applying policy when exceeded
+	max    int
+	policy BufferPolicy
+	// dropped is called with the bytes discarded for a tag, if not nil
+	dropped func(tag T, n int)
+	// ring limits the bytes kept per tag, discarding the oldest, if not 0
+	ring int
+	// head and tail limit the bytes kept per tag to the first head and last tail bytes, if either is not 0
+	head, tail int
+	// source returns the writer of the last read, if not nil, so data from different writers isn't merged
+	source func() source
+	// level returns the level of the last read, if not nil, so data of different levels isn't merged
+	level func() slog.Level
+	// spill writes the oldest data to a temporary file, if not nil, see WithSpill
+	spill *spill[T]
+	// held is called with the bytes accumulated for a tag, and released once they have been returned, if not nil
+	held     func(tag T, n int)
+	released func()
+	// markers replace the data discarded by BufferDropOldestMarker, if not nil
+	markers *dropMarkers[T]
+	// unread returns data that didn't fit under BufferBlock to be read again, if not nil
+	unread func(tag T, data []byte)
+}
+
+// readUntil reads until io.EOF, accumulating data as configured by c.
+func readUntil[T comparable](ctx context.Context, read func(context.Context) ([]byte, T, error), c collect[T]) ([]*TaggedData[T], error) {
+	td, err := accumulate(ctx, read, c)
+	if c.released != nil {
+		c.released()
+	}
+	if c.markers != nil {
+		td = c.markers.prepend(td)
 	}
+	return td, err
+}
+
+// accumulate reads until io.EOF, accumulating data as configured by c.
+func accumulate[T comparable](ctx context.Context, read func(context.Context) ([]byte, T, error), c collect[T]) ([]*TaggedData[T], error) {
 	var result []*TaggedData[T]
+	var seq uint64
+	size := 0
+	var tagSize map[T]int
+	if c.ring > 0 {
+		tagSize = make(map[T]int)
+	}
+	var ht *headTail[T]
+	if c.head > 0 || c.tail > 0 {
+		ht = newHeadTail[T](c.head, c.tail)
+	}
 	for {
-		data, tag, err := mux.Read(ctx)
+		data, tag, err := read(ctx)
 		if err != nil {
 			if err == io.EOF {
 				return result, nil
 			}
-			return nil, err
+			return result, err
+		}
+		full := false
+		if c.max > 0 && c.policy == BufferBlock && size+len(data) > c.max {
+			// keep up to the limit, leaving the rest to later reads
+			room := c.max - size
+			if c.unread != nil {
+				c.unread(tag, data[room:])
+			}
+			data, full = data[:room:room], true
+			if len(data) == 0 {
+				return result, nil
+			}
+		}
+		size += len(data)
+		if c.held != nil {
+			c.held(tag, len(data))
+		}
+		var src source
+		if c.source != nil {
+			src = c.source()
+		}
+		var level slog.Level
+		if c.level != nil {
+			level = c.level()
 		}
 		resultLen := len(result)
-		if resultLen > 0 {
+		if c.merge && resultLen > 0 && result[resultLen-1].Tag == tag && result[resultLen-1].Truncated == 0 &&
+			result[resultLen-1].ConnID == src.conn && sameCred(result[resultLen-1].Cred, src.cred) &&
+			result[resultLen-1].Level == level {
 			previous := result[resultLen-1]
-			if previous.Tag == tag {
-				previous.Data = append(previous.Data, data...)
-				continue
+			previous.Data = append(previous.Data, data...)
+		} else {
+			seq++
+			result = append(result, &TaggedData[T]{
+				Data:   data,
+				Tag:    tag,
+				Seq:    seq,
+				Time:   time.Now(),
+				ConnID: src.conn,
+				Cred:   src.cred,
+				Level:  level,
+			})
+		}
+		if c.ring > 0 {
+			tagSize[tag] += len(data)
+			if over := tagSize[tag] - c.ring; over > 0 {
+				result = dropOldestTag(result, tag, over, c.dropped)
+				tagSize[tag] = c.ring
+				size -= over
+			}
+		}
+		if ht != nil {
+			var over int
+			result, over = ht.add(result, tag, len(data), c.dropped)
+			size -= over
+		}
+		if c.spill != nil {
+			if err := c.spill.add(result, len(data)); err != nil {
+				return result, err
+			}
+		}
+		if full {
+			return result, nil
+		}
+		if c.max > 0 && size > c.max {
+			switch c.policy {
+			case BufferDropOldest:
+				result, size = dropOldest(result, size-c.max, c.dropped), c.max
+			case BufferDropOldestMarker:
+				result, size = dropOldest(result, size-c.max, c.markers.drop(c.dropped)), c.max
+			case BufferError:
+				return result, MuxBufferFull
+			}
+		}
+	}
+}
+
+// dropOldest removes n bytes from the start of td, reporting them to dropped if not nil.
+func dropOldest[T comparable](td []*TaggedData[T], n int, dropped func(tag T, n int)) []*TaggedData[T] {
+	for n > 0 && len(td) > 0 {
+		first := td[0]
+		if len(first.Data) > n {
+			first.Data = first.Data[n:]
+			if dropped != nil {
+				dropped(first.Tag, n)
+			}
+			break
+		}
+		n -= len(first.Data)
+		if dropped != nil {
+			dropped(first.Tag, len(first.Data))
+		}
+		td[0] = nil
+		td = td[1:]
+	}
+	return td
+}
+
+// Stream Read in the background, delivering each chunk on the returned channel as it arrives rather than after the
+// fact like ReadWhile. The channel is closed when the stream ends, either because the stop function was called or a
+// read failed. The stop function ends the stream, waits for the background reader to finish and returns the error
+// that ended the stream, if any. Read must not be called while a stream is active.
+func (mux *Mux[T]) Stream() (<-chan *TaggedData[T], func() error) {
+	return stream(mux.Read)
+}
+
+func stream[T comparable](read func(context.Context) ([]byte, T, error)) (<-chan *TaggedData[T], func() error) {
+	ch := make(chan *TaggedData[T])
+	ctx, cancelFn := context.WithCancel(context.Background())
+	var streamErr error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer close(ch)
+		var seq uint64
+		for {
+			data, tag, err := read(ctx)
+			if err != nil {
+				if err != io.EOF {
+					streamErr = err
+				}
+				return
+			}
+			seq++
+			select {
+			case ch <- &TaggedData[T]{Data: data, Tag: tag, Seq: seq, Time: time.Now()}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, func() error {
+		cancelFn()
+		<-done
+		return streamErr
+	}
+}
+
+// All Read until ctx is done, yielding each chunk and its tag as it arrives, for use with range. Iteration ends at
+// io.EOF, or early on any other read error, so Read should be used when errors need handling.
+func (mux *Mux[T]) All(ctx context.Context) iter.Seq2[T, []byte] {
+	return all(ctx, mux.Read)
+}
+
+func all[T comparable](ctx context.Context, read func(context.Context) ([]byte, T, error)) iter.Seq2[T, []byte] {
+	return func(yield func(T, []byte) bool) {
+		for {
+			data, tag, err := read(ctx)
+			if err != nil {
+				return
+			}
+			if !yield(tag, data) {
+				return
 			}
 		}
-		result = append(result, &TaggedData[T]{
-			Data: data,
-			Tag:  tag,
-		})
 	}
 }
 
-// Close closes the Mux, closing connections and removing temporary files. Prevents reuse.
+// Close closes the Mux, closing connections and removing temporary files. Prevents reuse. Sinks given by WithSink are
+// closed once their queued data is written, returning their errors.
 func (mux *Mux[T]) Close() error {
+	mux.closemutex.Lock()
 	if mux.closed {
+		mux.closemutex.Unlock()
 		return MuxClosed
 	}
 	mux.closed = true
-	for _, closer := range mux.closers {
+	closers := mux.closers
+	mux.closers = nil
+	mux.closemutex.Unlock()
+	for _, closer := range closers {
 		closer.Close()
 	}
 	os.RemoveAll(mux.dir)
-	return nil
+	return mux.closeSinks()
+}
+
+// isClosed reports whether Close has been called.
+func (mux *Mux[T]) isClosed() bool {
+	mux.closemutex.Lock()
+	defer mux.closemutex.Unlock()
+	return mux.closed
+}
+
+// addCloser closes c when the Mux is closed, or straight away if it already has been.
+func (mux *Mux[T]) addCloser(c io.Closer) {
+	mux.closemutex.Lock()
+	if mux.closed {
+		mux.closemutex.Unlock()
+		c.Close()
+		return
+	}
+	mux.closers = append(mux.closers, c)
+	mux.closemutex.Unlock()
+}
+
+// removeCloser forgets c, which has been closed before the Mux, so closers doesn't grow with every writer.
+func (mux *Mux[T]) removeCloser(c io.Closer) {
+	mux.closemutex.Lock()
+	mux.closers = slices.DeleteFunc(mux.closers, func(closer io.Closer) bool {
+		return closer == c
+	})
+	mux.closemutex.Unlock()
 }
 
 func (mux *Mux[T]) createReceiver() (e error) {
 	mux.recvonce.Do(func() {
-		if mux.network == "" {
-			switch runtime.GOOS {
-			case "darwin":
-				mux.network = "unix"
-			default:
-				mux.network = "unixgram"
+		networks := []string{mux.network}
+		if mux.auto {
+			networks = mux.autoNetworks()
+		} else if mux.network == "" {
+			networks = []string{mux.defaultNetwork()}
+		}
+		for _, network := range networks {
+			mux.network = network
+			if e = mux.startReceiver(); e == nil {
+				return
 			}
 		}
+	})
+	return
+}
 
-		mux.dir, e = os.MkdirTemp("", "mux")
-		if e != nil {
-			return
-		}
-		file := filepath.Join(mux.dir, "recv.sock")
-		mux.recvaddr, e = net.ResolveUnixAddr(mux.network, file)
-		if e != nil {
-			return
+// startReceiver prepares to receive on the network of the Mux.
+func (mux *Mux[T]) startReceiver() error {
+	if mux.strict && !mux.Ordered() {
+		return MuxUnordered
+	}
+
+	mux.recvchan = make(chan *taggedData[T], 10)
+	mux.recvchanged = make(chan struct{}, 1)
+	if mux.socketpair {
+		if mux.auto {
+			// socket pairs are only created by Tag, so check this network can create them now
+			conn, sender, err := socketpair(mux.network)
+			if err != nil {
+				return err
+			}
+			conn.Close()
+			sender.Close()
 		}
-		mux.recvchan = make(chan *taggedData[T], 10)
-		e = mux.startListener()
-		if e != nil {
-			return
+		mux.startSocketpair()
+		return nil
+	}
+	var err error
+	if mux.abstract && runtime.GOOS == "linux" {
+		if mux.namespace == "" {
+			mux.namespace, err = abstractNamespace()
 		}
-	})
-	return
+	} else if mux.dir == "" {
+		err = mux.createDir()
+	}
+	if err != nil {
+		return err
+	}
+	mux.recvaddr, err = net.ResolveUnixAddr(mux.network, mux.address("recv.sock"))
+	if err != nil {
+		return err
+	}
+	return mux.startListener()
 }
 
-func (mux *Mux[T]) startListener() error {
+// abstractNamespace returns a unique prefix for socket names in the Linux abstract namespace.
+func abstractNamespace() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "iomux-" + hex.EncodeToString(b), nil
+}
+
+// address returns the address for the socket called name, in the abstract namespace if enabled, or the temporary
+// directory.
+func (mux *Mux[T]) address(name string) string {
+	if mux.namespace != "" {
+		return "@" + mux.namespace + "/" + name
+	}
+	return filepath.Join(mux.dir, name)
+}
+
+// batchSize returns how many messages to receive per syscall, see WithReadBatch.
+func (mux *Mux[T]) batchSize() int {
+	if mux.creds {
+		// batches don't receive credentials
+		return 1
+	}
+	if mux.readBatch == 0 {
+		return defaultReadBatch
+	}
+	return mux.readBatch
+}
+
+// bufferSize returns the size of the receive buffers for the network.
+func (mux *Mux[T]) bufferSize() int {
 	// If we got at the underlying poll.FD it would be possible to call recvfrom with MSG_PEEK | MSG_TRUNC to size
 	// the buffer to the current packet, but for now we just set the maximum message size for the OS for message
-	// oriented unixgram, because the message truncates if it exceeds the buffer, and a modest read buffer otherwise.
-	bufsize := 0
+	// oriented unixgram and unixpacket, because the message truncates if it exceeds the buffer, and a modest read
+	// buffer otherwise.
+	switch mux.network {
+	case "unixgram":
+		if runtime.GOOS == "darwin" {
+			return 2048
+		}
+		return 65536
+	case "unixpacket":
+		return 65536
+	default:
+		return 256
+	}
+}
+
+func (mux *Mux[T]) startListener() error {
+	bufsize := mux.bufferSize()
 	switch mux.network {
 	case "unixgram":
 		{
-			switch runtime.GOOS {
-			case "darwin":
-				bufsize = 2048
-			default:
-				bufsize = 65536
-			}
 			conn, err := net.ListenUnixgram(mux.network, mux.recvaddr)
 			if err != nil {
 				return err
 			}
-			mux.closers = append(mux.closers, conn)
+			mux.addCloser(conn)
 			mux.acceptFn = func() error {
 				return nil
 			}
 			_ = conn.CloseWrite()
-			mux.recvconns = append(mux.recvconns, conn)
-			mux.recvbufs = append(mux.recvbufs, make([]byte, bufsize))
-			mux.recvmutex = append(mux.recvmutex, sync.Mutex{})
+			mux.addReceiver(conn)
 		}
 	case "unix", "unixpacket":
 		{
-			bufsize = 256
 			listener, err := net.ListenUnix(mux.network, mux.recvaddr)
 			if err != nil {
 				return err
 			}
-			mux.closers = append(mux.closers, listener)
+			mux.addCloser(listener)
+			// fall back to a goroutine per connection where there is no poller
+			if p, err := newPoller(); err == nil {
+				mux.poller = p
+				mux.pollbuf = make([]byte, bufsize)
+				mux.pollbatch = newRecvBatch(mux.network, mux.batchSize(), bufsize)
+				if mux.creds {
+					mux.polloob = make([]byte, credOOBSize)
+				}
+				mux.addCloser(p)
+			}
 			mux.acceptFn = func() error {
 				err := listener.SetDeadline(time.Now().Add(deadlineDuration))
 				if err != nil {
@@ -347,11 +1282,15 @@ func (mux *Mux[T]) startListener() error {
 				if err != nil {
 					return err
 				}
-				mux.closers = append(mux.closers, conn)
+				mux.addCloser(conn)
 				_ = conn.CloseWrite()
-				mux.recvconns = append(mux.recvconns, conn)
-				mux.recvbufs = append(mux.recvbufs, make([]byte, bufsize))
-				mux.recvmutex = append(mux.recvmutex, sync.Mutex{})
+				if mux.poller != nil {
+					if err := mux.poller.add(conn); err != nil {
+						conn.Close()
+						return err
+					}
+				}
+				mux.addReceiver(conn)
 				return nil
 			}
 		}
@@ -360,40 +1299,54 @@ func (mux *Mux[T]) startListener() error {
 	return nil
 }
 
+// createSender connects a new sender for tag. Each sender has its own address, which identifies the tag of the data
+// it sends, and is only held open by the returned file, so closing it can be detected on connection oriented networks.
 func (mux *Mux[T]) createSender(tag T) (*os.File, error) {
-	if mux.senders == nil {
-		mux.senders = make(map[T]*net.UnixConn)
+	if mux.socketpair {
+		return mux.createPair(tag)
 	}
-	num := len(mux.senders) + 1
-	if _, ok := mux.senders[tag]; !ok {
-		address := filepath.Join(mux.dir, fmt.Sprintf("send_%d.sock", num))
-		addr, err := net.ResolveUnixAddr(mux.network, address)
-		if err != nil {
-			return nil, err
-		}
-		wg := sync.WaitGroup{}
-		wg.Add(1)
-		var acceptErr error
-		go func() {
-			acceptErr = mux.acceptFn()
-			wg.Done()
-		}()
-		conn, dialErr := net.DialUnix(mux.network, addr, mux.recvaddr)
-		wg.Wait()
-		if acceptErr != nil {
-			return nil, acceptErr
-		}
-		if dialErr != nil {
-			return nil, dialErr
+	mux.sendmutex.Lock()
+	mux.sendnum++
+	num := mux.sendnum
+	mux.sendmutex.Unlock()
+	addr, err := net.ResolveUnixAddr(mux.network, mux.address(fmt.Sprintf("send_%d.sock", num)))
+	if err != nil {
+		return nil, err
+	}
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	var acceptErr error
+	go func() {
+		acceptErr = mux.acceptFn()
+		wg.Done()
+	}()
+	conn, dialErr := net.DialUnix(mux.network, addr, mux.recvaddr)
+	wg.Wait()
+	if acceptErr != nil {
+		if dialErr == nil {
+			conn.Close()
 		}
-		mux.closers = append(mux.closers, conn)
-		_ = conn.CloseRead()
-		mux.senders[tag] = conn
+		return nil, acceptErr
 	}
+	if dialErr != nil {
+		return nil, dialErr
+	}
+	_ = conn.CloseRead()
 
-	file, err := mux.senders[tag].File()
-	if err != nil {
-		return nil, err
+	mux.sendmutex.Lock()
+	if mux.tags == nil {
+		mux.tags = make(map[string]tagSender[T])
+		mux.open = make(map[T]int)
+	}
+	mux.tags[conn.LocalAddr().String()] = tagSender[T]{tag: tag, id: uint64(num)}
+	mux.open[tag]++
+	mux.sendmutex.Unlock()
+	if mux.metrics != nil {
+		mux.metrics.Connected(tag)
+	}
+
+	if mux.fragmented() {
+		return fragmentFile(conn, mux.bufferSize())
 	}
-	return file, nil
+	return senderFile(conn)
 }
@@ -1,3 +1,5 @@
+//go:build !windows
+
 package iomux
 
 import (
@@ -10,6 +12,7 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"runtime"
 	"testing"
 	"time"
 )
@@ -104,6 +107,279 @@ func TestMuxReadNoData(t *testing.T) {
 	}
 }
 
+func TestMuxReadDeadline(t *testing.T) {
+	for _, network := range networks {
+		t.Run(network, func(t *testing.T) {
+			mux := &Mux[string]{network: network}
+			t.Cleanup(func() {
+				mux.Close()
+			})
+			_, err := mux.Tag("a")
+			if err != nil {
+				skipIfProtocolNotSupported(t, err)
+				assert.Nil(t, err)
+			}
+			_, err = mux.Tag("b")
+			assert.Nil(t, err)
+
+			ctx, cancelFn := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancelFn()
+			start := time.Now()
+			bytes, _, err := mux.Read(ctx)
+			assert.Nil(t, bytes)
+			assert.Equal(t, io.EOF, err)
+			assert.Less(t, time.Since(start), time.Second)
+		})
+	}
+}
+
+func TestMuxReadContext(t *testing.T) {
+	for _, network := range networks {
+		t.Run(network, func(t *testing.T) {
+			mux := &Mux[string]{network: network}
+			t.Cleanup(func() {
+				mux.Close()
+			})
+			taga, err := mux.Tag("a")
+			if err != nil {
+				skipIfProtocolNotSupported(t, err)
+				assert.Nil(t, err)
+			}
+
+			ctx, cancelFn := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancelFn()
+			start := time.Now()
+			data, _, err := mux.ReadContext(ctx)
+			assert.Nil(t, data)
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+			assert.Less(t, time.Since(start), time.Second)
+
+			io.WriteString(taga, "hello")
+			data, tag, err := mux.ReadContext(context.Background())
+			assert.Nil(t, err)
+			assert.Equal(t, "a", tag)
+			assert.Equal(t, "hello", string(data))
+		})
+	}
+}
+
+func TestMuxSetReadDeadline(t *testing.T) {
+	mux := &Mux[string]{}
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	taga, err := mux.Tag("a")
+	assert.Nil(t, err)
+
+	mux.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	io.WriteString(taga, "hello taga")
+	data, _, err := mux.Read(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "hello taga", string(data))
+	_, _, err = mux.Read(context.Background())
+	assert.ErrorIs(t, err, os.ErrDeadlineExceeded)
+
+	mux.SetReadDeadline(time.Time{})
+	io.WriteString(taga, "hello again")
+	data, _, err = mux.Read(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "hello again", string(data))
+}
+
+func TestMuxTryRead(t *testing.T) {
+	for _, network := range networks {
+		t.Run(network, func(t *testing.T) {
+			mux := &Mux[string]{network: network}
+			t.Cleanup(func() {
+				mux.Close()
+			})
+			taga, err := mux.Tag("a")
+			if err != nil {
+				skipIfProtocolNotSupported(t, err)
+				assert.Nil(t, err)
+			}
+			tagb, _ := mux.Tag("b")
+
+			_, _, ok, err := mux.TryRead()
+			assert.Nil(t, err)
+			assert.False(t, ok)
+
+			io.WriteString(taga, "hello taga")
+			io.WriteString(tagb, "hello tagb")
+			time.Sleep(sleepDuration)
+			received := make(map[string]string)
+			for i := 0; i < 2; i++ {
+				data, tag, ok, err := mux.TryRead()
+				assert.Nil(t, err)
+				assert.True(t, ok)
+				received[tag] = string(data)
+			}
+			assert.Equal(t, map[string]string{"a": "hello taga", "b": "hello tagb"}, received)
+
+			_, _, ok, err = mux.TryRead()
+			assert.Nil(t, err)
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestMuxReadInto(t *testing.T) {
+	for _, network := range networks {
+		t.Run(network, func(t *testing.T) {
+			mux := &Mux[string]{network: network}
+			t.Cleanup(func() {
+				mux.Close()
+			})
+			taga, err := mux.Tag("a")
+			if err != nil {
+				skipIfProtocolNotSupported(t, err)
+				assert.Nil(t, err)
+			}
+
+			buf := make([]byte, 64)
+			io.WriteString(taga, "hello taga")
+			n, tag, err := mux.ReadInto(context.Background(), buf)
+			assert.Nil(t, err)
+			assert.Equal(t, "a", tag)
+			assert.Equal(t, "hello taga", string(buf[:n]))
+
+			// data that doesn't fit is returned by the next read
+			io.WriteString(taga, "hello again")
+			n, tag, err = mux.ReadInto(context.Background(), buf[:5])
+			assert.Nil(t, err)
+			assert.Equal(t, "a", tag)
+			assert.Equal(t, "hello", string(buf[:n]))
+			data, tag, err := mux.Read(context.Background())
+			assert.Nil(t, err)
+			assert.Equal(t, "a", tag)
+			assert.Equal(t, " again", string(data))
+		})
+	}
+}
+
+func TestMuxReadIntoSubscriber(t *testing.T) {
+	mux := NewMux[string]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	taga, _ := mux.Tag("a")
+	tagb, _ := mux.Tag("b")
+	var subscribed []byte
+	mux.Subscribe("a", func(data []byte) {
+		subscribed = data
+	})
+
+	io.WriteString(taga, "hello taga")
+	io.WriteString(tagb, "hello tagb")
+	buf := make([]byte, 64)
+	n, tag, err := mux.ReadInto(context.Background(), buf)
+	assert.Nil(t, err)
+	assert.Equal(t, "b", tag)
+	assert.Equal(t, "hello tagb", string(buf[:n]))
+	// the subscriber's data isn't overwritten by later reads into buf
+	assert.Equal(t, "hello taga", string(subscribed))
+}
+
+func TestMuxConnID(t *testing.T) {
+	for _, network := range networks {
+		for _, opts := range [][]Option[string]{nil, {WithSocketpair[string]()}} {
+			mux := newMux(network, opts)
+			t.Cleanup(func() {
+				mux.Close()
+			})
+			first, err := mux.Tag("a")
+			if err != nil {
+				skipIfProtocolNotSupported(t, err)
+				assert.Nil(t, err)
+				continue
+			}
+			second, _ := mux.Tag("a")
+
+			td, err := mux.ReadWhile(func() error {
+				io.WriteString(first, "first")
+				time.Sleep(sleepDuration)
+				io.WriteString(second, "second")
+				time.Sleep(sleepDuration)
+				io.WriteString(first, "again")
+				first.Close()
+				return second.Close()
+			})
+			assert.Nil(t, err)
+			// the writers share the tag, but their data isn't merged
+			assert.Len(t, td, 3)
+			ids := map[string]uint64{}
+			for _, d := range td {
+				assert.Equal(t, "a", d.Tag)
+				ids[string(d.Data)] = d.ConnID
+			}
+			assert.NotZero(t, ids["first"])
+			assert.Equal(t, ids["first"], ids["again"])
+			assert.NotEqual(t, ids["first"], ids["second"])
+		}
+	}
+}
+
+func TestMuxTagsWriter(t *testing.T) {
+	mux := NewMux[string]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	assert.Empty(t, mux.Tags())
+	taga, _ := mux.Tag("b")
+	mux.Tag("a")
+	mux.Tag("b")
+	assert.Equal(t, []string{"b", "a"}, mux.Tags())
+
+	w, ok := mux.Writer("b")
+	assert.True(t, ok)
+	assert.Equal(t, taga, w)
+	_, ok = mux.Writer("c")
+	assert.False(t, ok)
+
+	io.WriteString(w, "hello tagb")
+	data, tag, err := mux.Read(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "b", tag)
+	assert.Equal(t, "hello tagb", string(data))
+
+	mux.Untag("b")
+	assert.Equal(t, []string{"a"}, mux.Tags())
+	_, ok = mux.Writer("b")
+	assert.False(t, ok)
+}
+
+func TestMuxTagSyscallConn(t *testing.T) {
+	for _, network := range networks {
+		t.Run(network, func(t *testing.T) {
+			mux := newMux[string](network, nil)
+			t.Cleanup(func() {
+				mux.Close()
+			})
+			w, err := mux.Tag("a")
+			skipIfProtocolNotSupported(t, err)
+			assert.Nil(t, err)
+			raw, err := w.SyscallConn()
+			assert.Nil(t, err)
+			var sndbuf int
+			var sockErr error
+			err = raw.Control(func(fd uintptr) {
+				sockErr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_SNDBUF, 64*1024)
+				if sockErr == nil {
+					sndbuf, sockErr = unix.GetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_SNDBUF)
+				}
+			})
+			assert.Nil(t, err)
+			assert.Nil(t, sockErr)
+			assert.GreaterOrEqual(t, sndbuf, 64*1024)
+
+			io.WriteString(w, "hello")
+			data, _, err := mux.Read(context.Background())
+			assert.Nil(t, err)
+			assert.Equal(t, "hello", string(data))
+		})
+	}
+}
+
 // More than one active context isn't really an intended use case, but making sure this logic works correctly
 func TestMuxMultipleContexts(t *testing.T) {
 	mux := &Mux[string]{}
@@ -144,15 +420,33 @@ func TestMuxReadWhileErr(t *testing.T) {
 	t.Cleanup(func() {
 		mux.Close()
 	})
-	_, err := mux.Tag("a")
+	taga, err := mux.Tag("a")
 	assert.Nil(t, err)
 
 	expected := errors.New("this is an error")
-	_, err = mux.ReadWhile(func() error {
+	td, err := mux.ReadWhile(func() error {
+		io.WriteString(taga, "hello taga")
+		time.Sleep(sleepDuration)
 		return expected
 	})
 
 	assert.ErrorIs(t, expected, err)
+	assert.Equal(t, 1, len(td))
+	assert.Equal(t, "hello taga", string(td[0].Data))
+}
+
+func TestReadWhileJoinedErr(t *testing.T) {
+	waitErr := errors.New("wait error")
+	readErr := errors.New("read error")
+	td, err := readWhile(func(ctx context.Context) ([]*TaggedData[string], error) {
+		<-ctx.Done()
+		return []*TaggedData[string]{{Tag: "a", Data: []byte("hello taga")}}, readErr
+	}, func() error {
+		return waitErr
+	})
+	assert.ErrorIs(t, err, waitErr)
+	assert.ErrorIs(t, err, readErr)
+	assert.Equal(t, 1, len(td))
 }
 
 func TestMuxTruncatedRead(t *testing.T) {
@@ -183,14 +477,9 @@ func TestMuxTruncatedRead(t *testing.T) {
 }
 
 func skipIfProtocolNotSupported(t *testing.T, err error) {
-	err = errors.Unwrap(err)
-	if sys, ok := err.(*os.SyscallError); ok {
-		if sys.Syscall == "socket" {
-			err = errors.Unwrap(err)
-			if err == unix.EPROTONOSUPPORT {
-				t.Skip("unsupported protocol")
-			}
-		}
+	var sys *os.SyscallError
+	if errors.As(err, &sys) && sys.Syscall == "socket" && sys.Err == unix.EPROTONOSUPPORT {
+		t.Skip("unsupported protocol")
 	}
 }
 
@@ -234,6 +523,150 @@ func TestMuxMultiple(t *testing.T) {
 	}
 }
 
+func TestMuxReadWhileLargeOutput(t *testing.T) {
+	for _, network := range networks {
+		t.Run(network, func(t *testing.T) {
+			if network == "unixgram" && runtime.GOOS == "darwin" {
+				t.Skip("writes exceed the unixgram message size limit")
+			}
+			mux := &Mux[int]{network: network}
+			t.Cleanup(func() {
+				mux.Close()
+			})
+			cmd := exec.Command("sh", "-c", "head -c 4000000 /dev/zero && head -c 3000000 /dev/zero 1>&2")
+			stdout, err := mux.Tag(0)
+			if err != nil {
+				skipIfProtocolNotSupported(t, err)
+				assert.Nil(t, err)
+			}
+			stderr, _ := mux.Tag(1)
+			cmd.Stdout = stdout
+			cmd.Stderr = stderr
+			td, err := mux.ReadWhile(func() error {
+				return cmd.Run()
+			})
+			assert.Nil(t, err)
+
+			total := make(map[int]int)
+			for _, d := range td {
+				total[d.Tag] += len(d.Data)
+			}
+			assert.Equal(t, 4000000, total[0])
+			assert.Equal(t, 3000000, total[1])
+		})
+	}
+}
+
+func TestMuxDrain(t *testing.T) {
+	for _, network := range networks {
+		t.Run(network, func(t *testing.T) {
+			mux := &Mux[string]{network: network}
+			t.Cleanup(func() {
+				mux.Close()
+			})
+			taga, err := mux.Tag("a")
+			if err != nil {
+				skipIfProtocolNotSupported(t, err)
+				assert.Nil(t, err)
+			}
+			tagb, _ := mux.Tag("b")
+
+			io.WriteString(taga, "out1")
+			io.WriteString(tagb, "err1")
+			taga.Close()
+			tagb.Close()
+			grace := time.Minute
+			if network == "unixgram" {
+				grace = 50 * time.Millisecond
+			}
+			td, err := mux.Drain(grace)
+			assert.Nil(t, err)
+
+			// written before reading, so connection oriented networks may read tags in any order
+			data := make(map[string]string)
+			for _, d := range td {
+				data[d.Tag] += string(d.Data)
+			}
+			assert.Equal(t, map[string]string{"a": "out1", "b": "err1"}, data)
+		})
+	}
+}
+
+func TestMuxStream(t *testing.T) {
+	for _, network := range networks {
+		t.Run(network, func(t *testing.T) {
+			mux := &Mux[string]{network: network}
+			t.Cleanup(func() {
+				mux.Close()
+			})
+			taga, err := mux.Tag("a")
+			if err != nil {
+				skipIfProtocolNotSupported(t, err)
+				assert.Nil(t, err)
+			}
+			tagb, _ := mux.Tag("b")
+
+			ch, stop := mux.Stream()
+			io.WriteString(taga, "hello taga")
+			td := <-ch
+			assert.Equal(t, "a", td.Tag)
+			assert.Equal(t, "hello taga", string(td.Data))
+
+			io.WriteString(tagb, "hello tagb")
+			td = <-ch
+			assert.Equal(t, "b", td.Tag)
+			assert.Equal(t, "hello tagb", string(td.Data))
+
+			assert.Nil(t, stop())
+			_, ok := <-ch
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestMuxAll(t *testing.T) {
+	for _, network := range networks {
+		t.Run(network, func(t *testing.T) {
+			mux := &Mux[string]{network: network}
+			t.Cleanup(func() {
+				mux.Close()
+			})
+			taga, err := mux.Tag("a")
+			if err != nil {
+				skipIfProtocolNotSupported(t, err)
+				assert.Nil(t, err)
+			}
+			tagb, _ := mux.Tag("b")
+
+			ctx, cancelFn := context.WithCancel(context.Background())
+			defer cancelFn()
+			io.WriteString(taga, "hello taga")
+			var tags []string
+			var data []string
+			for tag, b := range mux.All(ctx) {
+				tags = append(tags, tag)
+				data = append(data, string(b))
+				if tag == "a" {
+					io.WriteString(tagb, "hello tagb")
+				} else {
+					cancelFn()
+				}
+			}
+			assert.Equal(t, []string{"a", "b"}, tags)
+			assert.Equal(t, []string{"hello taga", "hello tagb"}, data)
+		})
+	}
+}
+
+func TestMuxStreamClosed(t *testing.T) {
+	mux := &Mux[string]{}
+	mux.Close()
+	ch, stop := mux.Stream()
+	_, ok := <-ch
+	assert.False(t, ok)
+	assert.ErrorIs(t, stop(), MuxClosed)
+}
+
 func TestMuxCmd(t *testing.T) {
 	for _, network := range networks {
 		t.Run(network, func(t *testing.T) {
@@ -0,0 +1,71 @@
+package iomux
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// jsonlRecord is the schema of each line of JSON Lines, with data encoded as base64 so binary data survives.
+type jsonlRecord[T comparable] struct {
+	Seq  uint64    `json:"seq"`
+	Time time.Time `json:"time"`
+	Tag  T         `json:"tag"`
+	Data []byte    `json:"data"`
+}
+
+// JSONLEncoder writes tagged data as JSON Lines, one object per chunk with the fields seq, time, tag and data, with
+// data encoded as base64. Tags are encoded using encoding/json, so T must be supported by it.
+type JSONLEncoder[T comparable] struct {
+	enc *json.Encoder
+}
+
+// JSONLDecoder reads tagged data written by a JSONLEncoder.
+type JSONLDecoder[T comparable] struct {
+	dec *json.Decoder
+}
+
+// NewJSONLEncoder Create a JSONLEncoder writing to w.
+func NewJSONLEncoder[T comparable](w io.Writer) *JSONLEncoder[T] {
+	return &JSONLEncoder[T]{enc: json.NewEncoder(w)}
+}
+
+// Encode Write td as a line of JSON.
+func (e *JSONLEncoder[T]) Encode(td *TaggedData[T]) error {
+	return e.enc.Encode(&jsonlRecord[T]{
+		Seq:  td.Seq,
+		Time: td.Time,
+		Tag:  td.Tag,
+		Data: td.Data,
+	})
+}
+
+// EncodeJSONL Write td to w as JSON Lines, see JSONLEncoder.
+func EncodeJSONL[T comparable](w io.Writer, td []*TaggedData[T]) error {
+	enc := NewJSONLEncoder[T](w)
+	for _, d := range td {
+		if err := enc.Encode(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewJSONLDecoder Create a JSONLDecoder reading from r.
+func NewJSONLDecoder[T comparable](r io.Reader) *JSONLDecoder[T] {
+	return &JSONLDecoder[T]{dec: json.NewDecoder(r)}
+}
+
+// Decode Read the next line of JSON. Returns io.EOF error when there are no more lines.
+func (d *JSONLDecoder[T]) Decode() (*TaggedData[T], error) {
+	var rec jsonlRecord[T]
+	if err := d.dec.Decode(&rec); err != nil {
+		return nil, err
+	}
+	return &TaggedData[T]{
+		Seq:  rec.Seq,
+		Time: rec.Time,
+		Tag:  rec.Tag,
+		Data: rec.Data,
+	}, nil
+}
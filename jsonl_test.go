@@ -0,0 +1,36 @@
+package iomux
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONL(t *testing.T) {
+	now := time.Date(2023, 2, 8, 12, 0, 0, 0, time.UTC)
+	td := []*TaggedData[string]{
+		{Tag: "a", Data: []byte("out1\n"), Seq: 1, Time: now},
+		{Tag: "b", Data: []byte{0, 1, 2}, Seq: 2, Time: now.Add(time.Second)},
+	}
+
+	var buf bytes.Buffer
+	assert.Nil(t, EncodeJSONL(&buf, td))
+	assert.Equal(t, `{"seq":1,"time":"2023-02-08T12:00:00Z","tag":"a","data":"b3V0MQo="}
+{"seq":2,"time":"2023-02-08T12:00:01Z","tag":"b","data":"AAEC"}
+`, buf.String())
+
+	dec := NewJSONLDecoder[string](&buf)
+	for _, expected := range td {
+		actual, err := dec.Decode()
+		assert.Nil(t, err)
+		assert.Equal(t, expected.Seq, actual.Seq)
+		assert.True(t, expected.Time.Equal(actual.Time))
+		assert.Equal(t, expected.Tag, actual.Tag)
+		assert.Equal(t, expected.Data, actual.Data)
+	}
+	_, err := dec.Decode()
+	assert.Equal(t, io.EOF, err)
+}
@@ -0,0 +1,46 @@
+package iomux
+
+import (
+	"log/slog"
+	"regexp"
+)
+
+var (
+	// ErrorPattern matches data reporting an error, see Classify.
+	ErrorPattern = regexp.MustCompile(`(?i)\b(error|err|fatal|panic|exception|critical|crit)\b`)
+	// WarnPattern matches data reporting a warning, see Classify.
+	WarnPattern = regexp.MustCompile(`(?i)\b(warn|warning|deprecated)\b`)
+)
+
+// Classify Create a classifier for WithClassifier, assigning slog.LevelError to data matching ErrorPattern,
+// slog.LevelWarn to data matching WarnPattern or of warnTags, such as Stderr, and slog.LevelInfo to the rest.
+func Classify[T comparable](warnTags ...T) func(tag T, data []byte) slog.Level {
+	warn := make(map[T]bool, len(warnTags))
+	for _, tag := range warnTags {
+		warn[tag] = true
+	}
+	return func(tag T, data []byte) slog.Level {
+		switch {
+		case ErrorPattern.Match(data):
+			return slog.LevelError
+		case warn[tag] || WarnPattern.Match(data):
+			return slog.LevelWarn
+		default:
+			return slog.LevelInfo
+		}
+	}
+}
+
+// LastLevel Return the level of the data last returned by Read, see WithClassifier.
+func (mux *Mux[T]) LastLevel() slog.Level {
+	return mux.readlevel
+}
+
+// classifyLevel sets the level of td, reporting whether it should be returned by Read, see WithMinLevel.
+func (mux *Mux[T]) classifyLevel(td *TaggedData[T]) bool {
+	if mux.classify == nil {
+		return true
+	}
+	td.Level = mux.classify(td.Tag, td.Data)
+	return !mux.filterLevel || td.Level >= mux.minLevel
+}
@@ -0,0 +1,51 @@
+//go:build !windows
+
+package iomux
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassify(t *testing.T) {
+	classify := Classify("stderr")
+	assert.Equal(t, slog.LevelInfo, classify("stdout", []byte("listening on :8080\n")))
+	assert.Equal(t, slog.LevelWarn, classify("stdout", []byte("WARNING: low disk\n")))
+	assert.Equal(t, slog.LevelWarn, classify("stderr", []byte("listening on :8080\n")))
+	assert.Equal(t, slog.LevelError, classify("stdout", []byte("Fatal: out of memory\n")))
+	assert.Equal(t, slog.LevelError, classify("stderr", []byte("level=error msg=boom\n")))
+	assert.Equal(t, slog.LevelInfo, classify("stdout", []byte("no errors found\n")))
+}
+
+func TestMuxMinLevel(t *testing.T) {
+	sink := &testSink{fail: -1}
+	mux := NewMux[string](
+		WithLineSplitting[string](),
+		WithClassifier(Classify("stderr")),
+		WithMinLevel[string](slog.LevelWarn),
+		WithSink[string](sink),
+	)
+	stdout, _ := mux.Tag("stdout")
+	stderr, _ := mux.Tag("stderr")
+
+	td, err := mux.ReadWhile(func() error {
+		io.WriteString(stdout, "starting\nerror: no config\n")
+		io.WriteString(stderr, "using defaults\n")
+		io.WriteString(stdout, "done\n")
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Len(t, td, 2)
+	assert.Equal(t, "error: no config\n", string(td[0].Data))
+	assert.Equal(t, slog.LevelError, td[0].Level)
+	assert.Equal(t, "using defaults\n", string(td[1].Data))
+	assert.Equal(t, slog.LevelWarn, td[1].Level)
+
+	// sinks still see everything
+	assert.Nil(t, mux.Close())
+	assert.Equal(t, []string{"1:stdout:starting\n", "2:stdout:error: no config\n", "3:stderr:using defaults\n",
+		"4:stdout:done\n"}, sink.data)
+}
@@ -0,0 +1,122 @@
+package iomux
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// StackTraceContinuation matches the lines following the first line of Go panics and Java stack traces, and indented
+// or blank lines, for use with WithLineGrouping.
+var StackTraceContinuation = regexp.MustCompile(`^(\s|Caused by: |\.\.\. \d+ (more|common frames omitted)|goroutine \d+ \[|created by |\S+\(.*\)\s*$)`)
+
+// lineSplitter splits data into lines per tag, holding back partial lines until they are completed.
+type lineSplitter[T comparable] struct {
+	partial []*TaggedData[T]
+	// fold keeps only the last carriage return separated frame of each line, see WithCarriageReturnFolding
+	fold bool
+	// group merges lines it matches into the preceding line of the same tag, see WithLineGrouping
+	group *regexp.Regexp
+	// held is the last record of each tag when grouping, until a line that isn't a continuation starts the next
+	held []*TaggedData[T]
+}
+
+// split returns each complete line in data, including the trailing newline, holding back a trailing partial line for
+// tag.
+func (ls *lineSplitter[T]) split(tag T, data []byte) []*TaggedData[T] {
+	if partial := take(&ls.partial, tag); partial != nil {
+		data = append(partial.Data, data...)
+	}
+	var lines []*TaggedData[T]
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		line := data[: i+1 : i+1]
+		if ls.fold {
+			line = foldLine(line)
+		}
+		lines = append(lines, &TaggedData[T]{Tag: tag, Data: line})
+		data = data[i+1:]
+	}
+	if ls.fold {
+		data = foldLine(data)
+	}
+	if len(data) > 0 {
+		ls.partial = append(ls.partial, &TaggedData[T]{Tag: tag, Data: data})
+	}
+	if ls.group != nil {
+		return ls.groupLines(tag, lines)
+	}
+	return lines
+}
+
+// groupLines merges continuation lines of tag into the preceding line, returning the records that are complete, as
+// the following line isn't a continuation, and holding back the last.
+func (ls *lineSplitter[T]) groupLines(tag T, lines []*TaggedData[T]) []*TaggedData[T] {
+	var records []*TaggedData[T]
+	held := take(&ls.held, tag)
+	for _, line := range lines {
+		if held != nil && ls.group.Match(line.Data) {
+			held.Data = append(held.Data, line.Data...)
+			continue
+		}
+		if held != nil {
+			records = append(records, held)
+		}
+		held = line
+	}
+	if held != nil {
+		ls.held = append(ls.held, held)
+	}
+	return records
+}
+
+// flush returns the held back data for tag, the last record when grouping and the partial line.
+func (ls *lineSplitter[T]) flush(tag T) []*TaggedData[T] {
+	var td []*TaggedData[T]
+	if partial := take(&ls.partial, tag); partial != nil {
+		td = append(td, partial)
+	}
+	if ls.group != nil {
+		td = ls.groupLines(tag, td)
+		if held := take(&ls.held, tag); held != nil {
+			td = append(td, held)
+		}
+	}
+	return td
+}
+
+// flushAll returns the held back data of every tag, in the order it was started.
+func (ls *lineSplitter[T]) flushAll() []*TaggedData[T] {
+	var td []*TaggedData[T]
+	for len(ls.held) > 0 {
+		td = append(td, ls.flush(ls.held[0].Tag)...)
+	}
+	for len(ls.partial) > 0 {
+		td = append(td, ls.flush(ls.partial[0].Tag)...)
+	}
+	return td
+}
+
+// take removes and returns the data for tag from held, or nil if there isn't any.
+func take[T comparable](held *[]*TaggedData[T], tag T) *TaggedData[T] {
+	for i, td := range *held {
+		if td.Tag == tag {
+			*held = append((*held)[:i], (*held)[i+1:]...)
+			return td
+		}
+	}
+	return nil
+}
+
+// foldLine returns the last frame of a line rewritten using carriage returns, followed by the line ending. A trailing
+// carriage return is kept as the line ending, as it may be the start of a "\r\n" in a partial line.
+func foldLine(line []byte) []byte {
+	text := bytes.TrimRight(line, "\r\n")
+	i := bytes.LastIndexByte(text, '\r')
+	if i < 0 {
+		return line
+	}
+	return append(text[i+1:len(text):len(text)], line[len(text):]...)
+}
@@ -0,0 +1,78 @@
+package iomux
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineSplitter(t *testing.T) {
+	var ls lineSplitter[string]
+	lines := ls.split("a", []byte("one\ntw"))
+	assert.Equal(t, 1, len(lines))
+	assert.Equal(t, "one\n", string(lines[0].Data))
+
+	assert.Empty(t, ls.split("b", []byte("partial")))
+	lines = ls.split("a", []byte("o\nthree"))
+	assert.Equal(t, 1, len(lines))
+	assert.Equal(t, "two\n", string(lines[0].Data))
+
+	partial := ls.flushAll()
+	assert.Equal(t, 2, len(partial))
+	assert.Equal(t, "b", partial[0].Tag)
+	assert.Equal(t, "partial", string(partial[0].Data))
+	assert.Equal(t, "a", partial[1].Tag)
+	assert.Equal(t, "three", string(partial[1].Data))
+	assert.Nil(t, ls.flush("a"))
+}
+
+func TestLineSplitterFold(t *testing.T) {
+	ls := lineSplitter[string]{fold: true}
+	lines := ls.split("a", []byte("10%\r50%\r"))
+	assert.Empty(t, lines)
+	assert.Equal(t, "50%\r", string(ls.partial[0].Data))
+	lines = ls.split("a", []byte("100%\r\nplain\nx\ry\n\r"))
+	assert.Equal(t, 3, len(lines))
+	assert.Equal(t, "100%\r\n", string(lines[0].Data))
+	assert.Equal(t, "plain\n", string(lines[1].Data))
+	assert.Equal(t, "y\n", string(lines[2].Data))
+	lines = ls.split("a", []byte("a\rb"))
+	assert.Empty(t, lines)
+	partial := ls.flushAll()
+	assert.Equal(t, 1, len(partial))
+	assert.Equal(t, "b", string(partial[0].Data))
+}
+
+func TestFoldLine(t *testing.T) {
+	assert.Equal(t, "c\n", string(foldLine([]byte("a\rb\rc\n"))))
+	assert.Equal(t, "done\r\n", string(foldLine([]byte("1/2\r2/2\rdone\r\n"))))
+	assert.Equal(t, "no frames\n", string(foldLine([]byte("no frames\n"))))
+	assert.Equal(t, "b\r", string(foldLine([]byte("a\rb\r"))))
+	assert.Equal(t, "a\r", string(foldLine([]byte("a\r"))))
+}
+
+func TestLineSplitterGroup(t *testing.T) {
+	ls := lineSplitter[string]{group: StackTraceContinuation}
+	lines := ls.split("err", []byte("Exception in thread \"main\" java.lang.IllegalStateException: boom\n"+
+		"\tat Main.run(Main.java:10)\n"))
+	assert.Empty(t, lines)
+	assert.Empty(t, ls.split("out", []byte("started\n")))
+	lines = ls.split("err", []byte("\tat Main.main(Main.java:5)\nCaused by: java.io.IOException\n\t... 2 more\nnext"))
+	assert.Empty(t, lines)
+	lines = ls.split("err", []byte(" record\nlast\n"))
+	assert.Equal(t, 2, len(lines))
+	assert.Equal(t, "Exception in thread \"main\" java.lang.IllegalStateException: boom\n"+
+		"\tat Main.run(Main.java:10)\n\tat Main.main(Main.java:5)\nCaused by: java.io.IOException\n\t... 2 more\n",
+		string(lines[0].Data))
+	assert.Equal(t, "next record\n", string(lines[1].Data))
+
+	// a partial continuation is merged into the last record when flushed
+	assert.Empty(t, ls.split("err", []byte("  more")))
+	held := ls.flushAll()
+	assert.Equal(t, 2, len(held))
+	assert.Equal(t, "out", held[0].Tag)
+	assert.Equal(t, "started\n", string(held[0].Data))
+	assert.Equal(t, "err", held[1].Tag)
+	assert.Equal(t, "last\n  more", string(held[1].Data))
+	assert.Nil(t, ls.flush("err"))
+}
@@ -0,0 +1,71 @@
+package iomux
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// DecodeLogfmt Decode a line of logfmt, as written by Heroku and Go kit services, for use with EventReader.Format.
+// Values are strings, with bare keys given an empty value. Quoted values are unquoted like Go strings.
+func DecodeLogfmt(line []byte, event *map[string]any) error {
+	m := make(map[string]any)
+	rest := bytes.TrimRight(line, "\r\n")
+	for {
+		rest = bytes.TrimLeft(rest, " \t")
+		if len(rest) == 0 {
+			break
+		}
+		i := bytes.IndexAny(rest, "= \t\"")
+		if i < 0 {
+			i = len(rest)
+		}
+		if i == 0 {
+			return fmt.Errorf("logfmt: unexpected %q, expected a key", rest[0])
+		}
+		key := string(rest[:i])
+		rest = rest[i:]
+		if len(rest) == 0 || rest[0] != '=' {
+			if len(rest) > 0 && rest[0] == '"' {
+				return fmt.Errorf("logfmt: unexpected %q in key %s", rest[0], key)
+			}
+			m[key] = ""
+			continue
+		}
+		rest = rest[1:]
+		if len(rest) > 0 && rest[0] == '"' {
+			end := quotedEnd(rest)
+			if end < 0 {
+				return fmt.Errorf("logfmt: unterminated quoted value of key %s", key)
+			}
+			value, err := strconv.Unquote(string(rest[:end]))
+			if err != nil {
+				return fmt.Errorf("logfmt: invalid quoted value of key %s: %w", key, err)
+			}
+			m[key] = value
+			rest = rest[end:]
+			continue
+		}
+		i = bytes.IndexAny(rest, " \t")
+		if i < 0 {
+			i = len(rest)
+		}
+		m[key] = string(rest[:i])
+		rest = rest[i:]
+	}
+	*event = m
+	return nil
+}
+
+// quotedEnd returns the index after the closing quote of the quoted string starting s, or -1 if it isn't closed.
+func quotedEnd(s []byte) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return -1
+}
@@ -0,0 +1,46 @@
+package iomux
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeLogfmt(t *testing.T) {
+	var event map[string]any
+	err := DecodeLogfmt([]byte(`level=info msg="request \"done\"" path=/ debug status=200`+"\n"), &event)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]any{
+		"level":  "info",
+		"msg":    `request "done"`,
+		"path":   "/",
+		"debug":  "",
+		"status": "200",
+	}, event)
+
+	for _, line := range []string{`=info`, `msg="unterminated`, `msg="bad \q"`, `ke"y=1`} {
+		assert.NotNil(t, DecodeLogfmt([]byte(line), &event), line)
+	}
+}
+
+func TestEventReaderLogfmt(t *testing.T) {
+	mux := NewMemoryMux[string]()
+	api, _ := mux.Tag("api")
+	web, _ := mux.Tag("web")
+	io.WriteString(api, "at=info method=GET\n")
+	io.WriteString(web, `{"at":"warn"}`+"\n")
+
+	r := NewEventReader[string, map[string]any](mux.Read).
+		Format("api", DecodeLogfmt).
+		Format("web", DecodeJSON)
+	e, err := r.Next(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "api", e.Tag)
+	assert.Equal(t, map[string]any{"at": "info", "method": "GET"}, e.Event)
+	e, err = r.Next(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "web", e.Tag)
+	assert.Equal(t, map[string]any{"at": "warn"}, e.Event)
+}
@@ -0,0 +1,153 @@
+package iomux
+
+import (
+	"context"
+	"io"
+	"iter"
+	"sync"
+)
+
+// MemoryMux provides the same read API as Mux without any sockets, files or syscalls, so it works on platforms like
+// js/wasm and in sandboxes. Data is recorded in the exact order it is written across all tags, making reads
+// deterministic. Because there is no file descriptor to hand out, Tag returns an io.WriteCloser rather than an *os.File.
+type MemoryMux[T comparable] struct {
+	mutex   sync.Mutex
+	notify  chan struct{}
+	queue   []*TaggedData[T]
+	writers int
+	closed  bool
+}
+
+type memoryWriter[T comparable] struct {
+	mux    *MemoryMux[T]
+	tag    T
+	closed bool
+}
+
+// NewMemoryMux Create a new MemoryMux.
+func NewMemoryMux[T comparable]() *MemoryMux[T] {
+	return &MemoryMux[T]{notify: make(chan struct{}, 1)}
+}
+
+// Tag Create a writer for data tagged with tag T.
+func (mux *MemoryMux[T]) Tag(tag T) (io.WriteCloser, error) {
+	mux.mutex.Lock()
+	defer mux.mutex.Unlock()
+	if mux.closed {
+		return nil, MuxClosed
+	}
+	mux.writers++
+	return &memoryWriter[T]{mux: mux, tag: tag}, nil
+}
+
+// Read perform a read, blocking until data is available or ctx.Done. Returns io.EOF error when ctx is done and there
+// is no data remaining to be read.
+func (mux *MemoryMux[T]) Read(ctx context.Context) ([]byte, T, error) {
+	var zeroTag T
+	for {
+		mux.mutex.Lock()
+		if mux.closed {
+			mux.mutex.Unlock()
+			return nil, zeroTag, MuxClosed
+		}
+		if mux.writers == 0 {
+			mux.mutex.Unlock()
+			return nil, zeroTag, MuxNoConnections
+		}
+		if len(mux.queue) > 0 {
+			td := mux.queue[0]
+			mux.queue[0] = nil
+			mux.queue = mux.queue[1:]
+			mux.mutex.Unlock()
+			return td.Data, td.Tag, nil
+		}
+		mux.mutex.Unlock()
+
+		select {
+		case <-mux.notify:
+		case <-ctx.Done():
+			mux.mutex.Lock()
+			empty := len(mux.queue) == 0
+			mux.mutex.Unlock()
+			if empty {
+				return nil, zeroTag, io.EOF
+			}
+		}
+	}
+}
+
+// ReadWhile Read until waitFn returns, returning the read data.
+func (mux *MemoryMux[T]) ReadWhile(waitFn func() error) ([]*TaggedData[T], error) {
+	if mux.isClosed() {
+		return nil, MuxClosed
+	}
+	return readWhile(mux.ReadUntil, waitFn)
+}
+
+// ReadUntil Read until ctx is done, returning the read data.
+func (mux *MemoryMux[T]) ReadUntil(ctx context.Context) ([]*TaggedData[T], error) {
+	if mux.isClosed() {
+		return nil, MuxClosed
+	}
+	return readUntil(ctx, mux.Read, collect[T]{merge: true})
+}
+
+// All Read until ctx is done, yielding each chunk and its tag as it arrives, for use with range.
+func (mux *MemoryMux[T]) All(ctx context.Context) iter.Seq2[T, []byte] {
+	return all(ctx, mux.Read)
+}
+
+// Close closes the MemoryMux, discarding unread data. Prevents reuse.
+func (mux *MemoryMux[T]) Close() error {
+	mux.mutex.Lock()
+	defer mux.mutex.Unlock()
+	if mux.closed {
+		return MuxClosed
+	}
+	mux.closed = true
+	mux.queue = nil
+	return nil
+}
+
+func (mux *MemoryMux[T]) isClosed() bool {
+	mux.mutex.Lock()
+	defer mux.mutex.Unlock()
+	return mux.closed
+}
+
+func (mux *MemoryMux[T]) write(tag T, p []byte) error {
+	mux.mutex.Lock()
+	defer mux.mutex.Unlock()
+	if mux.closed {
+		return MuxClosed
+	}
+	data := make([]byte, len(p))
+	copy(data, p)
+	mux.queue = append(mux.queue, &TaggedData[T]{Tag: tag, Data: data})
+	select {
+	case mux.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (w *memoryWriter[T]) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, io.ErrClosedPipe
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if err := w.mux.write(w.tag, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *memoryWriter[T]) Close() error {
+	if w.closed {
+		return io.ErrClosedPipe
+	}
+	w.closed = true
+	return nil
+}
@@ -0,0 +1,121 @@
+package iomux
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryMuxRead(t *testing.T) {
+	mux := NewMemoryMux[string]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	taga, err := mux.Tag("a")
+	assert.Nil(t, err)
+	tagb, err := mux.Tag("b")
+	assert.Nil(t, err)
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	io.WriteString(taga, "hello taga")
+	io.WriteString(tagb, "hello tagb")
+	bytes, tag, err := mux.Read(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "a", tag)
+	assert.Equal(t, "hello taga", string(bytes))
+
+	bytes, tag, err = mux.Read(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "b", tag)
+	assert.Equal(t, "hello tagb", string(bytes))
+
+	cancelFn()
+	_, _, err = mux.Read(ctx)
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestMemoryMuxReadNoSenders(t *testing.T) {
+	mux := NewMemoryMux[string]()
+	_, _, err := mux.Read(context.Background())
+	assert.ErrorIs(t, err, MuxNoConnections)
+}
+
+func TestMemoryMuxClosed(t *testing.T) {
+	mux := NewMemoryMux[string]()
+	w, err := mux.Tag("a")
+	assert.Nil(t, err)
+	assert.Nil(t, mux.Close())
+	assert.ErrorIs(t, mux.Close(), MuxClosed)
+	_, err = mux.Tag("a")
+	assert.ErrorIs(t, err, MuxClosed)
+	_, err = io.WriteString(w, "data")
+	assert.ErrorIs(t, err, MuxClosed)
+	_, _, err = mux.Read(context.Background())
+	assert.ErrorIs(t, err, MuxClosed)
+}
+
+func TestMemoryMuxReadWhile(t *testing.T) {
+	mux := NewMemoryMux[string]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	taga, _ := mux.Tag("a")
+	tagb, _ := mux.Tag("b")
+	tagc, _ := mux.Tag("c")
+
+	td, err := mux.ReadWhile(func() error {
+		io.WriteString(taga, "out1")
+		io.WriteString(tagb, "err1")
+		io.WriteString(tagb, "err2")
+		io.WriteString(tagc, "other")
+		return nil
+	})
+	assert.Nil(t, err)
+
+	assert.Equal(t, 3, len(td))
+	assert.Equal(t, "a", td[0].Tag)
+	assert.Equal(t, "out1", string(td[0].Data))
+	assert.Equal(t, "b", td[1].Tag)
+	assert.Equal(t, "err1err2", string(td[1].Data))
+	assert.Equal(t, "c", td[2].Tag)
+	assert.Equal(t, "other", string(td[2].Data))
+	for i, d := range td {
+		assert.Equal(t, uint64(i+1), d.Seq)
+		assert.False(t, d.Time.IsZero())
+	}
+}
+
+func TestMemoryMuxWriterClose(t *testing.T) {
+	mux := NewMemoryMux[string]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	w, _ := mux.Tag("a")
+	assert.Nil(t, w.Close())
+	_, err := io.WriteString(w, "data")
+	assert.ErrorIs(t, err, io.ErrClosedPipe)
+}
+
+func TestMemoryMuxAll(t *testing.T) {
+	mux := NewMemoryMux[string]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	taga, _ := mux.Tag("a")
+	tagb, _ := mux.Tag("b")
+	io.WriteString(taga, "out1")
+	io.WriteString(tagb, "err1")
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	cancelFn()
+	var tags []string
+	var data []string
+	for tag, b := range mux.All(ctx) {
+		tags = append(tags, tag)
+		data = append(data, string(b))
+	}
+	assert.Equal(t, []string{"a", "b"}, tags)
+	assert.Equal(t, []string{"out1", "err1"}, data)
+}
@@ -0,0 +1,23 @@
+package iomux
+
+import "time"
+
+// Metrics receives instrumentation events from a Mux, to be exported to a monitoring system such as Prometheus.
+// Implementations are called synchronously from Tag and the read methods, so should not block.
+type Metrics[T comparable] interface {
+	// Connected is called when a writer for tag is created by Tag.
+	Connected(tag T)
+	// Disconnected is called when a writer for tag is closed. Only connection oriented networks detect this.
+	Disconnected(tag T)
+	// Received is called for each chunk of n bytes read for tag, with how long the read waited for it.
+	Received(tag T, n int, latency time.Duration)
+	// Dropped is called when n bytes read for tag are discarded, see BufferDropOldest.
+	Dropped(tag T, n int)
+}
+
+// WithMetrics Report instrumentation events to m.
+func WithMetrics[T comparable](m Metrics[T]) Option[T] {
+	return func(mux *Mux[T]) {
+		mux.metrics = m
+	}
+}
@@ -0,0 +1,107 @@
+//go:build !windows
+
+package iomux
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testMetrics struct {
+	mutex        sync.Mutex
+	connected    map[string]int
+	disconnected map[string]int
+	bytes        map[string]int
+	chunks       map[string]int
+	dropped      map[string]int
+}
+
+func newTestMetrics() *testMetrics {
+	return &testMetrics{
+		connected:    make(map[string]int),
+		disconnected: make(map[string]int),
+		bytes:        make(map[string]int),
+		chunks:       make(map[string]int),
+		dropped:      make(map[string]int),
+	}
+}
+
+func (m *testMetrics) Connected(tag string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.connected[tag]++
+}
+
+func (m *testMetrics) Disconnected(tag string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.disconnected[tag]++
+}
+
+func (m *testMetrics) Received(tag string, n int, latency time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.bytes[tag] += n
+	m.chunks[tag]++
+}
+
+func (m *testMetrics) Dropped(tag string, n int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.dropped[tag] += n
+}
+
+func TestMuxMetrics(t *testing.T) {
+	metrics := newTestMetrics()
+	mux := NewMuxUnix[string](WithMetrics[string](metrics))
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	taga, err := mux.Tag("a")
+	assert.Nil(t, err)
+	tagb, _ := mux.Tag("b")
+	assert.Equal(t, map[string]int{"a": 1, "b": 1}, metrics.connected)
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+	io.WriteString(taga, "hello")
+	_, _, err = mux.Read(ctx)
+	assert.Nil(t, err)
+	io.WriteString(tagb, "hi")
+	_, _, err = mux.Read(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int{"a": 5, "b": 2}, metrics.bytes)
+	assert.Equal(t, map[string]int{"a": 1, "b": 1}, metrics.chunks)
+
+	taga.Close()
+	tagb.Close()
+	_, _, err = mux.Read(ctx)
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, map[string]int{"a": 1, "b": 1}, metrics.disconnected)
+}
+
+func TestMuxMetricsDropped(t *testing.T) {
+	metrics := newTestMetrics()
+	mux := NewMux[string](WithMetrics[string](metrics), WithMaxBufferedBytes[string](4, BufferDropOldest))
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	taga, err := mux.Tag("a")
+	assert.Nil(t, err)
+	tagb, _ := mux.Tag("b")
+
+	td, err := mux.ReadWhile(func() error {
+		io.WriteString(taga, "aaa")
+		time.Sleep(sleepDuration)
+		io.WriteString(tagb, "bbb")
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(td))
+	assert.Equal(t, map[string]int{"a": 2}, metrics.dropped)
+}
@@ -0,0 +1,25 @@
+package iomux
+
+import (
+	"context"
+	"os"
+)
+
+// Muxer is the API shared by Mux and the other muxes handing out files, for code that accepts a mux to depend on
+// rather than Mux itself, so it can be given a PipeMux, or a fake from the muxtest package, in tests.
+type Muxer[T comparable] interface {
+	// Tag Create a file to receive data tagged with tag T.
+	Tag(tag T) (*os.File, error)
+	// Read perform a read, blocking until data is available or ctx.Done.
+	Read(ctx context.Context) ([]byte, T, error)
+	// ReadWhile Read until waitFn returns, returning the read data.
+	ReadWhile(waitFn func() error) ([]*TaggedData[T], error)
+	// Close closes the mux. Prevents reuse.
+	Close() error
+}
+
+var (
+	_ Muxer[string] = (*Mux[string])(nil)
+	_ Muxer[string] = (*FIFOMux[string])(nil)
+	_ Muxer[string] = (*PipeMux[string])(nil)
+)
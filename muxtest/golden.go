@@ -0,0 +1,65 @@
+package muxtest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/netflix/go-iomux"
+)
+
+// UpdateGoldenEnv is the environment variable that makes AssertGolden write golden files rather than compare with them,
+// when set to a non-empty value, such as with IOMUX_UPDATE_GOLDEN=1 go test. A flag isn't used, as it would be
+// registered by every test binary importing the package.
+const UpdateGoldenEnv = "IOMUX_UPDATE_GOLDEN"
+
+// AssertGolden Assert that td matches the golden file at path, or write it when UpdateGoldenEnv is set. Each chunk is
+// written as a header naming its tag, followed by its data, so golden files are easy to review. Seq, Time, ConnID and
+// Cred vary between runs, so are left out. Mismatches are reported with t.Errorf, and returns whether td matched.
+func AssertGolden[T comparable](t testing.TB, td []*iomux.TaggedData[T], path string) bool {
+	t.Helper()
+	got := Golden(td)
+	if os.Getenv(UpdateGoldenEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return true
+	}
+	want, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("golden file %s does not exist, set %s=1 to create it", path, UpdateGoldenEnv)
+		return false
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(want, got) {
+		t.Errorf("golden file %s differs, set %s=1 to update it\n--- want\n%s\n--- got\n%s", path, UpdateGoldenEnv, want, got)
+		return false
+	}
+	return true
+}
+
+// Golden Serialize td as written by AssertGolden.
+func Golden[T comparable](td []*iomux.TaggedData[T]) []byte {
+	var buf bytes.Buffer
+	for _, d := range td {
+		fmt.Fprintf(&buf, "--- %v", d.Tag)
+		if d.Truncated > 0 {
+			fmt.Fprintf(&buf, " (truncated %d bytes)", d.Truncated)
+		}
+		buf.WriteByte('\n')
+		buf.Write(d.Data)
+		if len(d.Data) > 0 && d.Data[len(d.Data)-1] != '\n' {
+			buf.WriteString("\n\\ no newline\n")
+		}
+	}
+	return buf.Bytes()
+}
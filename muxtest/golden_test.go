@@ -0,0 +1,54 @@
+package muxtest
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/netflix/go-iomux"
+	"github.com/stretchr/testify/assert"
+)
+
+var goldenData = []*iomux.TaggedData[iomux.StdStream]{
+	{Tag: iomux.Stdout, Data: []byte("server starting\n"), Seq: 1, Time: time.Now()},
+	{Tag: iomux.Stderr, Data: []byte("warning: no config"), Seq: 2, Time: time.Now(), ConnID: 2},
+	{Tag: iomux.Stdout, Data: []byte("listening on :8080\n"), Seq: 3, Time: time.Now()},
+}
+
+func TestAssertGolden(t *testing.T) {
+	AssertGolden(t, goldenData, "testdata/case.golden")
+}
+
+func TestAssertGoldenUpdate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "testdata", "case.golden")
+	t.Setenv(UpdateGoldenEnv, "1")
+	assert.True(t, AssertGolden(t, goldenData, path))
+	t.Setenv(UpdateGoldenEnv, "")
+	assert.True(t, AssertGolden(t, goldenData, path))
+}
+
+// failT records failures rather than failing the test.
+type failT struct {
+	testing.TB
+	failed bool
+}
+
+func (t *failT) Helper() {}
+
+func (t *failT) Errorf(format string, args ...any) {
+	t.failed = true
+}
+
+func TestAssertGoldenMismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "case.golden")
+	mock := &failT{TB: t}
+	assert.False(t, AssertGolden(mock, goldenData, path))
+	assert.True(t, mock.failed)
+
+	t.Setenv(UpdateGoldenEnv, "1")
+	AssertGolden(t, goldenData[:1], path)
+	t.Setenv(UpdateGoldenEnv, "")
+	mock.failed = false
+	assert.False(t, AssertGolden(mock, goldenData, path))
+	assert.True(t, mock.failed)
+}
@@ -0,0 +1,182 @@
+// Package muxtest provides a scriptable fake of iomux.Muxer, for unit testing code that reads from a mux. Tests script
+// the tagged chunks to be read, in the exact order they should arrive, and inspect what the code under test wrote and
+// did, without sockets, timing, or goroutines.
+package muxtest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/netflix/go-iomux"
+)
+
+// Fake is a scriptable iomux.Muxer. Read returns the scripted chunks in order, then io.EOF, and ReadWhile returns every
+// remaining chunk once its waitFn returns. Files returned by Tag are temporary files, so what the code under test
+// writes to them can be checked with Written.
+type Fake[T comparable] struct {
+	mutex   sync.Mutex
+	script  []step[T]
+	tagErrs map[T]error
+	tags    []T
+	files   map[T][]*os.File
+	closed  bool
+}
+
+// step is a scripted chunk, or an error when err isn't nil.
+type step[T comparable] struct {
+	td  iomux.TaggedData[T]
+	err error
+}
+
+var _ iomux.Muxer[string] = (*Fake[string])(nil)
+
+// New Create a new Fake with nothing scripted.
+func New[T comparable]() *Fake[T] {
+	return &Fake[T]{tagErrs: make(map[T]error), files: make(map[T][]*os.File)}
+}
+
+// Push Script data tagged with tag to be read after everything already scripted.
+func (f *Fake[T]) Push(tag T, data string) *Fake[T] {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.script = append(f.script, step[T]{td: iomux.TaggedData[T]{Tag: tag, Data: []byte(data)}})
+	return f
+}
+
+// PushErr Script err to be returned by the read after everything already scripted.
+func (f *Fake[T]) PushErr(err error) *Fake[T] {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.script = append(f.script, step[T]{err: err})
+	return f
+}
+
+// FailTag Make Tag return err for tag.
+func (f *Fake[T]) FailTag(tag T, err error) *Fake[T] {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.tagErrs[tag] = err
+	return f
+}
+
+// Tag Create a temporary file for tag, recording the tag. Returns the error set by FailTag for tag, if any.
+func (f *Fake[T]) Tag(tag T) (*os.File, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.closed {
+		return nil, iomux.MuxClosed
+	}
+	if err := f.tagErrs[tag]; err != nil {
+		return nil, err
+	}
+	file, err := os.CreateTemp("", "muxtest")
+	if err != nil {
+		return nil, err
+	}
+	// keep a descriptor of our own, as the code under test is expected to close the file
+	r, err := os.Open(file.Name())
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, err
+	}
+	f.tags = append(f.tags, tag)
+	f.files[tag] = append(f.files[tag], r)
+	return file, nil
+}
+
+// Read Return the next scripted chunk or error, or io.EOF once the script is done. Never blocks.
+func (f *Fake[T]) Read(ctx context.Context) ([]byte, T, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	var zeroTag T
+	if f.closed {
+		return nil, zeroTag, iomux.MuxClosed
+	}
+	if len(f.script) == 0 {
+		return nil, zeroTag, io.EOF
+	}
+	s := f.script[0]
+	f.script = f.script[1:]
+	if s.err != nil {
+		return nil, zeroTag, s.err
+	}
+	return s.td.Data, s.td.Tag, nil
+}
+
+// ReadWhile Call waitFn, then return the scripted chunks up to the first scripted error, each as a separate
+// TaggedData. The error is that of waitFn joined with the scripted error, if any.
+func (f *Fake[T]) ReadWhile(waitFn func() error) ([]*iomux.TaggedData[T], error) {
+	waitErr := waitFn()
+	var result []*iomux.TaggedData[T]
+	for {
+		data, tag, err := f.Read(context.Background())
+		if err == io.EOF {
+			return result, waitErr
+		}
+		if err != nil {
+			return result, errors.Join(waitErr, err)
+		}
+		result = append(result, &iomux.TaggedData[T]{Tag: tag, Data: data})
+	}
+}
+
+// Close Mark the Fake closed, so Tag and Read return iomux.MuxClosed, and remove the files created by Tag. Written
+// can't be used once closed.
+func (f *Fake[T]) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.closed {
+		return iomux.MuxClosed
+	}
+	f.closed = true
+	for _, files := range f.files {
+		for _, r := range files {
+			r.Close()
+			os.Remove(r.Name())
+		}
+	}
+	return nil
+}
+
+// Closed Whether Close has been called.
+func (f *Fake[T]) Closed() bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.closed
+}
+
+// Tags The tags passed to Tag, in the order they were requested, including duplicates.
+func (f *Fake[T]) Tags() []T {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]T(nil), f.tags...)
+}
+
+// Remaining The number of scripted chunks and errors not yet read.
+func (f *Fake[T]) Remaining() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return len(f.script)
+}
+
+// Written What has been written to the files created for tag so far, in the order they were created.
+func (f *Fake[T]) Written(tag T) (string, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.closed {
+		return "", iomux.MuxClosed
+	}
+	var written []byte
+	for _, r := range f.files[tag] {
+		b, err := io.ReadAll(io.NewSectionReader(r, 0, 1<<62))
+		if err != nil {
+			return "", err
+		}
+		written = append(written, b...)
+	}
+	return string(written), nil
+}
@@ -0,0 +1,89 @@
+package muxtest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/netflix/go-iomux"
+	"github.com/stretchr/testify/assert"
+)
+
+// prefixLines is an example of code under test, writing a line to a file tagged "in" and returning what was read with
+// each chunk prefixed by its tag.
+func prefixLines(mux iomux.Muxer[string]) (string, error) {
+	in, err := mux.Tag("in")
+	if err != nil {
+		return "", err
+	}
+	td, err := mux.ReadWhile(func() error {
+		defer in.Close()
+		_, err := io.WriteString(in, "go\n")
+		return err
+	})
+	var sb strings.Builder
+	for _, d := range td {
+		sb.WriteString(d.Tag + ": " + string(d.Data))
+	}
+	return sb.String(), err
+}
+
+func TestFake(t *testing.T) {
+	mux := New[string]().
+		Push("out", "one\n").
+		Push("err", "two\n").
+		Push("out", "three\n")
+	got, err := prefixLines(mux)
+	assert.Nil(t, err)
+	assert.Equal(t, "out: one\nerr: two\nout: three\n", got)
+	assert.Equal(t, []string{"in"}, mux.Tags())
+	written, err := mux.Written("in")
+	assert.Nil(t, err)
+	assert.Equal(t, "go\n", written)
+	assert.Equal(t, 0, mux.Remaining())
+
+	assert.False(t, mux.Closed())
+	assert.Nil(t, mux.Close())
+	assert.True(t, mux.Closed())
+	assert.ErrorIs(t, mux.Close(), iomux.MuxClosed)
+}
+
+func TestFakeRead(t *testing.T) {
+	boom := errors.New("boom")
+	mux := New[int]().Push(1, "a").PushErr(boom).Push(2, "b")
+	ctx := context.Background()
+
+	data, tag, err := mux.Read(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, tag)
+	assert.Equal(t, "a", string(data))
+	_, _, err = mux.Read(ctx)
+	assert.ErrorIs(t, err, boom)
+	data, tag, err = mux.Read(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, tag)
+	assert.Equal(t, "b", string(data))
+	_, _, err = mux.Read(ctx)
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestFakeErrors(t *testing.T) {
+	boom := errors.New("boom")
+	mux := New[string]().FailTag("in", boom)
+	_, err := prefixLines(mux)
+	assert.ErrorIs(t, err, boom)
+
+	mux = New[string]().Push("out", "partial\n").PushErr(boom).Push("out", "unread\n")
+	got, err := prefixLines(mux)
+	assert.ErrorIs(t, err, boom)
+	assert.Equal(t, "out: partial\n", got)
+	assert.Equal(t, 1, mux.Remaining())
+
+	assert.Nil(t, mux.Close())
+	_, err = mux.Tag("in")
+	assert.ErrorIs(t, err, iomux.MuxClosed)
+	_, _, err = mux.Read(context.Background())
+	assert.ErrorIs(t, err, iomux.MuxClosed)
+}
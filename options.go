@@ -0,0 +1,290 @@
+package iomux
+
+import (
+	"io"
+	"log/slog"
+	"regexp"
+	"time"
+)
+
+// Option configures a Mux when passed to one of the constructors.
+type Option[T comparable] func(*Mux[T])
+
+// WithLineSplitting Split received data into lines, so Read returns, and ReadWhile and ReadUntil collect, one complete
+// line for a tag at a time, including the trailing newline. Partial lines are buffered per tag until completed, and
+// returned as they are once there is no more data to read.
+func WithLineSplitting[T comparable]() Option[T] {
+	return func(mux *Mux[T]) {
+		mux.lines = true
+	}
+}
+
+// WithCarriageReturnFolding Split received data into lines like WithLineSplitting, keeping only the text after the
+// last carriage return of each line, so the frames of progress bars and spinners that redraw a line are collapsed into
+// the final one.
+func WithCarriageReturnFolding[T comparable]() Option[T] {
+	return func(mux *Mux[T]) {
+		mux.lines = true
+		mux.splitter.fold = true
+	}
+}
+
+// WithLineGrouping Split received data into lines like WithLineSplitting, merging lines matched by continuation into
+// the preceding line of the same tag, so multi-line records such as stack traces are read as one. Uses
+// StackTraceContinuation when continuation is nil. As a record is only complete once the following line starts, the
+// last record of a tag is held back until then, the tag is closed, or there is no more data to read.
+func WithLineGrouping[T comparable](continuation *regexp.Regexp) Option[T] {
+	return func(mux *Mux[T]) {
+		if continuation == nil {
+			continuation = StackTraceContinuation
+		}
+		mux.lines = true
+		mux.splitter.group = continuation
+	}
+}
+
+// WithIdleTimeout Fail Read with os.ErrDeadlineExceeded when no writer produces data for d, for detecting hung writers.
+// Zero or negative values disable the timeout.
+func WithIdleTimeout[T comparable](d time.Duration) Option[T] {
+	return func(mux *Mux[T]) {
+		mux.idleTimeout = d
+	}
+}
+
+// WithCloseHandler Call fn from Read once every writer returned by Tag for a tag has been closed, and all of their
+// data received. Only connection oriented networks can detect writers being closed, so fn is never called for
+// 'unixgram'. Note that writers passed to exec.Cmd are only closed once both the parent and child have closed them.
+func WithCloseHandler[T comparable](fn func(tag T)) Option[T] {
+	return func(mux *Mux[T]) {
+		mux.onClose = fn
+	}
+}
+
+// WithTee Copy data for tag to w as soon as it is read, as well as capturing it, for example to show output live on
+// the terminal and have the ordered capture afterwards. With WithLineSplitting each line is copied once it is
+// complete. Errors writing to w are ignored so they can't interrupt capturing, and the option can be given once per
+// tag.
+func WithTee[T comparable](tag T, w io.Writer) Option[T] {
+	return func(mux *Mux[T]) {
+		if mux.tees == nil {
+			mux.tees = make(map[T]io.Writer)
+		}
+		mux.tees[tag] = w
+	}
+}
+
+// WithTransform Replace data received for a tag with the result of fn before it is teed, buffered or returned by Read,
+// for example to mask secrets with Redact. fn is called from Read with each chunk as it is received, or with
+// WithLineSplitting each complete line, and data it returns empty is discarded. The option can be given more than
+// once, applying each fn in order.
+func WithTransform[T comparable](fn func(tag T, data []byte) []byte) Option[T] {
+	return func(mux *Mux[T]) {
+		mux.transforms = append(mux.transforms, fn)
+	}
+}
+
+// WithSink Write data to sink as it is read, after any WithTransform and a line at a time with WithLineSplitting, from
+// a goroutine of its own so a slow sink doesn't hold up reading until its queue is full. The sink stops being written
+// to after it returns an error, and is closed by Close, which returns the error. The option can be given more than
+// once.
+func WithSink[T comparable](sink Sink[T]) Option[T] {
+	return func(mux *Mux[T]) {
+		mux.sinks = append(mux.sinks, newSinkQueue(sink))
+	}
+}
+
+// WithErrorHandler Keep reading when reading from the connection of a writer fails, for example with ECONNRESET when
+// a child process is killed, rather than failing the read. fn is called from Read with the tag and error, and the
+// writer is treated as closed, so the output of other writers isn't lost to one failing.
+func WithErrorHandler[T comparable](fn func(tag T, err error)) Option[T] {
+	return func(mux *Mux[T]) {
+		mux.onError = fn
+	}
+}
+
+// WithPooledBuffers Read data into buffers from a pool, which can be returned for reuse with Release once they are no
+// longer needed, to reduce allocations for high throughput captures. WithLineSplitting copies each line into a buffer
+// of its own, so lines can be released independently.
+func WithPooledBuffers[T comparable]() Option[T] {
+	return func(mux *Mux[T]) {
+		mux.pooled = true
+	}
+}
+
+// WithSocketpair Connect each writer returned by Tag with a socket pair, rather than through a listening socket in a
+// temporary directory, so nothing is created on the filesystem, which suits the common case of a single process and
+// works on read-only filesystems. As every writer has its own socket, 'unixgram' loses its ordering guarantee across
+// tags, see Limitations in the README. Not supported on Windows.
+func WithSocketpair[T comparable]() Option[T] {
+	return func(mux *Mux[T]) {
+		mux.socketpair = true
+	}
+}
+
+// WithAbstractNamespace Name sockets in the Linux abstract namespace, so there are no filesystem entries to clean up,
+// even if the process crashes. Other platforms fall back to a temporary directory.
+func WithAbstractNamespace[T comparable]() Option[T] {
+	return func(mux *Mux[T]) {
+		mux.abstract = true
+	}
+}
+
+// WithSocketDir Create the temporary directory for sockets in dir, rather than the default directory for temporary
+// files, for when that is unsuitable, for example too small, shared, or with a path too long for a socket address.
+func WithSocketDir[T comparable](dir string) Option[T] {
+	return func(mux *Mux[T]) {
+		mux.sockDir = dir
+	}
+}
+
+// WithStaleSocketCleanup Remove socket directories left behind by processes that exited without closing their Mux,
+// when creating the socket directory. Only directories recording a process that no longer exists are removed, so don't
+// use this where the directory is shared with processes in another pid namespace.
+func WithStaleSocketCleanup[T comparable]() Option[T] {
+	return func(mux *Mux[T]) {
+		mux.reap = true
+	}
+}
+
+// WithStrictOrdering Guarantee data is read in the order it was written across all tags, see Ordered, so Tag fails
+// with MuxUnordered if the network or other options can't keep that order. The network defaults to 'unixgram' on
+// every platform, so on macOS writes are limited to 2048 bytes.
+func WithStrictOrdering[T comparable]() Option[T] {
+	return func(mux *Mux[T]) {
+		mux.strict = true
+	}
+}
+
+// WithFragmentation Split writes too large for a single message on the 'unixgram' and 'unixpacket' networks into
+// fragments, which are reassembled by Read, so writes of any size work. Tag then returns the write end of a pipe
+// that is copied to the socket in the background, so as with 'unix', Read returns data in chunks that need not match
+// the writes made, and Drain should be used rather than ReadWhile to avoid missing data still being copied once the
+// writer finishes. Has no effect on the 'unix' network, which has no message size limit.
+func WithFragmentation[T comparable]() Option[T] {
+	return func(mux *Mux[T]) {
+		mux.fragment = true
+	}
+}
+
+// WithRingBuffer Keep only the last n bytes for each tag in the data collected by ReadUntil, ReadWhile and Drain,
+// discarding older data for the tag as more arrives, for when only the tail of each stream is needed, such as for
+// failure reports. Discarded bytes are reported to Metrics.Dropped.
+func WithRingBuffer[T comparable](n int) Option[T] {
+	return func(mux *Mux[T]) {
+		mux.ringBytes = n
+	}
+}
+
+// WithHeadTail Keep only the first head and last tail bytes for each tag in the data collected by ReadUntil, ReadWhile
+// and Drain, replacing the data in between with a marker that has TaggedData.Truncated set, so very long output keeps
+// both how it started and how it ended. Discarded bytes are reported to Metrics.Dropped.
+func WithHeadTail[T comparable](head, tail int) Option[T] {
+	return func(mux *Mux[T]) {
+		mux.headBytes = head
+		mux.tailBytes = tail
+	}
+}
+
+// BufferPolicy decides what happens when ReadUntil or ReadWhile accumulate more data than allowed by
+// WithMaxBufferedBytes.
+type BufferPolicy int
+
+const (
+	// BufferDropOldest discards the oldest data, keeping the most recent bytes. This is the default, as reading carries
+	// on however much is written.
+	BufferDropOldest BufferPolicy = iota
+	// BufferBlock stops reading once the limit is reached, returning exactly the limit and leaving the rest of the data
+	// to later reads. Writers are blocked once the socket buffers fill, so it is only for ReadUntil and Drain, and
+	// ReadWhile fails with MuxBufferBlocks, as would Run, WrapCmd and Group.
+	BufferBlock
+	// BufferError fails the read with MuxBufferFull.
+	BufferError
+	// BufferDropOldestMarker discards the oldest data like BufferDropOldest, putting a marker per tag in front of the
+	// data that was kept, with Truncated counting the bytes discarded for the tag and Data describing it.
+	BufferDropOldestMarker
+)
+
+// WithMaxBufferedBytes Limit the data ReadUntil and ReadWhile accumulate to n bytes across every tag, applying policy
+// when exceeded, so long-running noisy writers cannot exhaust memory. The bytes held and dropped are reported by Stats.
+func WithMaxBufferedBytes[T comparable](n int, policy BufferPolicy) Option[T] {
+	return func(mux *Mux[T]) {
+		mux.maxBuffered = n
+		mux.bufferPolicy = policy
+	}
+}
+
+// WithSpill Bound the memory ReadUntil and ReadWhile use to accumulate data, by writing the oldest chunks to a
+// temporary file in dir, or the default directory for temporary files if empty, once more than threshold bytes are
+// held in memory. Spilled chunks are returned with Data nil, to be read back one at a time with TaggedData.Load, so
+// memory stays bounded while the capture is used as long as loaded chunks are released once done with. The file is
+// removed once every spilled chunk has been loaded or released. Has no effect with WithMaxBufferedBytes,
+// WithRingBuffer or WithHeadTail, which already bound the data kept.
+func WithSpill[T comparable](threshold int, dir string) Option[T] {
+	return func(mux *Mux[T]) {
+		mux.spillThreshold = threshold
+		mux.spillDir = dir
+	}
+}
+
+// DuplicateTagPolicy decides what happens when Tag is called with a tag that already has a writer, see
+// WithDuplicateTagPolicy.
+type DuplicateTagPolicy int
+
+const (
+	// DuplicateTagNew creates another writer for the tag, so the tag has multiple writers, and is only reported to
+	// WithCloseHandler once every one of them has been closed.
+	DuplicateTagNew DuplicateTagPolicy = iota
+	// DuplicateTagExisting returns the writer created by the first call to Tag, until the tag is removed by Untag. The
+	// writer is shared, so should only be closed once none of the callers need it.
+	DuplicateTagExisting
+	// DuplicateTagError fails Tag with MuxDuplicateTag.
+	DuplicateTagError
+)
+
+// WithDuplicateTagPolicy Apply policy when Tag is called with a tag that already has a writer. Defaults to
+// DuplicateTagNew.
+func WithDuplicateTagPolicy[T comparable](policy DuplicateTagPolicy) Option[T] {
+	return func(mux *Mux[T]) {
+		mux.duplicatePolicy = policy
+	}
+}
+
+// WithReadBatch Receive up to n datagrams or packets per syscall with recvmmsg, for the 'unixgram' and 'unixpacket'
+// networks on Linux, so frequent small writes such as one per log line don't each cost a syscall to read. Each batch
+// buffers n of the largest messages. Defaults to 8, and 1 disables batching.
+func WithReadBatch[T comparable](n int) Option[T] {
+	return func(mux *Mux[T]) {
+		mux.readBatch = n
+	}
+}
+
+// WithCredentials Receive the credentials of the process that wrote each chunk with SO_PASSCRED, reported by LastCred
+// and the Cred of TaggedData, so output of several processes sharing a tag can be attributed to each of them. Chunks
+// written by different processes are not merged. Disables WithReadBatch. Only supported on Linux, elsewhere Cred is
+// always nil.
+func WithCredentials[T comparable]() Option[T] {
+	return func(mux *Mux[T]) {
+		mux.creds = true
+	}
+}
+
+// WithClassifier Assign a level to each chunk with classify, such as one created by Classify, set as the Level of
+// TaggedData and returned by LastLevel. With line splitting each line is classified separately.
+func WithClassifier[T comparable](classify func(tag T, data []byte) slog.Level) Option[T] {
+	return func(mux *Mux[T]) {
+		mux.classify = classify
+	}
+}
+
+// WithMinLevel Discard chunks classified below level rather than returning them from Read, or passing them to
+// subscribers, classifying with Classify unless WithClassifier is used. Sinks, tees and watchers still see every chunk,
+// so everything can be persisted while only errors are acted on.
+func WithMinLevel[T comparable](level slog.Level) Option[T] {
+	return func(mux *Mux[T]) {
+		if mux.classify == nil {
+			mux.classify = Classify[T]()
+		}
+		mux.minLevel = level
+		mux.filterLevel = true
+	}
+}
@@ -0,0 +1,397 @@
+//go:build !windows
+
+package iomux
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMuxLineSplitting(t *testing.T) {
+	for _, network := range networks {
+		t.Run(network, func(t *testing.T) {
+			mux := newMux(network, []Option[string]{WithLineSplitting[string]()})
+			t.Cleanup(func() {
+				mux.Close()
+			})
+			taga, err := mux.Tag("a")
+			if err != nil {
+				skipIfProtocolNotSupported(t, err)
+				assert.Nil(t, err)
+			}
+			tagb, _ := mux.Tag("b")
+
+			td, err := mux.ReadWhile(func() error {
+				io.WriteString(taga, "line1\nline2\nli")
+				time.Sleep(sleepDuration)
+				io.WriteString(tagb, "other\n")
+				time.Sleep(sleepDuration)
+				io.WriteString(taga, "ne3\n")
+				time.Sleep(sleepDuration)
+				io.WriteString(taga, "tail")
+				return nil
+			})
+			assert.Nil(t, err)
+
+			var lines []string
+			for _, d := range td {
+				lines = append(lines, d.Tag+":"+string(d.Data))
+			}
+			assert.Equal(t, []string{"a:line1\n", "a:line2\n", "b:other\n", "a:line3\n", "a:tail"}, lines)
+		})
+	}
+}
+
+func TestMuxLineSplittingRead(t *testing.T) {
+	mux := NewMux[string](WithLineSplitting[string]())
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	taga, err := mux.Tag("a")
+	assert.Nil(t, err)
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	io.WriteString(taga, "one\ntwo\nthr")
+	data, tag, err := mux.Read(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "a", tag)
+	assert.Equal(t, "one\n", string(data))
+	data, _, err = mux.Read(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "two\n", string(data))
+
+	cancelFn()
+	data, _, err = mux.Read(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "thr", string(data))
+	_, _, err = mux.Read(ctx)
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestMuxCarriageReturnFolding(t *testing.T) {
+	mux := NewMux[string](WithCarriageReturnFolding[string]())
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	taga, _ := mux.Tag("a")
+	tagb, _ := mux.Tag("b")
+
+	td, err := mux.ReadWhile(func() error {
+		io.WriteString(taga, "pulling\n")
+		for i := 0; i <= 100; i += 10 {
+			fmt.Fprintf(taga, "\r%3d%%", i)
+		}
+		io.WriteString(tagb, "other\n")
+		io.WriteString(taga, "\ndone\n")
+		return nil
+	})
+	assert.Nil(t, err)
+	var lines []string
+	for _, d := range td {
+		lines = append(lines, d.Tag+":"+string(d.Data))
+	}
+	assert.Equal(t, []string{"a:pulling\n", "b:other\n", "a:100%\n", "a:done\n"}, lines)
+}
+
+func TestMuxIdleTimeout(t *testing.T) {
+	for _, network := range networks {
+		t.Run(network, func(t *testing.T) {
+			mux := newMux(network, []Option[string]{WithIdleTimeout[string](50 * time.Millisecond)})
+			t.Cleanup(func() {
+				mux.Close()
+			})
+			taga, err := mux.Tag("a")
+			if err != nil {
+				skipIfProtocolNotSupported(t, err)
+				assert.Nil(t, err)
+			}
+			_, err = mux.Tag("b")
+			assert.Nil(t, err)
+
+			io.WriteString(taga, "hello taga")
+			data, tag, err := mux.Read(context.Background())
+			assert.Nil(t, err)
+			assert.Equal(t, "a", tag)
+			assert.Equal(t, "hello taga", string(data))
+
+			start := time.Now()
+			_, _, err = mux.Read(context.Background())
+			assert.ErrorIs(t, err, os.ErrDeadlineExceeded)
+			assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+		})
+	}
+}
+
+func TestMuxMaxBufferedBytes(t *testing.T) {
+	write := func(mux *Mux[string]) ([]*TaggedData[string], error) {
+		taga, err := mux.Tag("a")
+		assert.Nil(t, err)
+		tagb, _ := mux.Tag("b")
+		return mux.ReadWhile(func() error {
+			io.WriteString(taga, "aaaa")
+			time.Sleep(sleepDuration)
+			io.WriteString(tagb, "bbbb")
+			time.Sleep(sleepDuration)
+			io.WriteString(taga, "cccc")
+			return nil
+		})
+	}
+
+	t.Run("drop oldest", func(t *testing.T) {
+		mux := NewMux[string](WithMaxBufferedBytes[string](6, BufferDropOldest))
+		t.Cleanup(func() {
+			mux.Close()
+		})
+		td, err := write(mux)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(td))
+		assert.Equal(t, "b", td[0].Tag)
+		assert.Equal(t, "bb", string(td[0].Data))
+		assert.Equal(t, "a", td[1].Tag)
+		assert.Equal(t, "cccc", string(td[1].Data))
+	})
+
+	t.Run("drop oldest marker", func(t *testing.T) {
+		mux := NewMux[string](WithMaxBufferedBytes[string](6, BufferDropOldestMarker))
+		t.Cleanup(func() {
+			mux.Close()
+		})
+		td, err := write(mux)
+		assert.Nil(t, err)
+		assert.Equal(t, 4, len(td))
+		assert.Equal(t, "a", td[0].Tag)
+		assert.Equal(t, 4, td[0].Truncated)
+		assert.Equal(t, "[... 4 bytes dropped ...]\n", string(td[0].Data))
+		assert.Equal(t, "b", td[1].Tag)
+		assert.Equal(t, 2, td[1].Truncated)
+		assert.Equal(t, "[... 2 bytes dropped ...]\n", string(td[1].Data))
+		assert.Equal(t, "bb", string(td[2].Data))
+		assert.Equal(t, "cccc", string(td[3].Data))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		mux := NewMux[string](WithMaxBufferedBytes[string](6, BufferError))
+		t.Cleanup(func() {
+			mux.Close()
+		})
+		td, err := write(mux)
+		assert.ErrorIs(t, err, MuxBufferFull)
+		assert.NotEmpty(t, td)
+	})
+
+	t.Run("block", func(t *testing.T) {
+		mux := NewMux[string](WithMaxBufferedBytes[string](6, BufferBlock))
+		t.Cleanup(func() {
+			mux.Close()
+		})
+		taga, _ := mux.Tag("a")
+		tagb, _ := mux.Tag("b")
+		io.WriteString(taga, "aaaa")
+		time.Sleep(sleepDuration)
+		io.WriteString(tagb, "bbbb")
+		time.Sleep(sleepDuration)
+		io.WriteString(taga, "cccc")
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		td, err := mux.ReadUntil(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(td))
+		assert.Equal(t, "aaaa", string(td[0].Data))
+		assert.Equal(t, "bb", string(td[1].Data))
+
+		data, tag, err := mux.Read(context.Background())
+		assert.Nil(t, err)
+		assert.Equal(t, "b", tag)
+		assert.Equal(t, "bb", string(data))
+		data, tag, err = mux.Read(context.Background())
+		assert.Nil(t, err)
+		assert.Equal(t, "a", tag)
+		assert.Equal(t, "cccc", string(data))
+	})
+
+	t.Run("block while waiting", func(t *testing.T) {
+		mux := NewMux[string](WithMaxBufferedBytes[string](6, BufferBlock))
+		t.Cleanup(func() {
+			mux.Close()
+		})
+		called := false
+		_, err := mux.ReadWhile(func() error {
+			called = true
+			return nil
+		})
+		assert.ErrorIs(t, err, MuxBufferBlocks)
+		assert.False(t, called)
+	})
+
+	t.Run("default drains", func(t *testing.T) {
+		cmd := exec.Command("sh", "-c", "head -c 1000000 /dev/zero")
+		result, err := Run(context.Background(), cmd, WithMaxBufferedBytes[StdStream](1000, 0))
+		assert.Nil(t, err)
+		assert.Equal(t, 0, result.ExitCode)
+		size := 0
+		for _, td := range result.Data {
+			size += len(td.Data)
+		}
+		assert.Equal(t, 1000, size)
+	})
+
+	t.Run("block without deadline", func(t *testing.T) {
+		mux := NewMux[string](WithMaxBufferedBytes[string](6, BufferBlock))
+		t.Cleanup(func() {
+			mux.Close()
+		})
+		taga, err := mux.Tag("a")
+		assert.Nil(t, err)
+		io.WriteString(taga, "aaaa")
+		io.WriteString(taga, "cccc")
+		td, err := mux.ReadUntil(context.Background())
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(td))
+		assert.Equal(t, "aaaacc", string(td[0].Data))
+
+		data, _, err := mux.Read(context.Background())
+		assert.Nil(t, err)
+		assert.Equal(t, "cc", string(data))
+	})
+}
+
+func TestDropOldest(t *testing.T) {
+	td := []*TaggedData[string]{
+		{Tag: "a", Data: []byte("aaa")},
+		{Tag: "b", Data: []byte("bbb")},
+	}
+	td = dropOldest(td, 4, nil)
+	assert.Equal(t, 1, len(td))
+	assert.Equal(t, "bb", string(td[0].Data))
+}
+
+func TestMuxCloseHandler(t *testing.T) {
+	for _, network := range []string{"unix", "unixpacket"} {
+		t.Run(network, func(t *testing.T) {
+			var closed []string
+			mux := newMux(network, []Option[string]{
+				WithLineSplitting[string](),
+				WithCloseHandler(func(tag string) {
+					closed = append(closed, tag)
+				}),
+			})
+			t.Cleanup(func() {
+				mux.Close()
+			})
+			taga, err := mux.Tag("a")
+			if err != nil {
+				skipIfProtocolNotSupported(t, err)
+				assert.Nil(t, err)
+			}
+			tagb, _ := mux.Tag("b")
+			taga2, _ := mux.Tag("a")
+
+			ctx, cancelFn := context.WithCancel(context.Background())
+			defer cancelFn()
+			io.WriteString(taga, "partial")
+			taga.Close()
+			taga2.Close()
+			data, tag, err := mux.Read(ctx)
+			assert.Nil(t, err)
+			assert.Equal(t, "a", tag)
+			assert.Equal(t, "partial", string(data))
+			assert.Equal(t, []string{"a"}, closed)
+
+			tagb.Close()
+			cancelFn()
+			_, _, err = mux.Read(ctx)
+			assert.Equal(t, io.EOF, err)
+			assert.ElementsMatch(t, []string{"a", "b"}, closed)
+		})
+	}
+}
+
+func TestMuxTee(t *testing.T) {
+	var stdoutTee, stderrTee bytes.Buffer
+	mux := NewMux[StdStream](
+		WithLineSplitting[StdStream](),
+		WithTee(Stdout, &stdoutTee),
+		WithTee(Stderr, &stderrTee),
+	)
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	stdout, err := mux.Tag(Stdout)
+	assert.Nil(t, err)
+	stderr, _ := mux.Tag(Stderr)
+
+	td, err := mux.ReadWhile(func() error {
+		io.WriteString(stdout, "out1\npart")
+		time.Sleep(sleepDuration)
+		io.WriteString(stderr, "err1\n")
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(td))
+	assert.Equal(t, "out1\npart", stdoutTee.String())
+	assert.Equal(t, "err1\n", stderrTee.String())
+}
+
+func TestMuxDuplicateTagPolicy(t *testing.T) {
+	mux := NewMux[string]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	taga, err := mux.Tag("a")
+	assert.Nil(t, err)
+	again, err := mux.Tag("a")
+	assert.Nil(t, err)
+	assert.NotEqual(t, taga, again)
+
+	mux = NewMux[string](WithDuplicateTagPolicy[string](DuplicateTagExisting))
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	taga, err = mux.Tag("a")
+	assert.Nil(t, err)
+	again, err = mux.Tag("a")
+	assert.Nil(t, err)
+	assert.Equal(t, taga, again)
+	assert.Nil(t, mux.Untag("a"))
+	again, err = mux.Tag("a")
+	assert.Nil(t, err)
+	assert.NotEqual(t, taga, again)
+
+	mux = NewMux[string](WithDuplicateTagPolicy[string](DuplicateTagError))
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	_, err = mux.Tag("a")
+	assert.Nil(t, err)
+	_, err = mux.Tag("a")
+	assert.ErrorIs(t, err, MuxDuplicateTag)
+	_, err = mux.Tag("b")
+	assert.Nil(t, err)
+}
+
+func TestMuxLineGrouping(t *testing.T) {
+	mux := NewMux[string](WithLineGrouping[string](nil))
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	stderr, _ := mux.Tag("stderr")
+	td, err := mux.ReadWhile(func() error {
+		defer stderr.Close()
+		_, err := io.WriteString(stderr, "starting\npanic: boom\n\ngoroutine 1 [running]:\nmain.main()\n"+
+			"\t/src/main.go:5 +0x25\nexit status 2\n")
+		return err
+	})
+	assert.Nil(t, err)
+	assert.Len(t, td, 3)
+	assert.Equal(t, "starting\n", string(td[0].Data))
+	assert.Equal(t, "panic: boom\n\ngoroutine 1 [running]:\nmain.main()\n\t/src/main.go:5 +0x25\n", string(td[1].Data))
+	assert.Equal(t, "exit status 2\n", string(td[2].Data))
+}
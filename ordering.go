@@ -0,0 +1,53 @@
+package iomux
+
+import "runtime"
+
+// defaultNetwork returns the network used when none was chosen, which is 'unix' on macOS due to the 2048 byte message
+// limit of 'unixgram' there, see Limitations in the README.
+func (mux *Mux[T]) defaultNetwork() string {
+	if mux.strict {
+		return "unixgram"
+	}
+	if runtime.GOOS == "darwin" {
+		return "unix"
+	}
+	return defaultNetwork
+}
+
+// autoNetworks returns the networks tried by NewMuxAuto in order. macOS doesn't support 'unixpacket', and limits
+// 'unixgram' to 2048 byte messages, so only 'unix' is used there, as on Windows, which only supports 'unix'. With
+// WithStrictOrdering only 'unixgram' keeps the order.
+func (mux *Mux[T]) autoNetworks() []string {
+	if mux.strict {
+		return []string{"unixgram"}
+	}
+	switch runtime.GOOS {
+	case "darwin", "ios", "windows":
+		return []string{"unix"}
+	default:
+		return []string{"unixpacket", "unixgram", "unix"}
+	}
+}
+
+// Network Return the network used by the Mux. For NewMuxAuto, the network is chosen by the first call to Tag, before
+// which Network returns an empty string.
+func (mux *Mux[T]) Network() string {
+	if mux.network == "" && !mux.auto {
+		return mux.defaultNetwork()
+	}
+	return mux.network
+}
+
+// Ordered Report whether data is read in the order it was written across all tags. This holds for 'unixgram', where
+// every writer sends to the same socket, but not for the connection oriented networks or WithSocketpair, which read a
+// separate socket per writer, so only the order for each tag is kept.
+func (mux *Mux[T]) Ordered() bool {
+	network := mux.network
+	if network == "" && mux.auto {
+		// the network isn't chosen yet, so assume the first
+		network = mux.autoNetworks()[0]
+	} else if network == "" {
+		network = mux.defaultNetwork()
+	}
+	return network == "unixgram" && !mux.socketpair && runtime.GOOS != "windows"
+}
@@ -0,0 +1,94 @@
+//go:build !windows
+
+package iomux
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMuxOrdered(t *testing.T) {
+	assert.True(t, NewMuxUnixGram[string]().Ordered())
+	assert.False(t, NewMuxUnix[string]().Ordered())
+	assert.False(t, NewMuxUnixPacket[string]().Ordered())
+	assert.False(t, NewMuxUnixGram[string](WithSocketpair[string]()).Ordered())
+	assert.True(t, NewMux[string](WithStrictOrdering[string]()).Ordered())
+}
+
+func TestMuxStrictOrdering(t *testing.T) {
+	mux := NewMux[int](WithStrictOrdering[int]())
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	taga, err := mux.Tag(0)
+	assert.Nil(t, err)
+	tagb, err := mux.Tag(1)
+	assert.Nil(t, err)
+	assert.Equal(t, "unixgram", mux.network)
+
+	// datagrams queue up to a small limit, so write while reading
+	go func() {
+		for i := 0; i < 100; i++ {
+			if i%2 == 0 {
+				fmt.Fprint(taga, i)
+			} else {
+				fmt.Fprint(tagb, i)
+			}
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		data, tag, err := mux.Read(context.Background())
+		assert.Nil(t, err)
+		assert.Equal(t, i%2, tag)
+		assert.Equal(t, fmt.Sprint(i), string(data))
+	}
+}
+
+func TestMuxStrictOrderingUnsupported(t *testing.T) {
+	for _, mux := range []*Mux[string]{
+		NewMuxUnix[string](WithStrictOrdering[string]()),
+		NewMuxUnixPacket[string](WithStrictOrdering[string]()),
+		NewMux[string](WithStrictOrdering[string](), WithSocketpair[string]()),
+	} {
+		_, err := mux.Tag("a")
+		assert.Equal(t, MuxUnordered, err)
+		assert.Equal(t, MuxClosed, mux.Close())
+	}
+}
+
+func TestMuxNetwork(t *testing.T) {
+	assert.Equal(t, "unixpacket", NewMuxUnixPacket[string]().Network())
+	assert.Equal(t, NewMux[string]().defaultNetwork(), NewMux[string]().Network())
+	assert.Equal(t, "", NewMuxAuto[string]().Network())
+}
+
+func TestMuxAuto(t *testing.T) {
+	for _, opts := range [][]Option[string]{nil, {WithSocketpair[string]()}, {WithStrictOrdering[string]()}} {
+		mux := NewMuxAuto[string](opts...)
+		t.Cleanup(func() {
+			mux.Close()
+		})
+		taga, err := mux.Tag("a")
+		assert.Nil(t, err)
+		assert.Contains(t, mux.autoNetworks(), mux.Network())
+		if runtime.GOOS == "linux" && !mux.strict {
+			assert.Equal(t, "unixpacket", mux.Network())
+		}
+
+		io.WriteString(taga, "hello")
+		taga.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		data, tag, err := mux.Read(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, "a", tag)
+		assert.Equal(t, "hello", string(data))
+	}
+	assert.Equal(t, "unixgram", NewMuxAuto[string](WithStrictOrdering[string]()).autoNetworks()[0])
+}
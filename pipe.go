@@ -0,0 +1,112 @@
+package iomux
+
+import (
+	"context"
+	"io"
+	"iter"
+	"os"
+	"sync"
+	"time"
+)
+
+// PipeMux is a Muxer using an os.Pipe per writer rather than sockets, as a test double for code accepting a Muxer, as
+// it works on every platform with no temporary files. Each pipe is copied separately, so only the order for each tag
+// is kept.
+type PipeMux[T comparable] struct {
+	mem     *MemoryMux[T]
+	mutex   sync.Mutex
+	readers []*os.File
+	wg      sync.WaitGroup
+}
+
+// NewPipeMux Create a new PipeMux.
+func NewPipeMux[T comparable]() *PipeMux[T] {
+	return &PipeMux[T]{mem: NewMemoryMux[T]()}
+}
+
+// Tag Create a pipe to receive data tagged with tag T. Returns its write end as an *os.File, or an error.
+func (mux *PipeMux[T]) Tag(tag T) (*os.File, error) {
+	r, file, err := os.Pipe()
+	if err != nil {
+		return nil, &TagError[T]{Tag: tag, Op: "tag", Err: err}
+	}
+	if err := mux.copy(tag, r); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+// copy copies what is written to the pipe read by r, tagged with tag, until every copy of its write end is closed.
+// Takes ownership of r.
+func (mux *PipeMux[T]) copy(tag T, r *os.File) error {
+	mux.mutex.Lock()
+	defer mux.mutex.Unlock()
+	w, err := mux.mem.Tag(tag)
+	if err != nil {
+		r.Close()
+		return err
+	}
+	mux.readers = append(mux.readers, r)
+	mux.wg.Add(1)
+	go func() {
+		defer mux.wg.Done()
+		defer w.Close()
+		// the pipe reaches io.EOF once every copy of the write end has been closed
+		_, _ = io.CopyBuffer(w, r, make([]byte, 65536))
+	}()
+	return nil
+}
+
+// Read perform a read, blocking until data is available or ctx.Done. Returns io.EOF error when ctx is done and there
+// is no data remaining to be read.
+func (mux *PipeMux[T]) Read(ctx context.Context) ([]byte, T, error) {
+	return mux.mem.Read(ctx)
+}
+
+// ReadWhile Read until waitFn returns, returning the read data.
+func (mux *PipeMux[T]) ReadWhile(waitFn func() error) ([]*TaggedData[T], error) {
+	return mux.mem.ReadWhile(func() error {
+		err := waitFn()
+		mux.drain()
+		return err
+	})
+}
+
+// ReadUntil Read until ctx is done, returning the read data.
+func (mux *PipeMux[T]) ReadUntil(ctx context.Context) ([]*TaggedData[T], error) {
+	return mux.mem.ReadUntil(ctx)
+}
+
+// All Read until ctx is done, yielding each chunk and its tag as it arrives, for use with range.
+func (mux *PipeMux[T]) All(ctx context.Context) iter.Seq2[T, []byte] {
+	return mux.mem.All(ctx)
+}
+
+// drain waits briefly for the pipes to be copied, so data written before a process exits isn't left in a pipe when
+// reading stops. Pipes still open for writing elsewhere are not waited on beyond deadlineDuration.
+func (mux *PipeMux[T]) drain() {
+	done := make(chan struct{})
+	go func() {
+		mux.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(deadlineDuration):
+	}
+}
+
+// Close closes the PipeMux, closing the read end of every pipe. Prevents reuse.
+func (mux *PipeMux[T]) Close() error {
+	mux.mutex.Lock()
+	defer mux.mutex.Unlock()
+	if err := mux.mem.Close(); err != nil {
+		return err
+	}
+	for _, r := range mux.readers {
+		r.Close()
+	}
+	mux.wg.Wait()
+	return nil
+}
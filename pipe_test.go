@@ -0,0 +1,65 @@
+package iomux
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// capture is an example of code accepting a Muxer, writing greeting to a file tagged tag.
+func capture[T comparable](mux Muxer[T], tag T, greeting string) ([]*TaggedData[T], error) {
+	w, err := mux.Tag(tag)
+	if err != nil {
+		return nil, err
+	}
+	return mux.ReadWhile(func() error {
+		defer w.Close()
+		_, err := io.WriteString(w, greeting)
+		return err
+	})
+}
+
+func TestPipeMux(t *testing.T) {
+	mux := NewPipeMux[string]()
+	td, err := capture[string](mux, "a", "hello")
+	assert.Nil(t, err)
+	assert.Len(t, td, 1)
+	assert.Equal(t, "a", td[0].Tag)
+	assert.Equal(t, "hello", string(td[0].Data))
+	assert.Nil(t, mux.Close())
+}
+
+func TestPipeMuxTags(t *testing.T) {
+	mux := NewPipeMux[string]()
+	defer mux.Close()
+	a, err := mux.Tag("a")
+	assert.Nil(t, err)
+	b, err := mux.Tag("b")
+	assert.Nil(t, err)
+
+	_, _ = a.Write([]byte("hello"))
+	_, _ = b.Write([]byte("world"))
+	a.Close()
+	b.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	td, err := mux.ReadUntil(ctx)
+	assert.Nil(t, err)
+	got := map[string]string{}
+	for _, d := range td {
+		got[d.Tag] += string(d.Data)
+	}
+	assert.Equal(t, map[string]string{"a": "hello", "b": "world"}, got)
+}
+
+func TestPipeMuxClosed(t *testing.T) {
+	mux := NewPipeMux[string]()
+	assert.Nil(t, mux.Close())
+	_, err := mux.Tag("a")
+	assert.ErrorIs(t, err, MuxClosed)
+	assert.ErrorIs(t, mux.Close(), MuxClosed)
+}
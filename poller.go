@@ -0,0 +1,134 @@
+package iomux
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"time"
+)
+
+// poller waits for the receive connections of a Mux to become readable, so a single goroutine can read any number of
+// connections rather than needing a goroutine per connection. It is implemented with epoll on Linux and kqueue on the
+// BSDs and macOS, elsewhere newPoller fails and connections are read by goroutines instead.
+type poller interface {
+	// add starts watching conn.
+	add(conn *net.UnixConn) error
+	// remove stops watching conn.
+	remove(conn *net.UnixConn) error
+	// wait blocks for up to timeout, returning the connections that are ready to read.
+	wait(timeout time.Duration) ([]*net.UnixConn, error)
+	// Close releases the poller.
+	Close() error
+}
+
+// errWouldBlock is returned by readConn when there turned out to be nothing to read.
+var errWouldBlock = errors.New("read would block")
+
+// poll reads from the next ready connection, waiting on the poller for one to become ready.
+func (mux *Mux[T]) poll(ctx context.Context, deadline time.Time) ([]byte, T, error) {
+	var zeroTag T
+	mux.pollmutex.Lock()
+	defer mux.pollmutex.Unlock()
+	for {
+		if mux.sendersClosed() {
+			return nil, zeroTag, io.EOF
+		}
+		var conn *net.UnixConn
+		if mux.pollbatch.pending() {
+			// return the rest of the last batch before receiving more
+			conn = mux.pollconn
+		} else {
+			if len(mux.ready) == 0 {
+				// wake at least every deadlineDuration to notice ctx, and don't wait at all once it is done, so any
+				// data already sent is drained before reporting io.EOF
+				timeout := deadlineDuration
+				if ctx.Err() != nil {
+					timeout = 0
+				}
+				if !deadline.IsZero() {
+					timeout = max(min(timeout, time.Until(deadline)), 0)
+				}
+				ready, err := mux.poller.wait(timeout)
+				if err != nil {
+					if mux.isClosed() {
+						return nil, zeroTag, MuxClosed
+					}
+					return nil, zeroTag, err
+				}
+				if len(ready) == 0 {
+					if ctx.Err() != nil {
+						return nil, zeroTag, io.EOF
+					}
+					if !deadline.IsZero() && !time.Now().Before(deadline) {
+						return nil, zeroTag, os.ErrDeadlineExceeded
+					}
+					continue
+				}
+				mux.ready = ready
+			}
+			conn = mux.ready[0]
+			mux.ready = mux.ready[1:]
+		}
+		if mux.recvclosed[conn] || !mux.hasReceiver(conn) {
+			if mux.pollbatch.pending() {
+				mux.pollbatch.discard()
+			}
+			continue
+		}
+		data, truncated, err := mux.readReady(conn)
+		if err == errWouldBlock {
+			continue
+		}
+		if err != nil {
+			err = mux.tagError("read", conn, err)
+			if tag, ok := mux.failed(err); ok {
+				_ = mux.poller.remove(conn)
+				mux.senderClosed(conn, tag)
+				return nil, zeroTag, errSenderClosed
+			}
+			return nil, zeroTag, err
+		}
+		if truncated {
+			mux.stats.truncate()
+		}
+		if len(data) == 0 && mux.network == "unixgram" {
+			// an empty datagram, as datagram sockets don't report being closed
+			continue
+		}
+		s, _ := mux.senderOf(nil, conn)
+		tag := s.tag
+		mux.recvsrc.conn = s.id
+		if len(data) == 0 {
+			_ = mux.poller.remove(conn)
+			mux.senderClosed(conn, tag)
+			return nil, zeroTag, errSenderClosed
+		}
+		if mux.fragmented() {
+			data, ok := mux.reassemble(conn, data)
+			if !ok {
+				continue
+			}
+			return data, tag, nil
+		}
+		return mux.copyData(data, mux.into), tag, nil
+	}
+}
+
+// readReady reads from conn, which the poller reported ready, or which has messages remaining from the last batch.
+func (mux *Mux[T]) readReady(conn *net.UnixConn) ([]byte, bool, error) {
+	if mux.pollbatch == nil {
+		n, oobn, truncated, err := readConn(conn, mux.pollbuf, mux.polloob)
+		if mux.creds {
+			mux.recvsrc.cred = parseCred(mux.polloob[:oobn])
+		}
+		return mux.pollbuf[:n], truncated, err
+	}
+	if mux.pollbatch.pending() {
+		data, _, truncated := mux.pollbatch.pop()
+		return data, truncated, nil
+	}
+	mux.pollconn = conn
+	return mux.pollbatch.readReady(conn)
+}
@@ -0,0 +1,90 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+
+package iomux
+
+import (
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+type kqueue struct {
+	fd     int
+	mutex  sync.Mutex
+	conns  map[int]*net.UnixConn
+	events []unix.Kevent_t
+}
+
+func newPoller() (poller, error) {
+	fd, err := unix.Kqueue()
+	if err != nil {
+		return nil, os.NewSyscallError("kqueue", err)
+	}
+	unix.CloseOnExec(fd)
+	return &kqueue{
+		fd:     fd,
+		conns:  make(map[int]*net.UnixConn),
+		events: make([]unix.Kevent_t, 64),
+	}, nil
+}
+
+func (p *kqueue) add(conn *net.UnixConn) error {
+	fd, err := connFd(conn)
+	if err != nil {
+		return err
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if err := p.change(fd, unix.EV_ADD); err != nil {
+		return err
+	}
+	p.conns[fd] = conn
+	return nil
+}
+
+func (p *kqueue) remove(conn *net.UnixConn) error {
+	fd, err := connFd(conn)
+	if err != nil {
+		return err
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	delete(p.conns, fd)
+	return p.change(fd, unix.EV_DELETE)
+}
+
+func (p *kqueue) change(fd int, flags int) error {
+	var event unix.Kevent_t
+	unix.SetKevent(&event, fd, unix.EVFILT_READ, flags)
+	if _, err := unix.Kevent(p.fd, []unix.Kevent_t{event}, nil, nil); err != nil {
+		return os.NewSyscallError("kevent", err)
+	}
+	return nil
+}
+
+func (p *kqueue) wait(timeout time.Duration) ([]*net.UnixConn, error) {
+	ts := unix.NsecToTimespec(int64(timeout))
+	n, err := unix.Kevent(p.fd, nil, p.events, &ts)
+	if err == unix.EINTR {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, os.NewSyscallError("kevent", err)
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	ready := make([]*net.UnixConn, 0, n)
+	for _, event := range p.events[:n] {
+		if conn, ok := p.conns[int(event.Ident)]; ok {
+			ready = append(ready, conn)
+		}
+	}
+	return ready, nil
+}
+
+func (p *kqueue) Close() error {
+	return unix.Close(p.fd)
+}
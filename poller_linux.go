@@ -0,0 +1,81 @@
+package iomux
+
+import (
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+type epoll struct {
+	fd     int
+	mutex  sync.Mutex
+	conns  map[int32]*net.UnixConn
+	events []unix.EpollEvent
+}
+
+func newPoller() (poller, error) {
+	fd, err := unix.EpollCreate1(unix.EPOLL_CLOEXEC)
+	if err != nil {
+		return nil, os.NewSyscallError("epoll_create1", err)
+	}
+	return &epoll{
+		fd:     fd,
+		conns:  make(map[int32]*net.UnixConn),
+		events: make([]unix.EpollEvent, 64),
+	}, nil
+}
+
+func (p *epoll) add(conn *net.UnixConn) error {
+	fd, err := connFd(conn)
+	if err != nil {
+		return err
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	event := unix.EpollEvent{Events: unix.EPOLLIN | unix.EPOLLRDHUP, Fd: int32(fd)}
+	if err := unix.EpollCtl(p.fd, unix.EPOLL_CTL_ADD, fd, &event); err != nil {
+		return os.NewSyscallError("epoll_ctl", err)
+	}
+	p.conns[int32(fd)] = conn
+	return nil
+}
+
+func (p *epoll) remove(conn *net.UnixConn) error {
+	fd, err := connFd(conn)
+	if err != nil {
+		return err
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	delete(p.conns, int32(fd))
+	if err := unix.EpollCtl(p.fd, unix.EPOLL_CTL_DEL, fd, nil); err != nil {
+		return os.NewSyscallError("epoll_ctl", err)
+	}
+	return nil
+}
+
+func (p *epoll) wait(timeout time.Duration) ([]*net.UnixConn, error) {
+	n, err := unix.EpollWait(p.fd, p.events, int((timeout+time.Millisecond-1)/time.Millisecond))
+	if err == unix.EINTR {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, os.NewSyscallError("epoll_wait", err)
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	ready := make([]*net.UnixConn, 0, n)
+	for _, event := range p.events[:n] {
+		if conn, ok := p.conns[event.Fd]; ok {
+			ready = append(ready, conn)
+		}
+	}
+	return ready, nil
+}
+
+func (p *epoll) Close() error {
+	return unix.Close(p.fd)
+}
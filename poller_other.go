@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+
+package iomux
+
+import "errors"
+
+func newPoller() (poller, error) {
+	return nil, errors.ErrUnsupported
+}
@@ -0,0 +1,80 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package iomux
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMuxPollManyTags(t *testing.T) {
+	for _, network := range []string{"unix", "unixpacket"} {
+		t.Run(network, func(t *testing.T) {
+			mux := &Mux[int]{network: network}
+			t.Cleanup(func() {
+				mux.Close()
+			})
+			const tags = 100
+			for i := 0; i < tags; i++ {
+				sender, err := mux.Tag(i)
+				if err != nil {
+					skipIfProtocolNotSupported(t, err)
+					assert.Nil(t, err)
+				}
+				sender.WriteString(fmt.Sprint(i))
+				sender.Close()
+			}
+			assert.NotNil(t, mux.poller)
+
+			goroutines := runtime.NumGoroutine()
+			received := make(map[int]string)
+			for {
+				data, tag, err := mux.Read(context.Background())
+				if err == io.EOF {
+					break
+				}
+				assert.Nil(t, err)
+				received[tag] += string(data)
+				// connections are read by the poller, rather than a goroutine each
+				assert.LessOrEqual(t, runtime.NumGoroutine(), goroutines)
+			}
+			assert.Equal(t, tags, len(received))
+			for i := 0; i < tags; i++ {
+				assert.Equal(t, fmt.Sprint(i), received[i])
+			}
+		})
+	}
+}
+
+func TestMuxPollDeadline(t *testing.T) {
+	mux := NewMuxUnix[string]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	taga, _ := mux.Tag("a")
+	defer taga.Close()
+	tagb, _ := mux.Tag("b")
+	defer tagb.Close()
+
+	mux.SetReadDeadline(time.Now().Add(sleepDuration))
+	_, _, err := mux.Read(context.Background())
+	assert.ErrorIs(t, err, os.ErrDeadlineExceeded)
+
+	mux.SetReadDeadline(time.Time{})
+	ctx, cancelFn := context.WithTimeout(context.Background(), sleepDuration)
+	defer cancelFn()
+	tagb.WriteString("hello tagb")
+	data, tag, err := mux.Read(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "b", tag)
+	assert.Equal(t, "hello tagb", string(data))
+	_, _, err = mux.Read(ctx)
+	assert.Equal(t, io.EOF, err)
+}
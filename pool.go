@@ -0,0 +1,52 @@
+package iomux
+
+import (
+	"math/bits"
+	"sync"
+)
+
+const (
+	minPoolShift = 6
+	maxPoolShift = 16
+)
+
+// buffers pools read buffers by power of two size classes, from 64 bytes up to the 64KiB maximum read size.
+var buffers [maxPoolShift - minPoolShift + 1]sync.Pool
+
+// getBuffer returns a slice of length n, reusing a released buffer where possible.
+func getBuffer(n int) []byte {
+	shift := max(bits.Len(uint(n-1)), minPoolShift)
+	if shift > maxPoolShift {
+		return make([]byte, n)
+	}
+	if b, ok := buffers[shift-minPoolShift].Get().(*[]byte); ok {
+		return (*b)[:n]
+	}
+	return make([]byte, n, 1<<shift)
+}
+
+// Release Return data read from a Mux created WithPooledBuffers for reuse by later reads. data must not be used
+// afterwards. Slices that didn't come from the pool, for example because they were grown by append, are ignored.
+func Release(data []byte) {
+	c := cap(data)
+	if c == 0 || c&(c-1) != 0 {
+		return
+	}
+	shift := bits.Len(uint(c)) - 1
+	if shift < minPoolShift || shift > maxPoolShift {
+		return
+	}
+	data = data[:0]
+	buffers[shift-minPoolShift].Put(&data)
+}
+
+// Release Return the data of td for reuse, see Release. td.Data is set to nil, and data spilled by WithSpill is given up
+// without being loaded.
+func (td *TaggedData[T]) Release() {
+	if td.spilled != nil {
+		td.spilled.file.done()
+		td.spilled = nil
+	}
+	Release(td.Data)
+	td.Data = nil
+}
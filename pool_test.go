@@ -0,0 +1,139 @@
+//go:build !windows
+
+package iomux
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetBuffer(t *testing.T) {
+	b := getBuffer(100)
+	assert.Equal(t, 100, len(b))
+	assert.Equal(t, 128, cap(b))
+	b = getBuffer(1)
+	assert.Equal(t, 1, len(b))
+	assert.Equal(t, 64, cap(b))
+	b = getBuffer(1 << 20)
+	assert.Equal(t, 1<<20, len(b))
+}
+
+func TestRelease(t *testing.T) {
+	b := getBuffer(1000)
+	b[0] = 'x'
+	Release(b)
+	// may not be reused as the pool is free to drop buffers, but must be the right size if it is
+	b = getBuffer(600)
+	assert.Equal(t, 600, len(b))
+	assert.Equal(t, 1024, cap(b))
+
+	// ignored as they didn't come from the pool
+	Release(make([]byte, 10, 10))
+	Release(nil)
+
+	td := &TaggedData[string]{Data: getBuffer(10)}
+	td.Release()
+	assert.Nil(t, td.Data)
+}
+
+func TestMuxPooledBuffers(t *testing.T) {
+	mux := NewMux[string](WithPooledBuffers[string]())
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	taga, err := mux.Tag("a")
+	assert.Nil(t, err)
+
+	for i := 0; i < 3; i++ {
+		taga.WriteString("hello taga")
+		data, tag, err := mux.Read(context.Background())
+		assert.Nil(t, err)
+		assert.Equal(t, "a", tag)
+		assert.Equal(t, "hello taga", string(data))
+		Release(data)
+	}
+}
+
+func TestMuxPooledBuffersLines(t *testing.T) {
+	mux := NewMux[string](WithPooledBuffers[string](), WithLineSplitting[string]())
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	taga, err := mux.Tag("a")
+	assert.Nil(t, err)
+
+	for i := 0; i < 3; i++ {
+		taga.WriteString("one\ntwo\nthr")
+		taga.WriteString("ee\n")
+		for _, expected := range []string{"one\n", "two\n", "three\n"} {
+			data, tag, err := mux.Read(context.Background())
+			assert.Nil(t, err)
+			assert.Equal(t, "a", tag)
+			assert.Equal(t, expected, string(data))
+			// each line has a pooled buffer of its own, rather than aliasing the buffer it was read into
+			assert.Equal(t, 64, cap(data))
+			Release(data)
+		}
+	}
+}
+
+func benchmarkMuxRead(b *testing.B, opts ...Option[string]) {
+	mux := NewMux[string](opts...)
+	b.Cleanup(func() {
+		mux.Close()
+	})
+	taga, err := mux.Tag("a")
+	if err != nil {
+		b.Fatal(err)
+	}
+	chunk := make([]byte, 4096)
+	ctx := context.Background()
+	b.SetBytes(int64(len(chunk)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := taga.Write(chunk); err != nil {
+			b.Fatal(err)
+		}
+		data, _, err := mux.Read(ctx)
+		if err != nil {
+			b.Fatal(err)
+		}
+		Release(data)
+	}
+}
+
+func BenchmarkMuxRead(b *testing.B) {
+	benchmarkMuxRead(b)
+}
+
+func BenchmarkMuxReadPooled(b *testing.B) {
+	benchmarkMuxRead(b, WithPooledBuffers[string]())
+}
+
+func BenchmarkMuxReadInto(b *testing.B) {
+	mux := NewMux[string]()
+	b.Cleanup(func() {
+		mux.Close()
+	})
+	taga, err := mux.Tag("a")
+	if err != nil {
+		b.Fatal(err)
+	}
+	chunk := make([]byte, 4096)
+	buf := make([]byte, len(chunk))
+	ctx := context.Background()
+	b.SetBytes(int64(len(chunk)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := taga.Write(chunk); err != nil {
+			b.Fatal(err)
+		}
+		if _, _, err := mux.ReadInto(ctx, buf); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
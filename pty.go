@@ -0,0 +1,33 @@
+package iomux
+
+import (
+	"io"
+	"os"
+)
+
+// TagPty Create a pseudo-terminal tagged with tag, returning its terminal end for use as a command's stdout or stderr,
+// so programs that check for a terminal keep their colors and progress output. Output is copied from the
+// pseudo-terminal to a file created by Tag, so it is ordered with other tags as it is copied, and has the terminal's
+// line endings. Close the returned file once the command has started, copying stops when every copy of it is closed.
+// Pseudo-terminals are supported on Linux, macOS and FreeBSD, elsewhere the error is errors.ErrUnsupported.
+func (mux *Mux[T]) TagPty(tag T) (*os.File, error) {
+	pty, tty, err := openPty()
+	if err != nil {
+		return nil, &TagError[T]{Tag: tag, Op: "tag", Err: err}
+	}
+	w, err := mux.Tag(tag)
+	if err != nil {
+		pty.Close()
+		tty.Close()
+		return nil, err
+	}
+	mux.addCloser(pty)
+	go func() {
+		defer w.Close()
+		defer mux.removeCloser(pty)
+		defer pty.Close()
+		// reading the pseudo-terminal fails with EIO once the terminal end is closed
+		io.Copy(w, pty)
+	}()
+	return tty, nil
+}
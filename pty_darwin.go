@@ -0,0 +1,55 @@
+package iomux
+
+import (
+	"bytes"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// openPty Open a pseudo-terminal, returning the pty and tty ends. Equivalent to posix_openpt, grantpt, unlockpt and
+// ptsname, which are ioctls of /dev/ptmx on macOS.
+func openPty() (*os.File, *os.File, error) {
+	pty, err := os.OpenFile("/dev/ptmx", os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	raw, err := pty.SyscallConn()
+	if err != nil {
+		pty.Close()
+		return nil, nil, err
+	}
+	// sized by TIOCPTYGNAME
+	name := make([]byte, 128)
+	var ioctlErr error
+	err = raw.Control(func(fd uintptr) {
+		if ioctlErr = unix.IoctlSetInt(int(fd), unix.TIOCPTYGRANT, 0); ioctlErr != nil {
+			return
+		}
+		if ioctlErr = unix.IoctlSetInt(int(fd), unix.TIOCPTYUNLK, 0); ioctlErr != nil {
+			return
+		}
+		_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, unix.TIOCPTYGNAME, uintptr(unsafe.Pointer(&name[0])))
+		if errno != 0 {
+			ioctlErr = errno
+		}
+	})
+	if err == nil {
+		err = ioctlErr
+	}
+	if err != nil {
+		pty.Close()
+		return nil, nil, err
+	}
+	if i := bytes.IndexByte(name, 0); i >= 0 {
+		name = name[:i]
+	}
+	tty, err := os.OpenFile(string(name), os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		pty.Close()
+		return nil, nil, err
+	}
+	return pty, tty, nil
+}
@@ -0,0 +1,30 @@
+package iomux
+
+import (
+	"os"
+	"strconv"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// openPty Open a pseudo-terminal, returning the pty and tty ends. FreeBSD has no /dev/ptmx, so the pty is opened with
+// posix_openpt, and needs no grantpt or unlockpt.
+func openPty() (*os.File, *os.File, error) {
+	fd, _, errno := syscall.Syscall(unix.SYS_POSIX_OPENPT, uintptr(unix.O_RDWR|unix.O_NOCTTY|unix.O_CLOEXEC), 0, 0)
+	if errno != 0 {
+		return nil, nil, os.NewSyscallError("posix_openpt", errno)
+	}
+	pty := os.NewFile(fd, "/dev/ptmx")
+	n, err := unix.IoctlGetInt(int(fd), unix.TIOCGPTN)
+	if err != nil {
+		pty.Close()
+		return nil, nil, err
+	}
+	tty, err := os.OpenFile("/dev/pts/"+strconv.Itoa(n), os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		pty.Close()
+		return nil, nil, err
+	}
+	return pty, tty, nil
+}
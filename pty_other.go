@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+package iomux
+
+import (
+	"errors"
+	"os"
+)
+
+// openPty is unsupported. The other BSDs open pseudo-terminals with ioctls of their own, which aren't implemented.
+func openPty() (*os.File, *os.File, error) {
+	return nil, nil, errors.ErrUnsupported
+}
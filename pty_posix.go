@@ -0,0 +1,21 @@
+//go:build !windows
+
+package iomux
+
+import "os/exec"
+
+// startPty Start cmd with its stdout and stderr on a pseudo-terminal created by TagPty for tag. The returned function
+// has nothing left to release, as copying stops when the command exits.
+func startPty[T comparable](mux *Mux[T], tag T, cmd *exec.Cmd) (func(), error) {
+	tty, err := mux.TagPty(tag)
+	if err != nil {
+		return nil, err
+	}
+	defer tty.Close()
+	cmd.Stdout = tty
+	cmd.Stderr = tty
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return func() {}, nil
+}
@@ -0,0 +1,73 @@
+//go:build linux || darwin || freebsd
+
+package iomux
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMuxTagPty(t *testing.T) {
+	mux := NewMux[string]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	tty, err := mux.TagPty("tty")
+	assert.Nil(t, err)
+	stderr, err := mux.Tag("stderr")
+	assert.Nil(t, err)
+
+	cmd := exec.Command("sh", "-c", "[ -t 1 ] && echo tty; [ -t 2 ] || echo pipe 1>&2")
+	cmd.Stdout = tty
+	cmd.Stderr = stderr
+	assert.Nil(t, cmd.Start())
+	tty.Close()
+	assert.Nil(t, cmd.Wait())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	got := make(map[string]string)
+	for len(got) < 2 {
+		data, tag, err := mux.Read(ctx)
+		if !assert.Nil(t, err) {
+			break
+		}
+		got[tag] += string(data)
+	}
+	assert.Equal(t, "tty\r\n", got["tty"])
+	assert.Equal(t, "pipe\n", got["stderr"])
+}
+
+func TestIsTerminal(t *testing.T) {
+	pty, tty, err := openPty()
+	assert.Nil(t, err)
+	defer pty.Close()
+	defer tty.Close()
+	assert.True(t, isTerminal(tty))
+	assert.False(t, isTerminal(&bytes.Buffer{}))
+
+	r, w, err := os.Pipe()
+	assert.Nil(t, err)
+	defer r.Close()
+	defer w.Close()
+	assert.False(t, isTerminal(w))
+}
+
+func TestRunPty(t *testing.T) {
+	cmd := exec.Command("sh", "-c", "[ -t 1 ] && echo tty; [ -t 2 ] && echo tty 1>&2; exit 3")
+	result, err := RunPty(context.Background(), cmd)
+	assert.NotNil(t, err)
+	assert.Equal(t, 3, result.ExitCode)
+	var out string
+	for _, td := range result.Data {
+		assert.Equal(t, Stdout, td.Tag)
+		out += string(td.Data)
+	}
+	assert.Equal(t, "tty\r\ntty\r\n", out)
+}
@@ -0,0 +1,148 @@
+package iomux
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"unicode/utf16"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	kernel32                      = windows.NewLazySystemDLL("kernel32.dll")
+	procCreatePseudoConsole       = kernel32.NewProc("CreatePseudoConsole")
+	procClosePseudoConsole        = kernel32.NewProc("ClosePseudoConsole")
+	procUpdateProcThreadAttribute = kernel32.NewProc("UpdateProcThreadAttribute")
+)
+
+// procThreadAttributePseudoConsole is PROC_THREAD_ATTRIBUTE_PSEUDOCONSOLE, which golang.org/x/sys doesn't define.
+const procThreadAttributePseudoConsole = 0x20016
+
+// consoleSize is the size of the pseudo console commands are run on by RunPty.
+var consoleSize = windows.Coord{X: 80, Y: 25}
+
+// openPty is unsupported, as a ConPTY is attached to a process through its STARTUPINFOEX rather than a file, so can't
+// be handed to exec.Cmd, see startPty.
+func openPty() (*os.File, *os.File, error) {
+	return nil, nil, errors.ErrUnsupported
+}
+
+// startPty Start cmd attached to a pseudo console, copying the console's output to a file created by Tag for tag. The
+// returned function closes the console once cmd has exited, which ends the copy. exec.Cmd can't pass a pseudo console,
+// so the process is created directly, and cmd.Process is set as if by cmd.Start so cmd.Wait can be used.
+func startPty[T comparable](mux *Mux[T], tag T, cmd *exec.Cmd) (func(), error) {
+	if cmd.Process != nil {
+		return nil, errors.New("exec: already started")
+	}
+	if cmd.Err != nil {
+		return nil, cmd.Err
+	}
+	w, err := mux.Tag(tag)
+	if err != nil {
+		return nil, err
+	}
+	var inR, inW, outR, outW windows.Handle
+	if err := windows.CreatePipe(&inR, &inW, nil, 0); err != nil {
+		w.Close()
+		return nil, os.NewSyscallError("CreatePipe", err)
+	}
+	if err := windows.CreatePipe(&outR, &outW, nil, 0); err != nil {
+		windows.CloseHandle(inR)
+		windows.CloseHandle(inW)
+		w.Close()
+		return nil, os.NewSyscallError("CreatePipe", err)
+	}
+	var console windows.Handle
+	size := uintptr(uint16(consoleSize.X)) | uintptr(uint16(consoleSize.Y))<<16
+	hr, _, _ := procCreatePseudoConsole.Call(size, uintptr(inR), uintptr(outW), 0, uintptr(unsafe.Pointer(&console)))
+	// the console holds its own handles to its ends of the pipes
+	windows.CloseHandle(inR)
+	windows.CloseHandle(outW)
+	if hr != 0 {
+		windows.CloseHandle(inW)
+		windows.CloseHandle(outR)
+		w.Close()
+		return nil, fmt.Errorf("CreatePseudoConsole: HRESULT %#x", hr)
+	}
+	out := os.NewFile(uintptr(outR), "conpty")
+	go func() {
+		defer w.Close()
+		defer out.Close()
+		// reading fails once the console is closed
+		io.Copy(w, out)
+	}()
+	closeConsole := func() {
+		procClosePseudoConsole.Call(uintptr(console))
+		windows.CloseHandle(inW)
+	}
+	if err := createConsoleProcess(cmd, console); err != nil {
+		closeConsole()
+		return nil, err
+	}
+	return closeConsole, nil
+}
+
+// createConsoleProcess creates the process of cmd attached to console, setting cmd.Process.
+func createConsoleProcess(cmd *exec.Cmd, console windows.Handle) error {
+	attrs, err := windows.NewProcThreadAttributeList(1)
+	if err != nil {
+		return err
+	}
+	defer attrs.Delete()
+	// the attribute's value is the handle itself, rather than a pointer to it
+	ok, _, err := procUpdateProcThreadAttribute.Call(uintptr(unsafe.Pointer(attrs.List())), 0,
+		procThreadAttributePseudoConsole, uintptr(console), unsafe.Sizeof(console), 0, 0)
+	if ok == 0 {
+		return os.NewSyscallError("UpdateProcThreadAttribute", err)
+	}
+	si := &windows.StartupInfoEx{ProcThreadAttributeList: attrs.List()}
+	si.Cb = uint32(unsafe.Sizeof(*si))
+	appName, err := windows.UTF16PtrFromString(cmd.Path)
+	if err != nil {
+		return err
+	}
+	commandLine, err := windows.UTF16PtrFromString(windows.ComposeCommandLine(cmd.Args))
+	if err != nil {
+		return err
+	}
+	var dir *uint16
+	if cmd.Dir != "" {
+		if dir, err = windows.UTF16PtrFromString(cmd.Dir); err != nil {
+			return err
+		}
+	}
+	env := envBlock(cmd.Environ())
+	var pi windows.ProcessInformation
+	flags := uint32(windows.EXTENDED_STARTUPINFO_PRESENT | windows.CREATE_UNICODE_ENVIRONMENT)
+	err = windows.CreateProcess(appName, commandLine, nil, nil, false, flags, &env[0], dir, &si.StartupInfo, &pi)
+	if err != nil {
+		return os.NewSyscallError("CreateProcess", err)
+	}
+	// the process handle keeps the pid from being reused until it has been found
+	defer windows.CloseHandle(pi.Process)
+	windows.CloseHandle(pi.Thread)
+	p, err := os.FindProcess(int(pi.ProcessId))
+	if err != nil {
+		return err
+	}
+	cmd.Process = p
+	return nil
+}
+
+// envBlock encodes env as the environment block of CreateProcess, each variable terminated by a NUL, with another NUL
+// ending the block.
+func envBlock(env []string) []uint16 {
+	var block []uint16
+	for _, kv := range env {
+		block = append(block, utf16.Encode([]rune(kv))...)
+		block = append(block, 0)
+	}
+	if len(block) == 0 {
+		block = append(block, 0)
+	}
+	return append(block, 0)
+}
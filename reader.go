@@ -0,0 +1,104 @@
+package iomux
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// demux buffers chunks read from a Mux by tag, so that each tag can be consumed independently.
+type demux[T comparable] struct {
+	mutex   sync.Mutex
+	pending map[T][][]byte
+	pumping bool
+	changed chan struct{}
+}
+
+type tagReader[T comparable] struct {
+	ctx   context.Context
+	tag   T
+	demux *demux[T]
+	read  func(context.Context) ([]byte, T, error)
+	// boundary is set when the last Read returned the end of a chunk
+	boundary bool
+}
+
+// Reader Create an io.Reader of the data tagged with tag T, until ctx is done. Data read for other tags is buffered
+// for their own readers, so readers for different tags may be used concurrently, but must not be mixed with Read.
+// Data for tags without a reader is buffered until the Mux is closed.
+func (mux *Mux[T]) Reader(ctx context.Context, tag T) io.Reader {
+	mux.demuxonce.Do(func() {
+		mux.demux = &demux[T]{
+			pending: make(map[T][][]byte),
+			changed: make(chan struct{}),
+		}
+	})
+	return &tagReader[T]{
+		ctx:   ctx,
+		tag:   tag,
+		demux: mux.demux,
+		read:  mux.Read,
+	}
+}
+
+func (r *tagReader[T]) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	d := r.demux
+	for {
+		d.mutex.Lock()
+		if chunks := d.pending[r.tag]; len(chunks) > 0 {
+			// return at most one chunk, so callers can tell where chunks end
+			n := copy(p, chunks[0])
+			r.boundary = n == len(chunks[0])
+			if !r.boundary {
+				chunks[0] = chunks[0][n:]
+			} else if len(chunks) == 1 {
+				delete(d.pending, r.tag)
+			} else {
+				d.pending[r.tag] = chunks[1:]
+			}
+			d.mutex.Unlock()
+			return n, nil
+		}
+		if d.pumping {
+			// another reader is reading the mux, wait for it to hand over whatever it reads
+			changed := d.changed
+			cancelled := r.ctx.Err() != nil
+			d.mutex.Unlock()
+			if cancelled {
+				// data may still be left to drain, which the other reader hands over, or leaves to be read once it
+				// notices its own ctx is done
+				select {
+				case <-changed:
+					continue
+				case <-time.After(deadlineDuration):
+					return 0, io.EOF
+				}
+			}
+			select {
+			case <-changed:
+			case <-r.ctx.Done():
+			}
+			continue
+		}
+		d.pumping = true
+		d.mutex.Unlock()
+
+		data, tag, err := r.read(r.ctx)
+
+		d.mutex.Lock()
+		d.pumping = false
+		if err == nil && len(data) > 0 {
+			d.pending[tag] = append(d.pending[tag], data)
+		}
+		close(d.changed)
+		d.changed = make(chan struct{})
+		d.mutex.Unlock()
+		if err != nil {
+			return 0, err
+		}
+	}
+}
@@ -0,0 +1,83 @@
+//go:build !windows
+
+package iomux
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMuxReader(t *testing.T) {
+	for _, network := range networks {
+		t.Run(network, func(t *testing.T) {
+			mux := &Mux[string]{network: network}
+			t.Cleanup(func() {
+				mux.Close()
+			})
+			taga, err := mux.Tag("a")
+			if err != nil {
+				skipIfProtocolNotSupported(t, err)
+				assert.Nil(t, err)
+			}
+			tagb, _ := mux.Tag("b")
+
+			ctx, cancelFn := context.WithCancel(context.Background())
+			readera := mux.Reader(ctx, "a")
+			readerb := mux.Reader(ctx, "b")
+
+			io.WriteString(tagb, "hello tagb")
+			io.WriteString(taga, "hello taga")
+			buf := make([]byte, 10)
+			_, err = io.ReadFull(readera, buf)
+			assert.Nil(t, err)
+			assert.Equal(t, "hello taga", string(buf))
+			_, err = io.ReadFull(readerb, buf)
+			assert.Nil(t, err)
+			assert.Equal(t, "hello tagb", string(buf))
+
+			cancelFn()
+			n, err := readera.Read(buf)
+			assert.Equal(t, 0, n)
+			assert.Equal(t, io.EOF, err)
+		})
+	}
+}
+
+func TestMuxReaderConcurrent(t *testing.T) {
+	mux := &Mux[string]{}
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	taga, err := mux.Tag("a")
+	assert.Nil(t, err)
+	tagb, _ := mux.Tag("b")
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	results := make(map[string]string)
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	for _, tag := range []string{"a", "b"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			data, err := io.ReadAll(mux.Reader(ctx, tag))
+			assert.Nil(t, err)
+			mutex.Lock()
+			results[tag] = string(data)
+			mutex.Unlock()
+		}()
+	}
+	for i := 0; i < 3; i++ {
+		io.WriteString(taga, "a")
+		io.WriteString(tagb, "bb")
+	}
+	cancelFn()
+	wg.Wait()
+
+	assert.Equal(t, "aaa", results["a"])
+	assert.Equal(t, "bbbbbb", results["b"])
+}
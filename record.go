@@ -0,0 +1,157 @@
+package iomux
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"iter"
+	"sync"
+	"time"
+)
+
+// gzipMagic starts every gzip stream.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// Recorder persists tagged data along with the time it was recorded, to be played back by a Replayer. Recordings are
+// JSON Lines, see JSONLEncoder. A Recorder is also a Sink, see WithSink.
+type Recorder[T comparable] struct {
+	mutex sync.Mutex
+	enc   *JSONLEncoder[T]
+	seq   uint64
+	gz    *gzip.Writer
+}
+
+// Replayer plays back data persisted by a Recorder, through the same read API as Mux.
+type Replayer[T comparable] struct {
+	dec      *JSONLDecoder[T]
+	realtime bool
+	last     time.Time
+}
+
+// NewRecorder Create a Recorder writing to w.
+func NewRecorder[T comparable](w io.Writer) *Recorder[T] {
+	return &Recorder[T]{enc: NewJSONLEncoder[T](w)}
+}
+
+// NewGzipRecorder Create a Recorder writing to w compressed with gzip, which Close must be called to finish. Replayers
+// decompress gzip recordings transparently.
+func NewGzipRecorder[T comparable](w io.Writer) *Recorder[T] {
+	gz := gzip.NewWriter(w)
+	return &Recorder[T]{enc: NewJSONLEncoder[T](gz), gz: gz}
+}
+
+// Record Persist data tagged with tag, timestamped with the current time.
+func (r *Recorder[T]) Record(tag T, data []byte) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.seq++
+	return r.enc.Encode(&TaggedData[T]{
+		Tag:  tag,
+		Data: data,
+		Seq:  r.seq,
+		Time: time.Now(),
+	})
+}
+
+// Write Persist td as it is, keeping its sequence number and time.
+func (r *Recorder[T]) Write(td *TaggedData[T]) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.enc.Encode(td)
+}
+
+// Close Flush the recording if it is compressed. The underlying writer isn't closed.
+func (r *Recorder[T]) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.gz == nil {
+		return nil
+	}
+	return r.gz.Close()
+}
+
+// RecordStream Persist each chunk received from ch, such as one returned by Mux.Stream, until it is closed.
+func (r *Recorder[T]) RecordStream(ch <-chan *TaggedData[T]) error {
+	for td := range ch {
+		if err := r.Record(td.Tag, td.Data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewReplayer Create a Replayer reading a recording from rd. When realtime is set, reads are delayed to reproduce
+// the original time between chunks, otherwise chunks are replayed as fast as they are read. Recordings compressed with
+// gzip are decompressed transparently.
+func NewReplayer[T comparable](rd io.Reader, realtime bool) *Replayer[T] {
+	return &Replayer[T]{
+		dec:      NewJSONLDecoder[T](Decompress(rd)),
+		realtime: realtime,
+	}
+}
+
+// Read the next chunk of the recording. Returns io.EOF error at the end of the recording, or when ctx is done.
+func (r *Replayer[T]) Read(ctx context.Context) ([]byte, T, error) {
+	var zeroTag T
+	if ctx.Err() != nil {
+		return nil, zeroTag, io.EOF
+	}
+	rec, err := r.dec.Decode()
+	if err != nil {
+		return nil, zeroTag, err
+	}
+	if r.realtime && !r.last.IsZero() {
+		timer := time.NewTimer(rec.Time.Sub(r.last))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, zeroTag, io.EOF
+		}
+	}
+	r.last = rec.Time
+	return rec.Data, rec.Tag, nil
+}
+
+// ReadUntil Read the recording until its end or ctx is done, returning the read data.
+func (r *Replayer[T]) ReadUntil(ctx context.Context) ([]*TaggedData[T], error) {
+	return readUntil(ctx, r.Read, collect[T]{merge: true})
+}
+
+// All Read the recording until its end or ctx is done, yielding each chunk and its tag, for use with range.
+func (r *Replayer[T]) All(ctx context.Context) iter.Seq2[T, []byte] {
+	return all(ctx, r.Read)
+}
+
+// Stream Read the recording in the background, see Mux.Stream.
+func (r *Replayer[T]) Stream() (<-chan *TaggedData[T], func() error) {
+	return stream(r.Read)
+}
+
+// Decompress Create a reader of rd, decompressing it if it starts as a gzip stream, as a Replayer does, for reading
+// recordings with a JSONLDecoder.
+func Decompress(rd io.Reader) io.Reader {
+	return &decompressReader{rd: bufio.NewReader(rd)}
+}
+
+// decompressReader reads rd, decompressing it if it starts as a gzip stream.
+type decompressReader struct {
+	rd *bufio.Reader
+	r  io.Reader
+}
+
+func (d *decompressReader) Read(p []byte) (int, error) {
+	if d.r == nil {
+		d.r = d.rd
+		if magic, _ := d.rd.Peek(len(gzipMagic)); bytes.Equal(magic, gzipMagic) {
+			gz, err := gzip.NewReader(d.rd)
+			if err != nil {
+				return 0, err
+			}
+			d.r = gz
+		}
+	}
+	return d.r.Read(p)
+}
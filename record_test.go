@@ -0,0 +1,78 @@
+package iomux
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordReplay(t *testing.T) {
+	var buf bytes.Buffer
+	recorder := NewRecorder[string](&buf)
+	assert.Nil(t, recorder.Record("a", []byte("out1")))
+	assert.Nil(t, recorder.Record("b", []byte("err1")))
+	assert.Nil(t, recorder.Record("b", []byte("err2")))
+
+	replayer := NewReplayer[string](&buf, false)
+	td, err := replayer.ReadUntil(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(td))
+	assert.Equal(t, "a", td[0].Tag)
+	assert.Equal(t, "out1", string(td[0].Data))
+	assert.Equal(t, "b", td[1].Tag)
+	assert.Equal(t, "err1err2", string(td[1].Data))
+}
+
+func TestRecordStream(t *testing.T) {
+	ch := make(chan *TaggedData[int], 2)
+	ch <- &TaggedData[int]{Tag: 1, Data: []byte("one")}
+	ch <- &TaggedData[int]{Tag: 2, Data: []byte("two")}
+	close(ch)
+
+	var buf bytes.Buffer
+	assert.Nil(t, NewRecorder[int](&buf).RecordStream(ch))
+
+	var tags []int
+	for tag := range NewReplayer[int](&buf, false).All(context.Background()) {
+		tags = append(tags, tag)
+	}
+	assert.Equal(t, []int{1, 2}, tags)
+}
+
+func TestReplayRealtime(t *testing.T) {
+	var buf bytes.Buffer
+	recorder := NewRecorder[string](&buf)
+	assert.Nil(t, recorder.Record("a", []byte("out1")))
+	time.Sleep(50 * time.Millisecond)
+	assert.Nil(t, recorder.Record("a", []byte("out2")))
+
+	start := time.Now()
+	ch, stop := NewReplayer[string](&buf, true).Stream()
+	var data []string
+	for td := range ch {
+		data = append(data, string(td.Data))
+	}
+	assert.Nil(t, stop())
+	assert.Equal(t, []string{"out1", "out2"}, data)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+}
+
+func TestGzipRecordReplay(t *testing.T) {
+	var buf bytes.Buffer
+	recorder := NewGzipRecorder[string](&buf)
+	assert.Nil(t, recorder.Record("a", bytes.Repeat([]byte("out"), 1000)))
+	assert.Nil(t, recorder.Write(&TaggedData[string]{Tag: "b", Data: []byte("err"), Seq: 7}))
+	assert.Nil(t, recorder.Close())
+	assert.Equal(t, gzipMagic, buf.Bytes()[:2])
+	assert.Less(t, buf.Len(), 1000)
+
+	replayer := NewReplayer[string](&buf, false)
+	td, err := replayer.ReadUntil(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(td))
+	assert.Equal(t, 3000, len(td[0].Data))
+	assert.Equal(t, "err", string(td[1].Data))
+}
@@ -0,0 +1,159 @@
+// Package remote lets writers on other hosts, in containers or in virtual machines send tagged data to a Mux over a
+// network connection, authenticated by a shared token.
+package remote
+
+import (
+	"bufio"
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/netflix/go-iomux"
+)
+
+// ErrNoToken is returned by Serve when the token is empty, which would let any client connect. Use ServeUnauthenticated
+// to accept writers without a token.
+var ErrNoToken = errors.New("remote: a token is required, see ServeUnauthenticated")
+
+// handshakeTimeout limits how long a remote writer has to identify itself, see Serve.
+const handshakeTimeout = 10 * time.Second
+
+// maxHelloSize limits the size of the line a remote writer identifies itself with, so a client that hasn't yet
+// presented a token can't make the server buffer more.
+const maxHelloSize = 4096
+
+// remoteHello is sent by a remote writer to identify itself, as a line of JSON.
+type remoteHello[T comparable] struct {
+	Token string `json:"token"`
+	Tag   T      `json:"tag"`
+}
+
+// Serve Accept writers connecting to l with DialTag, such as processes in containers or on other hosts, until l is
+// closed. Each writer names its tag and presents a token, which must match token, then what it sends is copied to a
+// file created by mux.Tag for its tag, until it disconnects. Writers are refused tags that allow returns false for, or
+// may use any tag if allow is nil. Tags are encoded using encoding/json, so T must be supported by it. Use a listener
+// from tls.Listen, or wrap l with tls.NewListener, to encrypt connections. Fails with ErrNoToken if token is empty.
+func Serve[T comparable](mux *iomux.Mux[T], l net.Listener, token string, allow func(tag T) bool) error {
+	if token == "" {
+		return ErrNoToken
+	}
+	return serve(mux, l, token, allow)
+}
+
+// ServeUnauthenticated Accept writers connecting to l like Serve, but without checking their token, for listeners that
+// only trusted processes can reach.
+func ServeUnauthenticated[T comparable](mux *iomux.Mux[T], l net.Listener, allow func(tag T) bool) error {
+	return serve(mux, l, "", allow)
+}
+
+// serve accepts writers until l is closed, checking their token unless it is empty.
+func serve[T comparable](mux *iomux.Mux[T], l net.Listener, token string, allow func(tag T) bool) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go serveConn(mux, conn, token, allow)
+	}
+}
+
+// serveConn reads the handshake of a remote writer from conn and copies what it sends to a tagged file.
+func serveConn[T comparable](mux *iomux.Mux[T], conn net.Conn, token string, allow func(tag T) bool) {
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(handshakeTimeout))
+	rd := bufio.NewReaderSize(conn, maxHelloSize)
+	line, err := rd.ReadSlice('\n')
+	if errors.Is(err, bufio.ErrBufferFull) {
+		io.WriteString(conn, "error: handshake too long\n")
+		return
+	}
+	if err != nil {
+		return
+	}
+	var hello remoteHello[T]
+	if err := json.Unmarshal(line, &hello); err != nil {
+		fmt.Fprintf(conn, "error: %v\n", err)
+		return
+	}
+	if token != "" && subtle.ConstantTimeCompare([]byte(hello.Token), []byte(token)) != 1 {
+		io.WriteString(conn, "error: invalid token\n")
+		return
+	}
+	if allow != nil && !allow(hello.Tag) {
+		io.WriteString(conn, "error: tag not allowed\n")
+		return
+	}
+	w, err := mux.Tag(hello.Tag)
+	if err != nil {
+		fmt.Fprintf(conn, "error: %v\n", err)
+		return
+	}
+	defer w.Close()
+	_ = conn.SetReadDeadline(time.Time{})
+	if _, err := io.WriteString(conn, "ok\n"); err != nil {
+		return
+	}
+	_, _ = io.Copy(w, rd)
+}
+
+// DialTag Connect to a Mux serving addr on network, see Serve, as a writer for tag, presenting token. The connection
+// is encrypted with TLS when tlsConfig isn't nil. Returns the connection to write to, which should be closed once
+// writing is finished.
+func DialTag[T comparable](ctx context.Context, network, addr string, tlsConfig *tls.Config, tag T, token string) (net.Conn, error) {
+	var conn net.Conn
+	var err error
+	if tlsConfig != nil {
+		d := tls.Dialer{Config: tlsConfig}
+		conn, err = d.DialContext(ctx, network, addr)
+	} else {
+		var d net.Dialer
+		conn, err = d.DialContext(ctx, network, addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return DialTagConn(ctx, conn, tag, token)
+}
+
+// DialTagConn Identify conn to a Mux serving it, see Serve, as a writer for tag, presenting token, for connections made
+// other than by DialTag, such as with iomux.DialVsock. conn is closed if it fails.
+func DialTagConn[T comparable](ctx context.Context, conn net.Conn, tag T, token string) (net.Conn, error) {
+	hello, err := json.Marshal(&remoteHello[T]{Token: token, Tag: tag})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	if _, err := conn.Write(append(hello, '\n')); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	// read the reply a byte at a time, so nothing after it is buffered
+	var reply []byte
+	b := make([]byte, 1)
+	for len(reply) == 0 || reply[len(reply)-1] != '\n' {
+		if _, err := conn.Read(b); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		reply = append(reply, b[0])
+	}
+	_ = conn.SetDeadline(time.Time{})
+	if msg := strings.TrimSpace(string(reply)); msg != "ok" {
+		conn.Close()
+		return nil, fmt.Errorf("remote: %s", msg)
+	}
+	return conn, nil
+}
@@ -0,0 +1,140 @@
+//go:build !windows
+
+package remote
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/netflix/go-iomux"
+	"github.com/stretchr/testify/assert"
+)
+
+// sleepDuration is long enough for data sent by a remote writer to be copied to the mux.
+const sleepDuration = 10 * time.Millisecond
+
+// serveTest serves mux on a local TCP listener, wrapped by TLS when config isn't nil, returning its address. Writers
+// aren't authenticated when token is empty, and otherwise can use any tag but "forbidden".
+func serveTest(t *testing.T, mux *iomux.Mux[string], config *tls.Config, token string) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config != nil {
+		l = tls.NewListener(l, config)
+	}
+	served := make(chan error)
+	go func() {
+		if token == "" {
+			served <- ServeUnauthenticated(mux, l, nil)
+			return
+		}
+		served <- Serve(mux, l, token, func(tag string) bool {
+			return tag != "forbidden"
+		})
+	}()
+	t.Cleanup(func() {
+		l.Close()
+		assert.Nil(t, <-served)
+	})
+	return l.Addr().String()
+}
+
+func TestServe(t *testing.T) {
+	mux := iomux.NewMux[string]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	addr := serveTest(t, mux, nil, "secret")
+
+	_, err := DialTag(context.Background(), "tcp", addr, nil, "a", "wrong")
+	assert.EqualError(t, err, "remote: error: invalid token")
+	_, err = DialTag(context.Background(), "tcp", addr, nil, "forbidden", "secret")
+	assert.EqualError(t, err, "remote: error: tag not allowed")
+
+	conna, err := DialTag(context.Background(), "tcp", addr, nil, "a", "secret")
+	assert.Nil(t, err)
+	connb, err := DialTag(context.Background(), "tcp", addr, nil, "b", "secret")
+	assert.Nil(t, err)
+	td, err := mux.ReadWhile(func() error {
+		conna.Write([]byte("from a"))
+		time.Sleep(sleepDuration)
+		connb.Write([]byte("from b"))
+		time.Sleep(sleepDuration)
+		return nil
+	})
+	conna.Close()
+	connb.Close()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(td))
+	assert.Equal(t, "a", td[0].Tag)
+	assert.Equal(t, "from a", string(td[0].Data))
+	assert.Equal(t, "b", td[1].Tag)
+	assert.Equal(t, "from b", string(td[1].Data))
+}
+
+func TestServeNoToken(t *testing.T) {
+	mux := iomux.NewMux[string]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	assert.Equal(t, ErrNoToken, Serve(mux, l, "", nil))
+}
+
+func TestServeHandshakeTooLong(t *testing.T) {
+	mux := iomux.NewMux[string]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	addr := serveTest(t, mux, nil, "secret")
+	_, err := DialTag(context.Background(), "tcp", addr, nil, strings.Repeat("a", maxHelloSize), "secret")
+	assert.EqualError(t, err, "remote: error: handshake too long")
+}
+
+func TestServeTLS(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Nil(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.Nil(t, err)
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+
+	mux := iomux.NewMux[string]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	addr := serveTest(t, mux, &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}, "")
+
+	conn, err := DialTag(context.Background(), "tcp", addr, &tls.Config{RootCAs: roots}, "a", "")
+	assert.Nil(t, err)
+	td, err := mux.ReadWhile(func() error {
+		conn.Write([]byte("encrypted"))
+		time.Sleep(sleepDuration)
+		return nil
+	})
+	conn.Close()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(td))
+	assert.Equal(t, "encrypted", string(td[0].Data))
+}
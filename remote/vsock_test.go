@@ -0,0 +1,49 @@
+//go:build linux
+
+package remote
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/netflix/go-iomux"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sys/unix"
+)
+
+func TestServeVsock(t *testing.T) {
+	l, err := iomux.ListenVsock(unix.VMADDR_PORT_ANY)
+	if err != nil {
+		t.Skipf("vsock not supported: %v", err)
+	}
+	mux := iomux.NewMux[string]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	served := make(chan error)
+	go func() {
+		served <- ServeUnauthenticated(mux, l, nil)
+	}()
+	t.Cleanup(func() {
+		l.Close()
+		assert.Nil(t, <-served)
+	})
+
+	conn, err := iomux.DialVsock(unix.VMADDR_CID_LOCAL, l.Addr().(*iomux.VsockAddr).Port)
+	if err != nil {
+		t.Skipf("vsock loopback not supported: %v", err)
+	}
+	conn, err = DialTagConn(context.Background(), conn, "guest", "")
+	assert.Nil(t, err)
+	td, err := mux.ReadWhile(func() error {
+		conn.Write([]byte("from the guest"))
+		time.Sleep(sleepDuration)
+		return nil
+	})
+	conn.Close()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(td))
+	assert.Equal(t, "guest", td[0].Tag)
+	assert.Equal(t, "from the guest", string(td[0].Data))
+}
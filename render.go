@@ -0,0 +1,92 @@
+package iomux
+
+import (
+	"bytes"
+	"io"
+)
+
+// RenderOptions configures Render.
+type RenderOptions[T comparable] struct {
+	// Prefix returns the text written at the start of each line of tag, such as "[stderr] ", if not nil.
+	Prefix func(tag T) string
+	// Color returns the ANSI color each line of tag is written in, such as one assigned by AssignColors, if not nil.
+	Color func(tag T) string
+}
+
+// Render Render td as a single output in the order it was captured, showing exactly what a terminal would have shown
+// when no options are set. With a prefix or color, each line is attributed to its tag, and a line left partial by one
+// tag is ended before the next tag's output.
+func Render[T comparable](td []*TaggedData[T], opts RenderOptions[T]) []byte {
+	var buf bytes.Buffer
+	r := NewRenderer(&buf, opts)
+	for _, d := range td {
+		_ = r.Write(d)
+	}
+	return buf.Bytes()
+}
+
+// Renderer renders chunks to a writer as they are captured or replayed, like Render.
+type Renderer[T comparable] struct {
+	w         io.Writer
+	opts      RenderOptions[T]
+	buf       []byte
+	lineStart bool
+	lastTag   T
+}
+
+// NewRenderer Create a Renderer writing to w, configured by opts.
+func NewRenderer[T comparable](w io.Writer, opts RenderOptions[T]) *Renderer[T] {
+	return &Renderer[T]{w: w, opts: opts, lineStart: true}
+}
+
+// Write Render td, continuing from the chunks already rendered.
+func (r *Renderer[T]) Write(td *TaggedData[T]) error {
+	if r.opts.Prefix == nil && r.opts.Color == nil {
+		_, err := r.w.Write(td.Data)
+		return err
+	}
+	buf := r.buf[:0]
+	if !r.lineStart && td.Tag != r.lastTag {
+		buf = append(buf, '\n')
+		r.lineStart = true
+	}
+	r.lastTag = td.Tag
+	for data := td.Data; len(data) > 0; {
+		line := data
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			line = data[:i+1]
+		}
+		data = data[len(line):]
+		text, newline := bytes.CutSuffix(line, []byte{'\n'})
+		if r.opts.Color != nil {
+			buf = append(buf, r.opts.Color(td.Tag)...)
+		}
+		if r.lineStart && r.opts.Prefix != nil {
+			buf = append(buf, r.opts.Prefix(td.Tag)...)
+		}
+		buf = append(buf, text...)
+		if r.opts.Color != nil {
+			buf = append(buf, colorReset...)
+		}
+		if newline {
+			buf = append(buf, '\n')
+		}
+		r.lineStart = newline
+	}
+	r.buf = buf
+	_, err := r.w.Write(buf)
+	return err
+}
+
+// AssignColors Create a function assigning each tag a color from Colors, in the order tags are first seen.
+func AssignColors[T comparable]() func(tag T) string {
+	assigned := make(map[T]string)
+	return func(tag T) string {
+		c, ok := assigned[tag]
+		if !ok {
+			c = Colors[len(assigned)%len(Colors)]
+			assigned[tag] = c
+		}
+		return c
+	}
+}
@@ -0,0 +1,43 @@
+package iomux
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var renderData = []*TaggedData[StdStream]{
+	{Tag: Stdout, Data: []byte("Password: ")},
+	{Tag: Stderr, Data: []byte("warning: echo on\n")},
+	{Tag: Stdout, Data: []byte("ok\ndone\n")},
+}
+
+func TestRender(t *testing.T) {
+	assert.Equal(t, "Password: warning: echo on\nok\ndone\n", string(Render(renderData, RenderOptions[StdStream]{})))
+	assert.Empty(t, Render[StdStream](nil, RenderOptions[StdStream]{}))
+}
+
+func TestRenderPrefix(t *testing.T) {
+	got := Render(renderData, RenderOptions[StdStream]{Prefix: func(tag StdStream) string {
+		return "[" + tag.String() + "] "
+	}})
+	assert.Equal(t, "[stdout] Password: \n[stderr] warning: echo on\n[stdout] ok\n[stdout] done\n", string(got))
+}
+
+func TestRenderColor(t *testing.T) {
+	got := Render(renderData, RenderOptions[StdStream]{Color: AssignColors[StdStream]()})
+	assert.Equal(t, "\x1b[36mPassword: \x1b[0m\n\x1b[33mwarning: echo on\x1b[0m\n\x1b[36mok\x1b[0m\n\x1b[36mdone\x1b[0m\n",
+		string(got))
+}
+
+func TestRenderer(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewRenderer(&buf, RenderOptions[StdStream]{Prefix: func(tag StdStream) string {
+		return tag.String() + ": "
+	}})
+	for _, td := range renderData {
+		assert.Nil(t, r.Write(td))
+	}
+	assert.Equal(t, "stdout: Password: \nstderr: warning: echo on\nstdout: ok\nstdout: done\n", buf.String())
+}
@@ -0,0 +1,24 @@
+package iomux
+
+import "slices"
+
+// dropOldestTag removes n bytes from the start of the data for tag in td, reporting them to dropped if not nil.
+func dropOldestTag[T comparable](td []*TaggedData[T], tag T, n int, dropped func(tag T, n int)) []*TaggedData[T] {
+	for _, d := range td {
+		if n == 0 {
+			break
+		}
+		if d.Tag != tag {
+			continue
+		}
+		m := min(n, len(d.Data))
+		d.Data = d.Data[m:]
+		n -= m
+		if dropped != nil {
+			dropped(tag, m)
+		}
+	}
+	return slices.DeleteFunc(td, func(d *TaggedData[T]) bool {
+		return d.Tag == tag && len(d.Data) == 0
+	})
+}
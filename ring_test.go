@@ -0,0 +1,51 @@
+//go:build !windows
+
+package iomux
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDropOldestTag(t *testing.T) {
+	td := []*TaggedData[string]{
+		{Tag: "a", Data: []byte("aa")},
+		{Tag: "b", Data: []byte("bbb")},
+		{Tag: "a", Data: []byte("aaa")},
+	}
+	dropped := 0
+	td = dropOldestTag(td, "a", 3, func(tag string, n int) {
+		assert.Equal(t, "a", tag)
+		dropped += n
+	})
+	assert.Equal(t, 3, dropped)
+	assert.Equal(t, 2, len(td))
+	assert.Equal(t, "bbb", string(td[0].Data))
+	assert.Equal(t, "aa", string(td[1].Data))
+}
+
+func TestMuxRingBuffer(t *testing.T) {
+	mux := NewMuxUnixGram[string](WithRingBuffer[string](6))
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	taga, _ := mux.Tag("a")
+	tagb, _ := mux.Tag("b")
+
+	td, err := mux.ReadWhile(func() error {
+		io.WriteString(taga, "line 1\n")
+		io.WriteString(tagb, "b\n")
+		io.WriteString(taga, "line 2\n")
+		io.WriteString(taga, "line 3\n")
+		return nil
+	})
+	assert.Nil(t, err)
+	received := make(map[string]string)
+	for _, d := range td {
+		received[d.Tag] += string(d.Data)
+	}
+	assert.Equal(t, map[string]string{"a": "ine 3\n", "b": "b\n"}, received)
+	assert.Equal(t, uint64(15), mux.Stats().Dropped)
+}
@@ -0,0 +1,102 @@
+package iomux
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// RunRecord is the record of a command run by Run, holding the context needed to audit or reproduce the run along with
+// its output. It is encoded as a single JSON document, with the capture in the schema of JSONLEncoder, which is left out
+// when there is none.
+type RunRecord struct {
+	Args     []string
+	Dir      string
+	Env      []string
+	Start    time.Time
+	End      time.Time
+	ExitCode int
+	Capture  []*TaggedData[StdStream]
+}
+
+// runRecordJSON is the schema of a RunRecord.
+type runRecordJSON struct {
+	Args     []string                 `json:"args"`
+	Dir      string                   `json:"dir,omitempty"`
+	Env      []string                 `json:"env,omitempty"`
+	Start    time.Time                `json:"start"`
+	End      time.Time                `json:"end"`
+	ExitCode int                      `json:"exit_code"`
+	Capture  []jsonlRecord[StdStream] `json:"capture,omitempty"`
+}
+
+// Record Create the record of the run.
+func (r Result) Record() *RunRecord {
+	return &RunRecord{
+		Args:     r.Args,
+		Dir:      r.Dir,
+		Env:      r.Env,
+		Start:    r.Start,
+		End:      r.Start.Add(r.Duration),
+		ExitCode: r.ExitCode,
+		Capture:  r.Data,
+	}
+}
+
+// MarshalJSON Encode the record as a JSON document.
+func (r *RunRecord) MarshalJSON() ([]byte, error) {
+	var capture []jsonlRecord[StdStream]
+	for _, td := range r.Capture {
+		capture = append(capture, jsonlRecord[StdStream]{Seq: td.Seq, Time: td.Time, Tag: td.Tag, Data: td.Data})
+	}
+	return json.Marshal(&runRecordJSON{
+		Args:     r.Args,
+		Dir:      r.Dir,
+		Env:      r.Env,
+		Start:    r.Start,
+		End:      r.End,
+		ExitCode: r.ExitCode,
+		Capture:  capture,
+	})
+}
+
+// UnmarshalJSON Decode a record encoded by MarshalJSON.
+func (r *RunRecord) UnmarshalJSON(data []byte) error {
+	var rec runRecordJSON
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return err
+	}
+	capture := make([]*TaggedData[StdStream], len(rec.Capture))
+	for i, c := range rec.Capture {
+		capture[i] = &TaggedData[StdStream]{Seq: c.Seq, Time: c.Time, Tag: c.Tag, Data: c.Data}
+	}
+	*r = RunRecord{
+		Args:     rec.Args,
+		Dir:      rec.Dir,
+		Env:      rec.Env,
+		Start:    rec.Start,
+		End:      rec.End,
+		ExitCode: rec.ExitCode,
+		Capture:  capture,
+	}
+	return nil
+}
+
+// envChanges returns the variables of env that aren't in the environment of this process, or nil if env is nil as the
+// command inherits the environment unchanged.
+func envChanges(env []string) []string {
+	if env == nil {
+		return nil
+	}
+	inherited := make(map[string]bool)
+	for _, kv := range os.Environ() {
+		inherited[kv] = true
+	}
+	var changes []string
+	for _, kv := range env {
+		if !inherited[kv] {
+			changes = append(changes, kv)
+		}
+	}
+	return changes
+}
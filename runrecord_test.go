@@ -0,0 +1,59 @@
+//go:build !windows
+
+package iomux
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunRecord(t *testing.T) {
+	dir := t.TempDir()
+	cmd := exec.Command("sh", "-c", "echo $GREETING; echo failed >&2; exit 3")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GREETING=hello")
+	result, err := Run(context.Background(), cmd)
+	assert.NotNil(t, err)
+
+	record := result.Record()
+	assert.Equal(t, []string{"sh", "-c", "echo $GREETING; echo failed >&2; exit 3"}, record.Args)
+	assert.Equal(t, dir, record.Dir)
+	assert.Equal(t, []string{"GREETING=hello"}, record.Env)
+	assert.Equal(t, 3, record.ExitCode)
+	assert.False(t, record.Start.IsZero())
+	assert.Equal(t, result.Duration, record.End.Sub(record.Start))
+	assert.Equal(t, result.Data, record.Capture)
+
+	b, err := json.Marshal(record)
+	assert.Nil(t, err)
+	var doc map[string]any
+	assert.Nil(t, json.Unmarshal(b, &doc))
+	assert.Equal(t, float64(3), doc["exit_code"])
+	assert.Len(t, doc["capture"], 2)
+
+	var decoded RunRecord
+	assert.Nil(t, json.Unmarshal(b, &decoded))
+	assert.Equal(t, record.Args, decoded.Args)
+	assert.Equal(t, record.Env, decoded.Env)
+	assert.True(t, record.Start.Equal(decoded.Start))
+	assert.True(t, record.End.Equal(decoded.End))
+	assert.Len(t, decoded.Capture, 2)
+	for i, td := range decoded.Capture {
+		assert.Equal(t, record.Capture[i].Tag, td.Tag)
+		assert.Equal(t, record.Capture[i].Seq, td.Seq)
+		assert.Equal(t, record.Capture[i].Data, td.Data)
+	}
+}
+
+func TestRunRecordInheritedEnv(t *testing.T) {
+	result, err := Run(context.Background(), exec.Command("true"))
+	assert.Nil(t, err)
+	assert.Nil(t, result.Record().Env)
+	assert.Equal(t, []string(nil), envChanges(nil))
+	assert.Equal(t, []string{"IOMUX_TEST_UNSET=1"}, envChanges(append(os.Environ(), "IOMUX_TEST_UNSET=1")))
+}
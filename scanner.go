@@ -0,0 +1,28 @@
+package iomux
+
+import (
+	"bufio"
+	"context"
+)
+
+// Scanner Create a bufio.Scanner of the lines of data tagged with tag T, until ctx is done, built on Reader so the
+// same restrictions apply. Tokens never span chunks: the end of a chunk also ends a line, so a prompt written without
+// a newline is scanned as soon as it is read. Lines written across several writes are therefore scanned in pieces,
+// unless the Mux was created WithLineSplitting, which reassembles them before they are read.
+func (mux *Mux[T]) Scanner(ctx context.Context, tag T) *bufio.Scanner {
+	r := mux.Reader(ctx, tag).(*tagReader[T])
+	s := bufio.NewScanner(r)
+	s.Split(r.scanLines)
+	return s
+}
+
+// scanLines splits lines like bufio.ScanLines, also ending a line where the last chunk read ended.
+func (r *tagReader[T]) scanLines(data []byte, atEOF bool) (int, []byte, error) {
+	if advance, token, err := bufio.ScanLines(data, atEOF); advance > 0 || err != nil {
+		return advance, token, err
+	}
+	if r.boundary && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
@@ -0,0 +1,58 @@
+//go:build !windows
+
+package iomux
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMuxScanner(t *testing.T) {
+	mux := NewMux[string]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	taga, _ := mux.Tag("a")
+	tagb, _ := mux.Tag("b")
+
+	io.WriteString(taga, "one\ntwo\n")
+	io.WriteString(tagb, "ignored\n")
+	io.WriteString(taga, "Password: ")
+	io.WriteString(taga, "three\r\n")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	s := mux.Scanner(ctx, "a")
+	var lines []string
+	for s.Scan() {
+		lines = append(lines, s.Text())
+	}
+	assert.Nil(t, s.Err())
+	assert.Equal(t, []string{"one", "two", "Password: ", "three"}, lines)
+}
+
+func TestMuxScannerLineSplitting(t *testing.T) {
+	mux := NewMux[string](WithLineSplitting[string]())
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	taga, _ := mux.Tag("a")
+
+	io.WriteString(taga, "one\ntw")
+	io.WriteString(taga, "o\nthree")
+	taga.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	s := mux.Scanner(ctx, "a")
+	var lines []string
+	for s.Scan() {
+		lines = append(lines, s.Text())
+	}
+	assert.Nil(t, s.Err())
+	assert.Equal(t, []string{"one", "two", "three"}, lines)
+}
@@ -0,0 +1,318 @@
+package iomux
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"iter"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+const (
+	// shmDefaultSize is the size of the data area of each ring when none is given.
+	shmDefaultSize = 1 << 20
+	// shmHeaderSize is the size of the ring header, keeping the writer and reader positions on separate cache lines.
+	shmHeaderSize = 128
+	// shmBackoff is how long a writer sleeps while the ring is full.
+	shmBackoff = 50 * time.Microsecond
+)
+
+// Offsets of the fields of the ring header. head is only written by writers, tail only by the reader.
+const (
+	shmHead         = 0
+	shmTail         = 64
+	shmWaiting      = 72
+	shmReaderClosed = 76
+)
+
+// ShmMux is an experimental transport for very high-throughput capture on a single host, where writers append to a
+// ring buffer in shared memory rather than making a syscall per chunk. The reader is only woken through an eventfd
+// when it is waiting for data, so a busy writer makes no syscalls at all. Writers of the same tag must not write
+// concurrently, and a full ring makes writers wait for the reader rather than dropping data. Only supported on Linux.
+type ShmMux[T comparable] struct {
+	mutex  sync.Mutex
+	size   int
+	event  *shmEvent
+	rings  []*shmReader[T]
+	next   int
+	closed bool
+}
+
+// shmReader is the reader end of a ring, tagged with the tag of its writers.
+type shmReader[T comparable] struct {
+	ring *shmRing
+	tag  T
+}
+
+// ShmWriter writes to a ring of a ShmMux.
+type ShmWriter struct {
+	ring      *shmRing
+	file      *os.File
+	event     *shmEvent
+	ownsEvent bool
+	closed    bool
+}
+
+// shmRing is a mapping of a ring, a header followed by a power of two sized data area holding records of a uint32
+// length and the data.
+type shmRing struct {
+	mem  []byte
+	data []byte
+	mask uint64
+}
+
+// NewShmMux Create a new ShmMux, with rings of size bytes for each tag, rounded up to a power of two, or 1MiB when size
+// is 0. Chunks larger than half of size are split.
+func NewShmMux[T comparable](size int) (*ShmMux[T], error) {
+	if size <= 0 {
+		size = shmDefaultSize
+	}
+	n := 64
+	for n < size {
+		n <<= 1
+	}
+	event, err := newShmEvent()
+	if err != nil {
+		return nil, err
+	}
+	return &ShmMux[T]{size: n, event: event}, nil
+}
+
+// Tag Create a ring to receive data tagged with tag T, returning a writer for it. See ShmWriter.Files to write from
+// another process.
+func (mux *ShmMux[T]) Tag(tag T) (*ShmWriter, error) {
+	mux.mutex.Lock()
+	defer mux.mutex.Unlock()
+	if mux.closed {
+		return nil, MuxClosed
+	}
+	file, err := newShmFile(shmHeaderSize + mux.size)
+	if err != nil {
+		return nil, &TagError[T]{Tag: tag, Op: "tag", Err: err}
+	}
+	reader, err := mapShmRing(file)
+	if err != nil {
+		file.Close()
+		return nil, &TagError[T]{Tag: tag, Op: "tag", Err: err}
+	}
+	writer, err := mapShmRing(file)
+	if err != nil {
+		reader.unmap()
+		file.Close()
+		return nil, &TagError[T]{Tag: tag, Op: "tag", Err: err}
+	}
+	mux.rings = append(mux.rings, &shmReader[T]{ring: reader, tag: tag})
+	return &ShmWriter{ring: writer, file: file, event: mux.event}, nil
+}
+
+// Read perform a read, blocking until data is available or ctx.Done. Returns io.EOF error when ctx is done and there
+// is no data remaining to be read.
+func (mux *ShmMux[T]) Read(ctx context.Context) ([]byte, T, error) {
+	var zeroTag T
+	for {
+		data, tag, ok, err := mux.tryRead(false)
+		if ok || err != nil {
+			return data, tag, err
+		}
+		// ask writers to wake us, then check again in case data arrived before they could see the request
+		data, tag, ok, err = mux.tryRead(true)
+		if ok || err != nil {
+			return data, tag, err
+		}
+		if ctx.Err() != nil {
+			return nil, zeroTag, io.EOF
+		}
+		if err := mux.event.wait(deadlineDuration); err != nil {
+			return nil, zeroTag, err
+		}
+	}
+}
+
+// tryRead reads a record from the next ring with data, if any.
+func (mux *ShmMux[T]) tryRead(wait bool) ([]byte, T, bool, error) {
+	var zeroTag T
+	mux.mutex.Lock()
+	defer mux.mutex.Unlock()
+	if mux.closed {
+		return nil, zeroTag, false, MuxClosed
+	}
+	for i := 0; i < len(mux.rings); i++ {
+		r := mux.rings[(mux.next+i)%len(mux.rings)]
+		if wait {
+			r.ring.uint32(shmWaiting).Store(1)
+		}
+		if data, ok := r.ring.read(); ok {
+			mux.next = (mux.next + i + 1) % len(mux.rings)
+			return data, r.tag, true, nil
+		}
+	}
+	if len(mux.rings) == 0 {
+		return nil, zeroTag, false, MuxNoConnections
+	}
+	return nil, zeroTag, false, nil
+}
+
+// ReadWhile Read until waitFn returns, returning the read data.
+func (mux *ShmMux[T]) ReadWhile(waitFn func() error) ([]*TaggedData[T], error) {
+	return readWhile(mux.ReadUntil, waitFn)
+}
+
+// ReadUntil Read until ctx is done, returning the read data.
+func (mux *ShmMux[T]) ReadUntil(ctx context.Context) ([]*TaggedData[T], error) {
+	return readUntil(ctx, mux.Read, collect[T]{merge: true})
+}
+
+// All Read until ctx is done, yielding each chunk and its tag as it arrives, for use with range.
+func (mux *ShmMux[T]) All(ctx context.Context) iter.Seq2[T, []byte] {
+	return all(ctx, mux.Read)
+}
+
+// Close closes the ShmMux, discarding unread data. Writers fail with MuxClosed once the reader has closed. Prevents
+// reuse.
+func (mux *ShmMux[T]) Close() error {
+	mux.mutex.Lock()
+	defer mux.mutex.Unlock()
+	if mux.closed {
+		return MuxClosed
+	}
+	mux.closed = true
+	for _, r := range mux.rings {
+		r.ring.uint32(shmReaderClosed).Store(1)
+		r.ring.unmap()
+	}
+	mux.rings = nil
+	return mux.event.close()
+}
+
+// OpenShmWriter Open a writer for the ring and eventfd returned by ShmWriter.Files, typically in a child process that
+// was passed them in exec.Cmd.ExtraFiles. The writer takes ownership of both files.
+func OpenShmWriter(ring, event *os.File) (*ShmWriter, error) {
+	r, err := mapShmRing(ring)
+	if err != nil {
+		return nil, err
+	}
+	e, err := openShmEvent(event)
+	if err != nil {
+		r.unmap()
+		return nil, err
+	}
+	return &ShmWriter{ring: r, file: ring, event: e, ownsEvent: true}, nil
+}
+
+// Files returns the shared memory file of the ring and the eventfd used to wake the reader, for passing to another
+// process to open with OpenShmWriter. Writers in different processes must not write at the same time.
+func (w *ShmWriter) Files() (ring, event *os.File) {
+	return w.file, w.event.file
+}
+
+// Write appends p to the ring, waiting while the ring is full. Returns MuxClosed if the reader has closed.
+func (w *ShmWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, io.ErrClosedPipe
+	}
+	if w.ring.uint32(shmReaderClosed).Load() != 0 {
+		return 0, MuxClosed
+	}
+	max := len(w.ring.data)/2 - 4
+	n := 0
+	for n < len(p) {
+		chunk := p[n:]
+		if len(chunk) > max {
+			chunk = chunk[:max]
+		}
+		for !w.ring.write(chunk) {
+			if w.ring.uint32(shmReaderClosed).Load() != 0 {
+				return n, MuxClosed
+			}
+			w.wake()
+			time.Sleep(shmBackoff)
+		}
+		n += len(chunk)
+		w.wake()
+	}
+	return n, nil
+}
+
+// wake signals the reader if it is waiting for data.
+func (w *ShmWriter) wake() {
+	if w.ring.uint32(shmWaiting).CompareAndSwap(1, 0) {
+		w.event.signal()
+	}
+}
+
+// Close closes the writer. The reader keeps the ring until the ShmMux is closed.
+func (w *ShmWriter) Close() error {
+	if w.closed {
+		return io.ErrClosedPipe
+	}
+	w.closed = true
+	w.ring.unmap()
+	err := w.file.Close()
+	if w.ownsEvent {
+		if closeErr := w.event.close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+func newShmRing(mem []byte) *shmRing {
+	data := mem[shmHeaderSize:]
+	return &shmRing{mem: mem, data: data, mask: uint64(len(data) - 1)}
+}
+
+func (r *shmRing) uint64(off int) *atomic.Uint64 {
+	return (*atomic.Uint64)(unsafe.Pointer(&r.mem[off]))
+}
+
+func (r *shmRing) uint32(off int) *atomic.Uint32 {
+	return (*atomic.Uint32)(unsafe.Pointer(&r.mem[off]))
+}
+
+func (r *shmRing) empty() bool {
+	return r.uint64(shmHead).Load() == r.uint64(shmTail).Load()
+}
+
+// write appends p as a record, returning false if there is not enough free space.
+func (r *shmRing) write(p []byte) bool {
+	head := r.uint64(shmHead).Load()
+	tail := r.uint64(shmTail).Load()
+	if uint64(len(r.data))-(head-tail) < uint64(4+len(p)) {
+		return false
+	}
+	var size [4]byte
+	binary.LittleEndian.PutUint32(size[:], uint32(len(p)))
+	r.copyIn(head, size[:])
+	r.copyIn(head+4, p)
+	r.uint64(shmHead).Store(head + 4 + uint64(len(p)))
+	return true
+}
+
+// read removes the next record, returning false if there is none.
+func (r *shmRing) read() ([]byte, bool) {
+	head := r.uint64(shmHead).Load()
+	tail := r.uint64(shmTail).Load()
+	if head == tail {
+		return nil, false
+	}
+	var size [4]byte
+	r.copyOut(tail, size[:])
+	data := make([]byte, binary.LittleEndian.Uint32(size[:]))
+	r.copyOut(tail+4, data)
+	r.uint64(shmTail).Store(tail + 4 + uint64(len(data)))
+	return data, true
+}
+
+func (r *shmRing) copyIn(pos uint64, p []byte) {
+	n := copy(r.data[pos&r.mask:], p)
+	copy(r.data, p[n:])
+}
+
+func (r *shmRing) copyOut(pos uint64, p []byte) {
+	n := copy(p, r.data[pos&r.mask:])
+	copy(p[n:], r.data)
+}
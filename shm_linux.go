@@ -0,0 +1,104 @@
+package iomux
+
+import (
+	"encoding/binary"
+	"os"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// shmEvent is an eventfd used by writers to wake the reader.
+type shmEvent struct {
+	file *os.File
+	fd   int
+}
+
+// newShmFile creates an anonymous shared memory file of size bytes.
+func newShmFile(size int) (*os.File, error) {
+	fd, err := unix.MemfdCreate("iomux-ring", unix.MFD_CLOEXEC)
+	if err != nil {
+		return nil, os.NewSyscallError("memfd_create", err)
+	}
+	if err := unix.Ftruncate(fd, int64(size)); err != nil {
+		unix.Close(fd)
+		return nil, os.NewSyscallError("ftruncate", err)
+	}
+	return os.NewFile(uintptr(fd), "iomux-ring"), nil
+}
+
+// mapShmRing maps the ring in file into memory.
+func mapShmRing(file *os.File) (*shmRing, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := int(info.Size())
+	if size <= shmHeaderSize || (size-shmHeaderSize)&(size-shmHeaderSize-1) != 0 {
+		return nil, os.ErrInvalid
+	}
+	conn, err := file.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+	var mem []byte
+	var mmapErr error
+	if err := conn.Control(func(fd uintptr) {
+		mem, mmapErr = unix.Mmap(int(fd), 0, size, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_SHARED)
+	}); err != nil {
+		return nil, err
+	}
+	if mmapErr != nil {
+		return nil, os.NewSyscallError("mmap", mmapErr)
+	}
+	return newShmRing(mem), nil
+}
+
+func (r *shmRing) unmap() {
+	_ = unix.Munmap(r.mem)
+}
+
+func newShmEvent() (*shmEvent, error) {
+	fd, err := unix.Eventfd(0, unix.EFD_CLOEXEC|unix.EFD_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("eventfd", err)
+	}
+	return &shmEvent{file: os.NewFile(uintptr(fd), "iomux-event"), fd: fd}, nil
+}
+
+// openShmEvent uses an eventfd passed from another process.
+func openShmEvent(file *os.File) (*shmEvent, error) {
+	conn, err := file.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+	var fd int
+	if err := conn.Control(func(f uintptr) {
+		fd = int(f)
+	}); err != nil {
+		return nil, err
+	}
+	return &shmEvent{file: file, fd: fd}, nil
+}
+
+// signal wakes the reader.
+func (e *shmEvent) signal() {
+	var one [8]byte
+	binary.NativeEndian.PutUint64(one[:], 1)
+	_, _ = unix.Write(e.fd, one[:])
+}
+
+// wait waits up to timeout for a signal, consuming it.
+func (e *shmEvent) wait(timeout time.Duration) error {
+	fds := []unix.PollFd{{Fd: int32(e.fd), Events: unix.POLLIN}}
+	if _, err := unix.Poll(fds, int(timeout.Milliseconds())); err != nil && err != unix.EINTR {
+		return os.NewSyscallError("poll", err)
+	}
+	var count [8]byte
+	_, _ = unix.Read(e.fd, count[:])
+	return nil
+}
+
+func (e *shmEvent) close() error {
+	return e.file.Close()
+}
@@ -0,0 +1,44 @@
+//go:build !linux
+
+package iomux
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+// shmEvent is only supported on Linux.
+type shmEvent struct {
+	file *os.File
+}
+
+func newShmFile(size int) (*os.File, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func mapShmRing(file *os.File) (*shmRing, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (r *shmRing) unmap() {
+}
+
+func newShmEvent() (*shmEvent, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func openShmEvent(file *os.File) (*shmEvent, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (e *shmEvent) signal() {
+}
+
+func (e *shmEvent) wait(timeout time.Duration) error {
+	return errors.ErrUnsupported
+}
+
+func (e *shmEvent) close() error {
+	return errors.ErrUnsupported
+}
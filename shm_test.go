@@ -0,0 +1,195 @@
+//go:build linux
+
+package iomux
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShmMux(t *testing.T) {
+	mux, err := NewShmMux[string](0)
+	assert.Nil(t, err)
+	defer mux.Close()
+	a, err := mux.Tag("a")
+	assert.Nil(t, err)
+	b, err := mux.Tag("b")
+	assert.Nil(t, err)
+
+	_, _ = a.Write([]byte("hello"))
+	_, _ = b.Write([]byte("world"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	data, tag, err := mux.Read(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "a", tag)
+	assert.Equal(t, "hello", string(data))
+	data, tag, err = mux.Read(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "b", tag)
+	assert.Equal(t, "world", string(data))
+
+	_, _, err = mux.Read(ctx)
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestShmMuxWrap(t *testing.T) {
+	mux, err := NewShmMux[string](64)
+	assert.Nil(t, err)
+	defer mux.Close()
+	w, err := mux.Tag("a")
+	assert.Nil(t, err)
+
+	// more data than fits in the ring, so writers must wait for the reader and records wrap around the end
+	var want bytes.Buffer
+	go func() {
+		for i := 0; i < 100; i++ {
+			_, _ = w.Write([]byte("0123456789abcdefghijklmnopqrstuvwxyz"))
+		}
+		w.Close()
+	}()
+	for i := 0; i < 100; i++ {
+		want.WriteString("0123456789abcdefghijklmnopqrstuvwxyz")
+	}
+	var got bytes.Buffer
+	for got.Len() < want.Len() {
+		data, _, err := mux.Read(context.Background())
+		assert.Nil(t, err)
+		got.Write(data)
+	}
+	assert.Equal(t, want.String(), got.String())
+}
+
+func TestShmMuxNoConnections(t *testing.T) {
+	mux, err := NewShmMux[string](0)
+	assert.Nil(t, err)
+	defer mux.Close()
+	_, _, err = mux.Read(context.Background())
+	assert.ErrorIs(t, err, MuxNoConnections)
+}
+
+func TestShmMuxClosed(t *testing.T) {
+	mux, err := NewShmMux[string](0)
+	assert.Nil(t, err)
+	w, err := mux.Tag("a")
+	assert.Nil(t, err)
+	assert.Nil(t, mux.Close())
+	_, err = w.Write([]byte("hello"))
+	assert.ErrorIs(t, err, MuxClosed)
+	assert.Nil(t, w.Close())
+	_, err = mux.Tag("a")
+	assert.ErrorIs(t, err, MuxClosed)
+}
+
+func TestShmMuxChildProcess(t *testing.T) {
+	if os.Getenv("IOMUX_SHM_CHILD") != "" {
+		w, err := OpenShmWriter(os.NewFile(3, "ring"), os.NewFile(4, "event"))
+		if err != nil {
+			os.Exit(1)
+		}
+		_, _ = w.Write([]byte("hello from child"))
+		w.Close()
+		os.Exit(0)
+	}
+	mux, err := NewShmMux[string](0)
+	assert.Nil(t, err)
+	defer mux.Close()
+	w, err := mux.Tag("child")
+	assert.Nil(t, err)
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestShmMuxChildProcess$")
+	cmd.Env = append(os.Environ(), "IOMUX_SHM_CHILD=1")
+	ring, event := w.Files()
+	cmd.ExtraFiles = []*os.File{ring, event}
+	assert.Nil(t, cmd.Start())
+	w.Close()
+
+	td, err := mux.ReadWhile(cmd.Wait)
+	assert.Nil(t, err)
+	assert.Len(t, td, 1)
+	assert.Equal(t, "child", td[0].Tag)
+	assert.Equal(t, "hello from child", string(td[0].Data))
+}
+
+func BenchmarkShmMuxRead(b *testing.B) {
+	mux, err := NewShmMux[string](0)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() {
+		mux.Close()
+	})
+	taga, err := mux.Tag("a")
+	if err != nil {
+		b.Fatal(err)
+	}
+	chunk := make([]byte, 4096)
+	ctx := context.Background()
+	b.SetBytes(int64(len(chunk)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := taga.Write(chunk); err != nil {
+			b.Fatal(err)
+		}
+		if _, _, err := mux.Read(ctx); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// benchmarkSmallWrites measures throughput of many small writes, like a line per write, read concurrently.
+func benchmarkSmallWrites(b *testing.B, w io.Writer, read func(context.Context) ([]byte, string, error)) {
+	chunk := []byte("a line of log output of a typical length\n")
+	b.SetBytes(int64(len(chunk)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	go func() {
+		for i := 0; i < b.N; i++ {
+			_, _ = w.Write(chunk)
+		}
+	}()
+	ctx := context.Background()
+	for n := 0; n < b.N*len(chunk); {
+		data, _, err := read(ctx)
+		if err != nil {
+			b.Fatal(err)
+		}
+		n += len(data)
+	}
+}
+
+func BenchmarkMuxSmallWrites(b *testing.B) {
+	mux := NewMux[string]()
+	b.Cleanup(func() {
+		mux.Close()
+	})
+	taga, err := mux.Tag("a")
+	if err != nil {
+		b.Fatal(err)
+	}
+	benchmarkSmallWrites(b, taga, mux.Read)
+}
+
+func BenchmarkShmMuxSmallWrites(b *testing.B) {
+	mux, err := NewShmMux[string](0)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() {
+		mux.Close()
+	})
+	taga, err := mux.Tag("a")
+	if err != nil {
+		b.Fatal(err)
+	}
+	benchmarkSmallWrites(b, taga, mux.Read)
+}
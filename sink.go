@@ -0,0 +1,210 @@
+package iomux
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sinkBuffer is how many chunks can be queued for a sink before Read waits for it to catch up.
+const sinkBuffer = 64
+
+// Sink receives data as it is read by a Mux, see WithSink.
+type Sink[T comparable] interface {
+	// Write handles td, which must not be modified or retained after returning
+	Write(td *TaggedData[T]) error
+	// Close is called once all data has been written, when the Mux is closed
+	Close() error
+}
+
+// sinkQueue writes to a Sink from its own goroutine, so a slow sink doesn't hold up reading.
+type sinkQueue[T comparable] struct {
+	sink Sink[T]
+	ch   chan *TaggedData[T]
+	done chan struct{}
+	err  error
+	// mu guards sending on ch against close, as Close can race a Read
+	mu     sync.Mutex
+	closed bool
+}
+
+func newSinkQueue[T comparable](sink Sink[T]) *sinkQueue[T] {
+	q := &sinkQueue[T]{
+		sink: sink,
+		ch:   make(chan *TaggedData[T], sinkBuffer),
+		done: make(chan struct{}),
+	}
+	go func() {
+		defer close(q.done)
+		for td := range q.ch {
+			// stop writing after the first error, it is reported by close
+			if q.err == nil {
+				q.err = sink.Write(td)
+			}
+		}
+	}()
+	return q
+}
+
+// send queues td, dropping it once the queue is closed.
+func (q *sinkQueue[T]) send(td *TaggedData[T]) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.closed {
+		q.ch <- td
+	}
+}
+
+// close waits for queued data to be written and closes the sink, returning the first error.
+func (q *sinkQueue[T]) close() error {
+	q.mu.Lock()
+	q.closed = true
+	close(q.ch)
+	q.mu.Unlock()
+	<-q.done
+	return errors.Join(q.err, q.sink.Close())
+}
+
+// sink queues a copy of received data d to every sink.
+func (mux *Mux[T]) sink(d *TaggedData[T]) {
+	if len(mux.sinks) == 0 {
+		return
+	}
+	data := d.Data
+	if mux.pooled || mux.into != nil {
+		// Read's copy may be released or reused while the sinks are still writing
+		data = bytes.Clone(data)
+	}
+	mux.sinkseq++
+	td := &TaggedData[T]{Tag: d.Tag, Data: data, Seq: mux.sinkseq, Time: time.Now(), ConnID: d.ConnID, Cred: d.Cred}
+	if mux.classify != nil {
+		// sinks persist everything, WithMinLevel only filters what is read
+		td.Level = mux.classify(d.Tag, data)
+	}
+	for _, q := range mux.sinks {
+		q.send(td)
+	}
+}
+
+// closeSinks closes every sink, returning their errors.
+func (mux *Mux[T]) closeSinks() error {
+	var errs []error
+	for _, q := range mux.sinks {
+		errs = append(errs, q.close())
+	}
+	return errors.Join(errs...)
+}
+
+// FileSink is a Sink writing JSON Lines to a file, see JSONLEncoder, rotating it once it reaches a maximum size.
+// Files are compressed with gzip when the path ends in ".gz", and can be read back by a Replayer either way.
+type FileSink[T comparable] struct {
+	path    string
+	maxSize int64
+	backups int
+	file    *os.File
+	gz      *gzip.Writer
+	size    int64
+	enc     *JSONLEncoder[T]
+}
+
+// NewFileSink Create a FileSink appending to the file at path. Once the file reaches maxSize bytes it is renamed to
+// path.1, with previous backups renamed to path.2 and so on, keeping at most backups of them. A maxSize of 0 or less
+// never rotates. The size of compressed files only grows as the compressor flushes, so they can exceed maxSize by the
+// size of its buffer.
+func NewFileSink[T comparable](path string, maxSize int64, backups int) (*FileSink[T], error) {
+	s := &FileSink[T]{
+		path:    path,
+		maxSize: maxSize,
+		backups: backups,
+	}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileSink[T]) open() error {
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	s.file = file
+	s.size = info.Size()
+	if strings.HasSuffix(s.path, ".gz") {
+		// appending a new gzip member to an existing file still reads back as a single stream
+		s.gz = gzip.NewWriter(writerFunc(s.write))
+		s.enc = NewJSONLEncoder[T](s.gz)
+	} else {
+		s.enc = NewJSONLEncoder[T](writerFunc(s.write))
+	}
+	return nil
+}
+
+// close finishes compressing, if compressed, and closes the file.
+func (s *FileSink[T]) close() error {
+	var err error
+	if s.gz != nil {
+		err = s.gz.Close()
+	}
+	return errors.Join(err, s.file.Close())
+}
+
+// rotate closes the file, shifts the backups along and opens a new file.
+func (s *FileSink[T]) rotate() error {
+	if err := s.close(); err != nil {
+		return err
+	}
+	if s.backups > 0 {
+		for i := s.backups - 1; i > 0; i-- {
+			err := os.Rename(fmt.Sprintf("%s.%d", s.path, i), fmt.Sprintf("%s.%d", s.path, i+1))
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+		if err := os.Rename(s.path, s.path+".1"); err != nil {
+			return err
+		}
+	} else if err := os.Remove(s.path); err != nil {
+		return err
+	}
+	return s.open()
+}
+
+// Write Append td to the file as a line of JSON, rotating the file first if it is full.
+func (s *FileSink[T]) Write(td *TaggedData[T]) error {
+	if s.maxSize > 0 && s.size >= s.maxSize {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+	return s.enc.Encode(td)
+}
+
+// write counts the bytes written to the file, for the JSONLEncoder.
+func (s *FileSink[T]) write(p []byte) (int, error) {
+	n, err := s.file.Write(p)
+	s.size += int64(n)
+	return n, err
+}
+
+// Close Close the file.
+func (s *FileSink[T]) Close() error {
+	return s.close()
+}
+
+// writerFunc adapts a function to io.Writer.
+type writerFunc func(p []byte) (int, error)
+
+func (fn writerFunc) Write(p []byte) (int, error) {
+	return fn(p)
+}
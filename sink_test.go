@@ -0,0 +1,148 @@
+//go:build !windows
+
+package iomux
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var _ Sink[string] = (*SlogSink[string])(nil)
+
+type testSink struct {
+	mutex  sync.Mutex
+	data   []string
+	fail   int
+	closed bool
+}
+
+func (s *testSink) Write(td *TaggedData[string]) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if len(s.data) == s.fail {
+		return errors.New("sink failed")
+	}
+	s.data = append(s.data, fmt.Sprintf("%d:%s:%s", td.Seq, td.Tag, td.Data))
+	return nil
+}
+
+func (s *testSink) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestMuxSink(t *testing.T) {
+	sink := &testSink{fail: -1}
+	failing := &testSink{fail: 1}
+	mux := NewMux[string](WithSink[string](sink), WithSink[string](failing), WithPooledBuffers[string]())
+	taga, _ := mux.Tag("a")
+	tagb, _ := mux.Tag("b")
+
+	td, err := mux.ReadWhile(func() error {
+		io.WriteString(taga, "one")
+		io.WriteString(tagb, "two")
+		io.WriteString(taga, "three")
+		return nil
+	})
+	assert.Nil(t, err)
+	for _, d := range td {
+		d.Release()
+	}
+	assert.EqualError(t, mux.Close(), "sink failed")
+	assert.True(t, sink.closed)
+	assert.Equal(t, []string{"1:a:one", "2:b:two", "3:a:three"}, sink.data)
+	assert.True(t, failing.closed)
+	assert.Equal(t, []string{"1:a:one"}, failing.data)
+}
+
+func TestSinkQueueSendAfterClose(t *testing.T) {
+	sink := &testSink{fail: -1}
+	q := newSinkQueue[string](sink)
+	q.send(&TaggedData[string]{Tag: "a", Data: []byte("one"), Seq: 1})
+	assert.Nil(t, q.close())
+	// a Read racing Close drops its data rather than sending on the closed queue
+	q.send(&TaggedData[string]{Tag: "a", Data: []byte("two"), Seq: 2})
+	assert.Equal(t, []string{"1:a:one"}, sink.data)
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.jsonl")
+	sink, err := NewFileSink[string](path, 100, 2)
+	assert.Nil(t, err)
+	for i := range 8 {
+		assert.Nil(t, sink.Write(&TaggedData[string]{Tag: "a", Data: []byte(fmt.Sprintf("chunk %d", i)), Seq: uint64(i)}))
+	}
+	assert.Nil(t, sink.Close())
+
+	read := func(path string) []string {
+		f, err := os.Open(path)
+		assert.Nil(t, err)
+		defer f.Close()
+		var data []string
+		dec := NewJSONLDecoder[string](f)
+		for {
+			td, err := dec.Decode()
+			if err != nil {
+				assert.Equal(t, io.EOF, err)
+				return data
+			}
+			data = append(data, string(td.Data))
+		}
+	}
+	// each record is about 70 bytes, so the file rotates after every second record
+	assert.Equal(t, []string{"chunk 6", "chunk 7"}, read(path))
+	assert.Equal(t, []string{"chunk 4", "chunk 5"}, read(path+".1"))
+	assert.Equal(t, []string{"chunk 2", "chunk 3"}, read(path+".2"))
+	_, err = os.Stat(path + ".3")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestFileSinkGzip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.jsonl.gz")
+	sink, err := NewFileSink[int](path, 16*1024, 10)
+	assert.Nil(t, err)
+	rnd := rand.New(rand.NewSource(1))
+	for i := range 100 {
+		data := make([]byte, 1024)
+		rnd.Read(data)
+		assert.Nil(t, sink.Write(&TaggedData[int]{Tag: i, Data: data}))
+	}
+	assert.Nil(t, sink.Close())
+
+	// the oldest backup has the highest number
+	backups := 0
+	for ; ; backups++ {
+		if _, err := os.Stat(fmt.Sprintf("%s.%d", path, backups+1)); err != nil {
+			break
+		}
+	}
+	assert.Greater(t, backups, 1)
+	var tags []int
+	for i := backups; i >= 0; i-- {
+		name := path
+		if i > 0 {
+			name = fmt.Sprintf("%s.%d", path, i)
+		}
+		f, err := os.Open(name)
+		if !assert.Nil(t, err) {
+			return
+		}
+		for tag := range NewReplayer[int](f, false).All(context.Background()) {
+			tags = append(tags, tag)
+		}
+		f.Close()
+	}
+	assert.Equal(t, 100, len(tags))
+	for i, tag := range tags {
+		assert.Equal(t, i, tag)
+	}
+}
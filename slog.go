@@ -0,0 +1,49 @@
+package iomux
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+)
+
+// SlogHandler Create a slog.Handler writing JSON records to a new writer tagged with tag, so application logs are
+// read from mux in order with other tagged output such as that of a subprocess.
+func SlogHandler[T comparable](mux *Mux[T], tag T, opts *slog.HandlerOptions) (slog.Handler, error) {
+	file, err := mux.Tag(tag)
+	if err != nil {
+		return nil, err
+	}
+	return slog.NewJSONHandler(file, opts), nil
+}
+
+// SlogSink logs tagged data as slog records, with the data as the message and the tag as the "tag" attribute.
+type SlogSink[T comparable] struct {
+	logger *slog.Logger
+	level  func(tag T) slog.Level
+}
+
+// NewSlogSink Create a SlogSink logging to logger, at the level returned by level for each tag, or slog.LevelInfo if
+// level is nil.
+func NewSlogSink[T comparable](logger *slog.Logger, level func(tag T) slog.Level) *SlogSink[T] {
+	if level == nil {
+		level = func(T) slog.Level {
+			return slog.LevelInfo
+		}
+	}
+	return &SlogSink[T]{
+		logger: logger,
+		level:  level,
+	}
+}
+
+// Write Log td as a record, trimming a trailing newline from the data.
+func (s *SlogSink[T]) Write(td *TaggedData[T]) error {
+	msg := string(bytes.TrimSuffix(td.Data, []byte("\n")))
+	s.logger.Log(context.Background(), s.level(td.Tag), msg, slog.Any("tag", td.Tag))
+	return nil
+}
+
+// Close Do nothing, the logger is owned by the caller.
+func (s *SlogSink[T]) Close() error {
+	return nil
+}
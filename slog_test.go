@@ -0,0 +1,57 @@
+//go:build !windows
+
+package iomux
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlogHandler(t *testing.T) {
+	mux := NewMux[string]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	handler, err := SlogHandler(mux, "log", nil)
+	assert.Nil(t, err)
+	logger := slog.New(handler)
+
+	td, err := mux.ReadWhile(func() error {
+		logger.Info("hello", "key", "value")
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(td))
+	assert.Equal(t, "log", td[0].Tag)
+
+	var record map[string]any
+	assert.Nil(t, json.Unmarshal(td[0].Data, &record))
+	assert.Equal(t, "hello", record["msg"])
+	assert.Equal(t, "value", record["key"])
+}
+
+func TestSlogSink(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	sink := NewSlogSink(logger, func(tag StdStream) slog.Level {
+		if tag == Stderr {
+			return slog.LevelWarn
+		}
+		return slog.LevelInfo
+	})
+
+	assert.Nil(t, sink.Write(&TaggedData[StdStream]{Tag: Stdout, Data: []byte("out1\n")}))
+	assert.Nil(t, sink.Write(&TaggedData[StdStream]{Tag: Stderr, Data: []byte("err1\n")}))
+	assert.Equal(t, "level=INFO msg=out1 tag=stdout\nlevel=WARN msg=err1 tag=stderr\n", buf.String())
+}
@@ -0,0 +1,68 @@
+package iomux
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// pidFile records the process owning a socket directory, so it can be reaped if the process dies without closing
+// the Mux.
+const pidFile = "iomux.pid"
+
+// createDir creates the directory for sockets, in the directory set by WithSocketDir, or the default directory for
+// temporary files.
+func (mux *Mux[T]) createDir() error {
+	if mux.reap {
+		reapStale(mux.sockDir)
+	}
+	dir, err := os.MkdirTemp(mux.sockDir, "mux")
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(filepath.Join(dir, pidFile), []byte(strconv.Itoa(os.Getpid())), 0o600)
+	if err != nil {
+		os.RemoveAll(dir)
+		return err
+	}
+	mux.dir = dir
+	return nil
+}
+
+// reapStale removes socket directories in dir, or the default directory for temporary files if empty, that belong to
+// processes that no longer exist.
+func reapStale(dir string) {
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), "mux") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		b, err := os.ReadFile(filepath.Join(path, pidFile))
+		if err != nil {
+			continue
+		}
+		pid, err := strconv.Atoi(string(b))
+		if err != nil || pid == os.Getpid() || processAlive(pid) {
+			continue
+		}
+		os.RemoveAll(path)
+	}
+}
+
+// Addr Return the address sockets are received on, which is in the socket directory unless using the abstract
+// namespace, or nil before the first call to Tag, or when using WithSocketpair.
+func (mux *Mux[T]) Addr() net.Addr {
+	if mux.recvaddr == nil {
+		return nil
+	}
+	return mux.recvaddr
+}
@@ -0,0 +1,65 @@
+//go:build !windows
+
+package iomux
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMuxSocketDir(t *testing.T) {
+	dir := t.TempDir()
+	mux := NewMux[string](WithSocketDir[string](dir))
+	assert.Nil(t, mux.Addr())
+	taga, err := mux.Tag("a")
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(mux.Addr().String(), dir))
+
+	taga.WriteString("hello taga")
+	data, tag, err := mux.Read(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "a", tag)
+	assert.Equal(t, "hello taga", string(data))
+
+	b, err := os.ReadFile(filepath.Join(mux.dir, pidFile))
+	assert.Nil(t, err)
+	assert.Equal(t, strconv.Itoa(os.Getpid()), string(b))
+
+	mux.Close()
+	entries, err := os.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestMuxStaleSocketCleanup(t *testing.T) {
+	dir := t.TempDir()
+	cmd := exec.Command("true")
+	assert.Nil(t, cmd.Run())
+	stale := filepath.Join(dir, "mux1")
+	assert.Nil(t, os.Mkdir(stale, 0o700))
+	assert.Nil(t, os.WriteFile(filepath.Join(stale, pidFile), []byte(strconv.Itoa(cmd.Process.Pid)), 0o600))
+	live := filepath.Join(dir, "mux2")
+	assert.Nil(t, os.Mkdir(live, 0o700))
+	assert.Nil(t, os.WriteFile(filepath.Join(live, pidFile), []byte(strconv.Itoa(os.Getppid())), 0o600))
+	unknown := filepath.Join(dir, "mux3")
+	assert.Nil(t, os.Mkdir(unknown, 0o700))
+
+	mux := NewMux[string](WithSocketDir[string](dir), WithStaleSocketCleanup[string]())
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	_, err := mux.Tag("a")
+	assert.Nil(t, err)
+
+	assert.NoDirExists(t, stale)
+	assert.DirExists(t, live)
+	assert.DirExists(t, unknown)
+	assert.DirExists(t, mux.dir)
+}
@@ -0,0 +1,55 @@
+package iomux
+
+import (
+	"net"
+	"os"
+)
+
+// startSocketpair prepares to receive from socket pairs created by createPair, which need no listener or temporary
+// directory.
+func (mux *Mux[T]) startSocketpair() {
+	if p, err := newPoller(); err == nil {
+		mux.poller = p
+		mux.pollbuf = make([]byte, mux.bufferSize())
+		mux.pollbatch = newRecvBatch(mux.network, mux.batchSize(), mux.bufferSize())
+		if mux.creds {
+			mux.polloob = make([]byte, credOOBSize)
+		}
+		mux.addCloser(p)
+	}
+}
+
+// createPair creates a socket pair for tag, receiving from one end and returning the other for writing. The receive
+// end identifies the tag, as neither end has an address.
+func (mux *Mux[T]) createPair(tag T) (*os.File, error) {
+	conn, sender, err := socketpair(mux.network)
+	if err != nil {
+		return nil, err
+	}
+	if mux.poller != nil {
+		if err := mux.poller.add(conn); err != nil {
+			conn.Close()
+			sender.Close()
+			return nil, err
+		}
+	}
+	mux.addCloser(conn)
+	mux.addReceiver(conn)
+
+	mux.sendmutex.Lock()
+	if mux.conntags == nil {
+		mux.conntags = make(map[*net.UnixConn]tagSender[T])
+		mux.open = make(map[T]int)
+	}
+	mux.sendnum++
+	mux.conntags[conn] = tagSender[T]{tag: tag, id: uint64(mux.sendnum)}
+	mux.open[tag]++
+	mux.sendmutex.Unlock()
+	if mux.metrics != nil {
+		mux.metrics.Connected(tag)
+	}
+	if mux.fragmented() {
+		return fragmentFile(sender, mux.bufferSize())
+	}
+	return sender, nil
+}
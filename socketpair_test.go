@@ -0,0 +1,70 @@
+//go:build !windows
+
+package iomux
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMuxSocketpair(t *testing.T) {
+	for _, network := range networks {
+		t.Run(network, func(t *testing.T) {
+			mux := newMux(network, []Option[string]{WithSocketpair[string]()})
+			t.Cleanup(func() {
+				mux.Close()
+			})
+			taga, err := mux.Tag("a")
+			if err != nil {
+				skipIfProtocolNotSupported(t, err)
+				assert.Nil(t, err)
+			}
+			tagb, err := mux.Tag("b")
+			assert.Nil(t, err)
+			assert.Empty(t, mux.dir)
+
+			taga.WriteString("hello taga")
+			data, tag, err := mux.Read(context.Background())
+			assert.Nil(t, err)
+			assert.Equal(t, "a", tag)
+			assert.Equal(t, "hello taga", string(data))
+
+			tagb.WriteString("hello tagb")
+			data, tag, err = mux.Read(context.Background())
+			assert.Nil(t, err)
+			assert.Equal(t, "b", tag)
+			assert.Equal(t, "hello tagb", string(data))
+
+			taga.Close()
+			tagb.Close()
+			ctx, cancelFn := context.WithTimeout(context.Background(), sleepDuration)
+			defer cancelFn()
+			_, _, err = mux.Read(ctx)
+			assert.Equal(t, io.EOF, err)
+		})
+	}
+}
+
+func TestMuxSocketpairSingle(t *testing.T) {
+	var closed []string
+	mux := NewMuxUnix[string](WithSocketpair[string](), WithCloseHandler(func(tag string) {
+		closed = append(closed, tag)
+	}))
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	taga, err := mux.Tag("a")
+	assert.Nil(t, err)
+
+	taga.WriteString("hello taga")
+	taga.Close()
+	td, err := mux.ReadUntil(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(td))
+	assert.Equal(t, "a", td[0].Tag)
+	assert.Equal(t, "hello taga", string(td[0].Data))
+	assert.Equal(t, []string{"a"}, closed)
+}
@@ -0,0 +1,126 @@
+package iomux
+
+import (
+	"os"
+	"runtime"
+	"sync"
+)
+
+// spill writes the oldest chunks accumulated by readUntil to a temporary file once more than threshold bytes are held
+// in memory, leaving them to be read back by TaggedData.Load, see WithSpill.
+type spill[T comparable] struct {
+	threshold int
+	dir       string
+	file      *spillFile
+	// size is the bytes written to file
+	size int64
+	// mem is the bytes held in memory
+	mem int
+	// next is the index of the oldest chunk held in memory
+	next int
+}
+
+// spillFile is a spill file shared by the chunks written to it, removed once every one of them has been loaded or
+// released, or once none of them are reachable.
+type spillFile struct {
+	file   *os.File
+	mutex  sync.Mutex
+	unread int
+	closed bool
+}
+
+// spilled locates the data of a chunk in a spill file.
+type spilled struct {
+	file *spillFile
+	off  int64
+	n    int
+}
+
+func newSpill[T comparable](threshold int, dir string) *spill[T] {
+	return &spill[T]{threshold: threshold, dir: dir}
+}
+
+// add accounts for n bytes read into the last chunk of td. Once over the threshold, the oldest chunks are spilled until
+// at most half of it is held in memory, keeping the last chunk, which may still be appended to.
+func (s *spill[T]) add(td []*TaggedData[T], n int) error {
+	s.mem += n
+	if s.mem <= s.threshold {
+		return nil
+	}
+	if s.file == nil {
+		file, err := createSpillFile(s.dir)
+		if err != nil {
+			return err
+		}
+		s.file = &spillFile{file: file}
+		runtime.SetFinalizer(s.file, (*spillFile).close)
+	}
+	for s.next < len(td)-1 && s.mem > s.threshold/2 {
+		chunk := td[s.next]
+		s.next++
+		if len(chunk.Data) == 0 {
+			continue
+		}
+		if _, err := s.file.file.Write(chunk.Data); err != nil {
+			return err
+		}
+		s.file.mutex.Lock()
+		s.file.unread++
+		s.file.mutex.Unlock()
+		chunk.spilled = &spilled{file: s.file, off: s.size, n: len(chunk.Data)}
+		s.size += int64(len(chunk.Data))
+		s.mem -= len(chunk.Data)
+		chunk.Data = nil
+	}
+	return nil
+}
+
+// read reads the data of a chunk back into memory.
+func (f *spillFile) read(off int64, n int) ([]byte, error) {
+	data := make([]byte, n)
+	if _, err := f.file.ReadAt(data, off); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// done gives up the share of a chunk in the file, closing the file once every chunk has given up theirs.
+func (f *spillFile) done() {
+	f.mutex.Lock()
+	f.unread--
+	last := f.unread == 0
+	f.mutex.Unlock()
+	if last {
+		f.close()
+	}
+}
+
+// close closes and removes the file, if it hasn't been already.
+func (f *spillFile) close() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.closed {
+		return
+	}
+	f.closed = true
+	f.file.Close()
+	// already gone where the file could be removed while open
+	os.Remove(f.file.Name())
+}
+
+// Load Return Data, first reading it back from disk if it was spilled by WithSpill, which leaves Data nil until then.
+// Loaded data is kept in Data, and the spill file is removed once every chunk written to it has been loaded or
+// released. Not safe to call concurrently for the same TaggedData.
+func (td *TaggedData[T]) Load() ([]byte, error) {
+	if td.spilled == nil {
+		return td.Data, nil
+	}
+	data, err := td.spilled.file.read(td.spilled.off, td.spilled.n)
+	if err != nil {
+		return nil, err
+	}
+	td.spilled.file.done()
+	td.spilled = nil
+	td.Data = data
+	return data, nil
+}
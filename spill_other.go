@@ -0,0 +1,10 @@
+//go:build !unix
+
+package iomux
+
+import "os"
+
+// createSpillFile creates a spill file in dir, which is removed once it has been read back.
+func createSpillFile(dir string) (*os.File, error) {
+	return os.CreateTemp(dir, "iomux-spill-*")
+}
@@ -0,0 +1,94 @@
+//go:build !windows
+
+package iomux
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithSpill(t *testing.T) {
+	dir := t.TempDir()
+	mux := NewMux(WithSpill[StdStream](4096, dir))
+	defer mux.Close()
+	stdout, _ := mux.Tag(Stdout)
+	stderr, _ := mux.Tag(Stderr)
+	var want bytes.Buffer
+	td, err := mux.ReadWhile(func() error {
+		for i := 0; i < 1000; i++ {
+			line := fmt.Sprintf("line %d\n", i)
+			want.WriteString(line)
+			file := stdout
+			if i%100 == 99 {
+				file = stderr
+			}
+			if _, err := file.WriteString(line); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	var got bytes.Buffer
+	spilled := 0
+	for _, d := range td {
+		if d.Data == nil {
+			spilled++
+		}
+		data, err := d.Load()
+		assert.Nil(t, err)
+		got.Write(data)
+		d.Release()
+	}
+	assert.Equal(t, want.String(), got.String())
+	assert.Greater(t, spilled, 0)
+	entries, err := os.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestSpillAdd(t *testing.T) {
+	s := newSpill[string](100, t.TempDir())
+	var td []*TaggedData[string]
+	for i := 0; i < 10; i++ {
+		data := bytes.Repeat([]byte{byte('a' + i)}, 40)
+		td = append(td, &TaggedData[string]{Tag: "tag", Data: data})
+		assert.Nil(t, s.add(td, len(data)))
+		assert.LessOrEqual(t, s.mem, 100)
+		assert.NotNil(t, td[len(td)-1].Data)
+	}
+	assert.Nil(t, td[0].Data)
+	spilled := s.file.unread
+	assert.Greater(t, spilled, 1)
+
+	// spilled data is only read back when loaded, into memory of its own
+	data, err := td[0].Load()
+	assert.Nil(t, err)
+	assert.Equal(t, bytes.Repeat([]byte{'a'}, 40), data)
+	assert.Equal(t, data, td[0].Data)
+	data[0] = 'X'
+	assert.Nil(t, td[1].Data)
+	assert.Equal(t, spilled-1, s.file.unread)
+
+	// the file is closed once every spilled chunk has been loaded or released
+	td[1].Release()
+	for i, d := range td[2:] {
+		data, err := d.Load()
+		assert.Nil(t, err)
+		assert.Equal(t, bytes.Repeat([]byte{byte('c' + i)}, 40), data)
+	}
+	assert.True(t, s.file.closed)
+	_, err = s.file.file.Stat()
+	assert.NotNil(t, err)
+}
+
+func TestSpillIgnoredWithLimits(t *testing.T) {
+	mux := NewMux(WithSpill[StdStream](4096, ""), WithMaxBufferedBytes[StdStream](1<<20, BufferError))
+	assert.Nil(t, mux.collect().spill)
+	mux = NewMux(WithSpill[StdStream](4096, ""))
+	assert.NotNil(t, mux.collect().spill)
+}
@@ -0,0 +1,15 @@
+//go:build unix
+
+package iomux
+
+import "os"
+
+// createSpillFile creates a spill file in dir, removing it straight away so it is cleaned up however the process exits.
+func createSpillFile(dir string) (*os.File, error) {
+	file, err := os.CreateTemp(dir, "iomux-spill-*")
+	if err != nil {
+		return nil, err
+	}
+	os.Remove(file.Name())
+	return file, nil
+}
@@ -0,0 +1,180 @@
+package iomux
+
+import (
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// Stats describes the data read by a Mux, so supervisors can report progress, detect stuck tags, and tell whether a
+// capture is complete.
+type Stats[T comparable] struct {
+	// Chunks and Bytes count the data received from writers.
+	Chunks uint64
+	Bytes  uint64
+	// Truncated counts 'unixgram' datagrams larger than the receive buffer, which lose the data past the end of the
+	// buffer, see bufferSize. Writers block rather than datagrams being dropped when the receiver falls behind.
+	Truncated uint64
+	// Dropped counts bytes discarded by BufferDropOldest, BufferDropOldestMarker, WithRingBuffer and WithHeadTail.
+	Dropped uint64
+	// Connections counts the writers that are open. Only connection oriented networks detect writers being closed.
+	Connections int
+	// Buffered counts bytes received but not yet returned by Read.
+	Buffered int
+	// Held counts bytes accumulated by a ReadUntil, ReadWhile or Drain in progress, which WithMaxBufferedBytes limits.
+	Held int
+	// LastActivity is when data was last received, or zero if none has been.
+	LastActivity time.Time
+	// Tags breaks the counts down by tag, for every tag returned by Tag.
+	Tags map[T]TagStats
+}
+
+// TagStats describes the data read for a tag, see Stats.
+type TagStats struct {
+	Chunks       uint64
+	Bytes        uint64
+	Dropped      uint64
+	Held         int
+	Connections  int
+	LastActivity time.Time
+}
+
+type stats[T comparable] struct {
+	mutex     sync.Mutex
+	total     TagStats
+	truncated uint64
+	dropped   uint64
+	buffered  int
+	held      int
+	tags      map[T]*TagStats
+}
+
+func (s *stats[T]) received(tag T, n int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := time.Now()
+	s.total.Chunks++
+	s.total.Bytes += uint64(n)
+	s.total.LastActivity = now
+	ts := s.tag(tag)
+	ts.Chunks++
+	ts.Bytes += uint64(n)
+	ts.LastActivity = now
+}
+
+func (s *stats[T]) truncate() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.truncated++
+}
+
+// tag returns the counts for tag, creating them if needed. The mutex must be held.
+func (s *stats[T]) tag(tag T) *TagStats {
+	if s.tags == nil {
+		s.tags = make(map[T]*TagStats)
+	}
+	ts, ok := s.tags[tag]
+	if !ok {
+		ts = &TagStats{}
+		s.tags[tag] = ts
+	}
+	return ts
+}
+
+func (s *stats[T]) drop(tag T, n int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.dropped += uint64(n)
+	s.held -= n
+	ts := s.tag(tag)
+	ts.Dropped += uint64(n)
+	ts.Held -= n
+}
+
+func (s *stats[T]) hold(tag T, n int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.held += n
+	s.tag(tag).Held += n
+}
+
+func (s *stats[T]) release() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.held = 0
+	for _, ts := range s.tags {
+		ts.Held = 0
+	}
+}
+
+func (s *stats[T]) buffer(n int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.buffered += n
+}
+
+// Stats Return a snapshot of the data read so far. Safe to call concurrently with reads.
+func (mux *Mux[T]) Stats() Stats[T] {
+	mux.stats.mutex.Lock()
+	result := Stats[T]{
+		Chunks:       mux.stats.total.Chunks,
+		Bytes:        mux.stats.total.Bytes,
+		Truncated:    mux.stats.truncated,
+		Dropped:      mux.stats.dropped,
+		Buffered:     mux.stats.buffered,
+		Held:         mux.stats.held,
+		LastActivity: mux.stats.total.LastActivity,
+		Tags:         make(map[T]TagStats),
+	}
+	for tag, ts := range mux.stats.tags {
+		result.Tags[tag] = *ts
+	}
+	mux.stats.mutex.Unlock()
+
+	mux.sendmutex.RLock()
+	defer mux.sendmutex.RUnlock()
+	for tag, open := range mux.open {
+		ts := result.Tags[tag]
+		ts.Connections = open
+		result.Tags[tag] = ts
+		result.Connections += open
+	}
+	return result
+}
+
+// readFrom reads from the connection of r like ReadFrom, returning the data read, which is only valid until the next
+// read. Counts datagrams that were truncated by the buffer being too small.
+func (mux *Mux[T]) readFrom(r *receiver) ([]byte, net.Addr, error) {
+	if r.batch != nil {
+		data, addr, truncated, err := r.batch.read(r.conn)
+		if truncated {
+			mux.stats.truncate()
+		}
+		if err == nil && len(data) == 0 && mux.network == "unixpacket" {
+			return nil, nil, io.EOF
+		}
+		return data, addr, err
+	}
+	if mux.network != "unixgram" && r.oob == nil {
+		n, addr, err := r.conn.ReadFrom(r.buf)
+		return r.buf[:max(n, 0)], addr, err
+	}
+	n, oobn, flags, addr, err := r.conn.ReadMsgUnix(r.buf, r.oob)
+	if r.oob != nil {
+		r.src.cred = parseCred(r.oob[:oobn])
+		if err == nil && n == 0 && mux.network != "unixgram" {
+			// ReadMsgUnix doesn't report io.EOF like ReadFrom
+			return nil, nil, io.EOF
+		}
+	}
+	if flags&msgTrunc != 0 {
+		mux.stats.truncate()
+	}
+	// the count is negative on error
+	data := r.buf[:max(n, 0)]
+	if addr == nil {
+		return data, nil, err
+	}
+	return data, addr, err
+}
@@ -0,0 +1,134 @@
+//go:build !windows
+
+package iomux
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMuxStats(t *testing.T) {
+	for _, network := range networks {
+		t.Run(network, func(t *testing.T) {
+			mux := &Mux[string]{network: network}
+			t.Cleanup(func() {
+				mux.Close()
+			})
+			taga, err := mux.Tag("a")
+			if err != nil {
+				skipIfProtocolNotSupported(t, err)
+				assert.Nil(t, err)
+			}
+			taga.WriteString("hello")
+			taga.Close()
+			ctx, cancelFn := context.WithTimeout(context.Background(), sleepDuration)
+			defer cancelFn()
+			_, err = mux.ReadUntil(ctx)
+			assert.Nil(t, err)
+			stats := mux.Stats()
+			assert.Equal(t, uint64(1), stats.Chunks)
+			assert.Equal(t, uint64(5), stats.Bytes)
+			assert.Equal(t, uint64(1), stats.Tags["a"].Chunks)
+			assert.Equal(t, uint64(5), stats.Tags["a"].Bytes)
+			assert.False(t, stats.LastActivity.IsZero())
+			assert.Equal(t, stats.LastActivity, stats.Tags["a"].LastActivity)
+		})
+	}
+}
+
+func TestMuxStatsIntrospection(t *testing.T) {
+	mux := NewMuxUnix[string](WithLineSplitting[string]())
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	taga, _ := mux.Tag("a")
+	tagb, _ := mux.Tag("b")
+	stats := mux.Stats()
+	assert.Equal(t, 2, stats.Connections)
+	assert.Equal(t, 1, stats.Tags["b"].Connections)
+	assert.True(t, stats.LastActivity.IsZero())
+
+	taga.WriteString("line 1\nline 2\n")
+	data, _, err := mux.Read(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "line 1\n", string(data))
+	assert.Equal(t, len("line 2\n"), mux.Stats().Buffered)
+	_, _, err = mux.Read(context.Background())
+	assert.Nil(t, err)
+	assert.Zero(t, mux.Stats().Buffered)
+
+	tagb.Close()
+	ctx, cancelFn := context.WithTimeout(context.Background(), sleepDuration)
+	defer cancelFn()
+	_, _, err = mux.Read(ctx)
+	assert.Equal(t, io.EOF, err)
+	stats = mux.Stats()
+	assert.Equal(t, 1, stats.Connections)
+	assert.Equal(t, 0, stats.Tags["b"].Connections)
+	assert.Zero(t, stats.Tags["b"].Chunks)
+}
+
+func TestMuxStatsTruncated(t *testing.T) {
+	mux := NewMuxUnixGram[string]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	taga, err := mux.Tag("a")
+	assert.Nil(t, err)
+	size := mux.bufferSize() + 1
+	if _, err := taga.Write(bytes.Repeat([]byte("a"), size)); err != nil {
+		t.Skipf("datagram of %d bytes not supported: %v", size, err)
+	}
+	data, _, err := mux.Read(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, size-1, len(data))
+	assert.Equal(t, uint64(1), mux.Stats().Truncated)
+}
+
+func TestMuxStatsDropped(t *testing.T) {
+	mux := NewMux[string](WithMaxBufferedBytes[string](4, BufferDropOldest))
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	taga, _ := mux.Tag("a")
+	tagb, _ := mux.Tag("b")
+	taga.WriteString("aaa")
+	tagb.WriteString("bbb")
+	ctx, cancelFn := context.WithTimeout(context.Background(), sleepDuration)
+	defer cancelFn()
+	_, err := mux.ReadUntil(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), mux.Stats().Dropped)
+}
+
+func TestMuxStatsHeld(t *testing.T) {
+	mux := NewMux[string](WithMaxBufferedBytes[string](4, BufferDropOldest))
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	taga, _ := mux.Tag("a")
+	tagb, _ := mux.Tag("b")
+	_, err := mux.ReadWhile(func() error {
+		taga.WriteString("aaa")
+		assert.Eventually(t, func() bool {
+			return mux.Stats().Held == 3
+		}, time.Second, time.Millisecond)
+		tagb.WriteString("bbb")
+		assert.Eventually(t, func() bool {
+			stats := mux.Stats()
+			return stats.Held == 4 && stats.Tags["a"].Held == 1 && stats.Tags["b"].Held == 3
+		}, time.Second, time.Millisecond)
+		return nil
+	})
+	assert.Nil(t, err)
+	stats := mux.Stats()
+	assert.Equal(t, 0, stats.Held)
+	assert.Equal(t, 0, stats.Tags["a"].Held)
+	assert.Equal(t, uint64(2), stats.Tags["a"].Dropped)
+	assert.Equal(t, uint64(0), stats.Tags["b"].Dropped)
+}
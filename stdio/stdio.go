@@ -0,0 +1,37 @@
+// Package stdio provides a Mux tagged with the standard output streams, so capturing a command's stdout and stderr
+// doesn't require inventing a tag convention.
+package stdio
+
+import (
+	"os"
+
+	"github.com/netflix/go-iomux"
+)
+
+// Stream tags the standard output streams of a command.
+type Stream = iomux.StdStream
+
+const (
+	Stdout = iomux.Stdout
+	Stderr = iomux.Stderr
+)
+
+// Mux A Mux tagged with Stream, with helpers to create the stdout and stderr writers.
+type Mux struct {
+	*iomux.Mux[Stream]
+}
+
+// New Create a new Mux using the default network with opts.
+func New(opts ...iomux.Option[Stream]) *Mux {
+	return &Mux{iomux.NewMux[Stream](opts...)}
+}
+
+// Stdout Create a file tagged Stdout, for use as a command's stdout.
+func (mux *Mux) Stdout() (*os.File, error) {
+	return mux.Tag(Stdout)
+}
+
+// Stderr Create a file tagged Stderr, for use as a command's stderr.
+func (mux *Mux) Stderr() (*os.File, error) {
+	return mux.Tag(Stderr)
+}
@@ -0,0 +1,36 @@
+//go:build !windows
+
+package stdio
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMux(t *testing.T) {
+	mux := New()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	stdout, err := mux.Stdout()
+	assert.Nil(t, err)
+	stderr, err := mux.Stderr()
+	assert.Nil(t, err)
+
+	cmd := exec.Command("sh", "-c", "echo out && sleep 0.1 && echo err 1>&2")
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	td, err := mux.ReadWhile(func() error {
+		return cmd.Run()
+	})
+	assert.Nil(t, err)
+
+	assert.Equal(t, 2, len(td))
+	assert.Equal(t, Stdout, td[0].Tag)
+	assert.Equal(t, "out\n", string(td[0].Data))
+	assert.Equal(t, Stderr, td[1].Tag)
+	assert.Equal(t, "err\n", string(td[1].Data))
+	assert.Equal(t, "stderr", td[1].Tag.String())
+}
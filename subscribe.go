@@ -0,0 +1,34 @@
+package iomux
+
+type subscription struct {
+	fn func([]byte)
+}
+
+// Subscribe Call fn with data for tag as it is read, instead of returning it from Read. Data for other tags still
+// flows to Read, which must keep being called for fn to be called, for example by ReadWhile. Subscribing to a tag
+// replaces any existing subscription. Returns a function that ends the subscription.
+func (mux *Mux[T]) Subscribe(tag T, fn func(data []byte)) func() {
+	mux.submutex.Lock()
+	defer mux.submutex.Unlock()
+	if mux.subscribers == nil {
+		mux.subscribers = make(map[T]*subscription)
+	}
+	sub := &subscription{fn: fn}
+	mux.subscribers[tag] = sub
+	return func() {
+		mux.submutex.Lock()
+		defer mux.submutex.Unlock()
+		if mux.subscribers[tag] == sub {
+			delete(mux.subscribers, tag)
+		}
+	}
+}
+
+func (mux *Mux[T]) subscriber(tag T) func([]byte) {
+	mux.submutex.Lock()
+	defer mux.submutex.Unlock()
+	if sub, ok := mux.subscribers[tag]; ok {
+		return sub.fn
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+//go:build !windows
+
+package iomux
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMuxSubscribe(t *testing.T) {
+	mux := NewMux[StdStream](WithLineSplitting[StdStream]())
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	stdout, err := mux.Tag(Stdout)
+	assert.Nil(t, err)
+	stderr, _ := mux.Tag(Stderr)
+
+	var errors []string
+	unsubscribe := mux.Subscribe(Stderr, func(data []byte) {
+		errors = append(errors, string(data))
+	})
+	td, err := mux.ReadWhile(func() error {
+		io.WriteString(stdout, "out1\n")
+		time.Sleep(sleepDuration)
+		io.WriteString(stderr, "err1\nerr2\n")
+		time.Sleep(sleepDuration)
+		io.WriteString(stdout, "out2\n")
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"err1\n", "err2\n"}, errors)
+	assert.Equal(t, 2, len(td))
+	assert.Equal(t, "out1\n", string(td[0].Data))
+	assert.Equal(t, "out2\n", string(td[1].Data))
+
+	unsubscribe()
+	td, err = mux.ReadWhile(func() error {
+		io.WriteString(stderr, "err3\n")
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(td))
+	assert.Equal(t, Stderr, td[0].Tag)
+	assert.Equal(t, []string{"err1\n", "err2\n"}, errors)
+}
@@ -0,0 +1,91 @@
+package supervisor
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"github.com/netflix/go-iomux"
+)
+
+// EventKind is the kind of a lifecycle event.
+type EventKind string
+
+const (
+	// Started is emitted as a process is started, before any of its output.
+	Started EventKind = "started"
+	// Exited is emitted when a process exits, or fails to start.
+	Exited EventKind = "exited"
+	// Restarted is emitted before a process is started again.
+	Restarted EventKind = "restarted"
+)
+
+// Event is a lifecycle event of a process, recorded on its Events stream as a line of logfmt such as
+// "event=exited pid=42 code=1".
+type Event struct {
+	Kind EventKind
+	// PID is the process id, for Exited, or 0 if the process failed to start
+	PID int
+	// ExitCode is the exit code, or -1 if the process was terminated by a signal or failed to start, for Exited
+	ExitCode int
+	// Restarts is how many times the process has been restarted, for Restarted
+	Restarts int
+}
+
+// MarshalText Encode e as its record.
+func (e Event) MarshalText() ([]byte, error) {
+	switch e.Kind {
+	case Started:
+		return fmt.Appendf(nil, "event=%s\n", e.Kind), nil
+	case Exited:
+		return fmt.Appendf(nil, "event=%s pid=%d code=%d\n", e.Kind, e.PID, e.ExitCode), nil
+	case Restarted:
+		return fmt.Appendf(nil, "event=%s restarts=%d\n", e.Kind, e.Restarts), nil
+	default:
+		return nil, fmt.Errorf("supervisor: unknown event %q", e.Kind)
+	}
+}
+
+// UnmarshalText Decode a record into e.
+func (e *Event) UnmarshalText(text []byte) error {
+	var m map[string]any
+	if err := iomux.DecodeLogfmt(text, &m); err != nil {
+		return err
+	}
+	kind, _ := m["event"].(string)
+	*e = Event{Kind: EventKind(kind)}
+	fields := map[string]*int{"pid": &e.PID, "code": &e.ExitCode, "restarts": &e.Restarts}
+	for key, field := range fields {
+		value, ok := m[key].(string)
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("supervisor: invalid %s %q", key, value)
+		}
+		*field = n
+	}
+	switch e.Kind {
+	case Started, Exited, Restarted:
+		return nil
+	default:
+		return fmt.Errorf("supervisor: unknown event %q", kind)
+	}
+}
+
+// ParseEvents Decode the records in the data of a chunk read from an Events stream, which may hold several.
+func ParseEvents(data []byte) ([]Event, error) {
+	var events []Event
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		var e Event
+		if err := e.UnmarshalText(line); err != nil {
+			return events, err
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
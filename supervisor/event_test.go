@@ -0,0 +1,35 @@
+package supervisor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventText(t *testing.T) {
+	for _, e := range []Event{
+		{Kind: Started},
+		{Kind: Exited, PID: 42, ExitCode: -1},
+		{Kind: Restarted, Restarts: 3},
+	} {
+		text, err := e.MarshalText()
+		assert.Nil(t, err)
+		var got Event
+		assert.Nil(t, got.UnmarshalText(text))
+		assert.Equal(t, e, got)
+	}
+	text, _ := Event{Kind: Exited, PID: 7, ExitCode: 1}.MarshalText()
+	assert.Equal(t, "event=exited pid=7 code=1\n", string(text))
+	_, err := Event{Kind: "paused"}.MarshalText()
+	assert.EqualError(t, err, `supervisor: unknown event "paused"`)
+}
+
+func TestParseEvents(t *testing.T) {
+	events, err := ParseEvents([]byte("event=started\nevent=exited pid=1 code=0\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, []Event{{Kind: Started}, {Kind: Exited, PID: 1}}, events)
+	_, err = ParseEvents([]byte("event=exited pid=x\n"))
+	assert.EqualError(t, err, `supervisor: invalid pid "x"`)
+	_, err = ParseEvents([]byte("level=info\n"))
+	assert.EqualError(t, err, `supervisor: unknown event ""`)
+}
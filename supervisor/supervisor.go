@@ -0,0 +1,222 @@
+// Package supervisor runs named processes, restarting them by policy, and reads the output of all of them along with
+// their lifecycle events as a single ordered stream.
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/netflix/go-iomux"
+)
+
+// Events is the stream of the records of lifecycle events, see Event.
+const Events iomux.StdStream = -1
+
+// Tag tags the output of a process with the name it was added with and the stream it was written to, or Events for
+// its lifecycle events.
+type Tag struct {
+	Name   string
+	Stream iomux.StdStream
+}
+
+func (t Tag) String() string {
+	if t.Stream == Events {
+		return t.Name + ":events"
+	}
+	return t.Name + ":" + t.Stream.String()
+}
+
+// RestartPolicy decides whether a process is restarted when it exits.
+type RestartPolicy int
+
+const (
+	// RestartNever never restarts the process.
+	RestartNever RestartPolicy = iota
+	// RestartOnFailure restarts the process when it exits unsuccessfully.
+	RestartOnFailure
+	// RestartAlways restarts the process whenever it exits.
+	RestartAlways
+)
+
+// Process describes a process to supervise.
+type Process struct {
+	// Command creates the command for each run, as a command can only be started once. Its stdout and stderr must not
+	// be set.
+	Command func() *exec.Cmd
+	// Restart decides whether the process is restarted when it exits
+	Restart RestartPolicy
+	// MaxRestarts limits how many times the process is restarted, or no limit if 0
+	MaxRestarts int
+	// Backoff is how long to wait before restarting the process
+	Backoff time.Duration
+}
+
+// Supervisor runs processes, see Add and Run.
+type Supervisor struct {
+	opts  []iomux.Option[Tag]
+	names map[string]bool
+	procs []*process
+}
+
+type process struct {
+	name string
+	Process
+	stdout, stderr, events *os.File
+}
+
+// New Create a new Supervisor, with opts applied to the Mux its processes write to.
+func New(opts ...iomux.Option[Tag]) *Supervisor {
+	return &Supervisor{opts: opts, names: make(map[string]bool)}
+}
+
+// Add Add p under name, which must be unique within the supervisor.
+func (s *Supervisor) Add(name string, p Process) error {
+	if s.names[name] {
+		return fmt.Errorf("supervisor: process %q already added", name)
+	}
+	if p.Command == nil {
+		return fmt.Errorf("supervisor: process %q has no command", name)
+	}
+	s.names[name] = true
+	s.procs = append(s.procs, &process{name: name, Process: p})
+	return nil
+}
+
+// Run Start every process, restarting them by their policy, and call fn with the output and lifecycle events of all of
+// them in the order they were written, until every process has exited for good or ctx is done. Processes are
+// terminated as described by iomux.Run when ctx is done. The error joins the error of the last run of each process that
+// failed, prefixed with its name.
+func (s *Supervisor) Run(ctx context.Context, fn func(td *iomux.TaggedData[Tag])) error {
+	mux := iomux.NewMux[Tag](s.opts...)
+	defer mux.Close()
+	for _, p := range s.procs {
+		var err error
+		if p.stdout, err = mux.Tag(Tag{p.name, iomux.Stdout}); err != nil {
+			return err
+		}
+		defer p.stdout.Close()
+		if p.stderr, err = mux.Tag(Tag{p.name, iomux.Stderr}); err != nil {
+			return err
+		}
+		defer p.stderr.Close()
+		if p.events, err = mux.Tag(Tag{p.name, Events}); err != nil {
+			return err
+		}
+		defer p.events.Close()
+	}
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+	readCtx, cancelRead := context.WithCancel(context.Background())
+	var superviseErr error
+	go func() {
+		defer cancelRead()
+		errs := make([]error, len(s.procs))
+		var wg sync.WaitGroup
+		for i, p := range s.procs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[i] = p.supervise(ctx)
+			}()
+		}
+		wg.Wait()
+		superviseErr = errors.Join(errs...)
+	}()
+	var seq uint64
+	for {
+		data, tag, err := mux.Read(readCtx)
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			// processes may be blocked on writes that will now never be read
+			stop()
+			<-readCtx.Done()
+			return errors.Join(superviseErr, err)
+		}
+		seq++
+		fn(&iomux.TaggedData[Tag]{Data: data, Tag: tag, Seq: seq, Time: time.Now()})
+	}
+	<-readCtx.Done()
+	return superviseErr
+}
+
+// supervise runs the process until it exits for good or ctx is done.
+func (p *process) supervise(ctx context.Context) error {
+	for restarts := 0; ; restarts++ {
+		if restarts > 0 {
+			p.event(Event{Kind: Restarted, Restarts: restarts})
+		}
+		err := p.run(ctx)
+		if err != nil && errors.Is(err, ctx.Err()) {
+			return fmt.Errorf("%s: %w", p.name, err)
+		}
+		if !p.restart(err, restarts) {
+			if err != nil {
+				return fmt.Errorf("%s: %w", p.name, err)
+			}
+			return nil
+		}
+		if p.Backoff > 0 {
+			timer := time.NewTimer(p.Backoff)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return fmt.Errorf("%s: %w", p.name, ctx.Err())
+			}
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("%s: %w", p.name, ctx.Err())
+		}
+	}
+}
+
+// run runs the process once, emitting its started and exited events.
+func (p *process) run(ctx context.Context) error {
+	cmd := p.Command()
+	if cmd.Stdout != nil {
+		return errors.New("exec: Stdout already set")
+	}
+	if cmd.Stderr != nil {
+		return errors.New("exec: Stderr already set")
+	}
+	cmd.Stdout = p.stdout
+	cmd.Stderr = p.stderr
+	// emitted before starting, as the process can write before Start returns
+	p.event(Event{Kind: Started})
+	if err := cmd.Start(); err != nil {
+		p.event(Event{Kind: Exited, ExitCode: -1})
+		return err
+	}
+	err := iomux.Wait(ctx, cmd)
+	p.event(Event{Kind: Exited, PID: cmd.Process.Pid, ExitCode: cmd.ProcessState.ExitCode()})
+	return err
+}
+
+// restart reports whether the process should be restarted after a run ending with err.
+func (p *process) restart(err error, restarts int) bool {
+	if p.MaxRestarts > 0 && restarts >= p.MaxRestarts {
+		return false
+	}
+	switch p.Restart {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return err != nil
+	default:
+		return false
+	}
+}
+
+// event writes the record of e.
+func (p *process) event(e Event) {
+	text, _ := e.MarshalText()
+	p.events.Write(text)
+}
@@ -0,0 +1,128 @@
+//go:build !windows
+
+package supervisor
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/netflix/go-iomux"
+	"github.com/stretchr/testify/assert"
+)
+
+func shell(script string) func() *exec.Cmd {
+	return func() *exec.Cmd {
+		return exec.Command("sh", "-c", script)
+	}
+}
+
+// record is a simplified TaggedData, with the pids of events cleared so they can be compared.
+type record struct {
+	Tag   string
+	Data  string
+	Event Event
+}
+
+func records(t *testing.T, td []*iomux.TaggedData[Tag]) []record {
+	var result []record
+	for _, d := range td {
+		if d.Tag.Stream != Events {
+			result = append(result, record{Tag: d.Tag.String(), Data: string(d.Data)})
+			continue
+		}
+		events, err := ParseEvents(d.Data)
+		assert.Nil(t, err)
+		for _, e := range events {
+			e.PID = 0
+			result = append(result, record{Tag: d.Tag.String(), Event: e})
+		}
+	}
+	return result
+}
+
+func TestSupervisorRestartOnFailure(t *testing.T) {
+	s := New()
+	assert.Nil(t, s.Add("job", Process{Command: shell("echo trying; exit 2"), Restart: RestartOnFailure, MaxRestarts: 2}))
+	var td []*iomux.TaggedData[Tag]
+	err := s.Run(context.Background(), func(d *iomux.TaggedData[Tag]) {
+		td = append(td, d)
+	})
+	assert.EqualError(t, err, "job: exit status 2")
+	run := []record{
+		{Tag: "job:events", Event: Event{Kind: Started}},
+		{Tag: "job:stdout", Data: "trying\n"},
+		{Tag: "job:events", Event: Event{Kind: Exited, ExitCode: 2}},
+	}
+	var want []record
+	for i := 0; i < 3; i++ {
+		if i > 0 {
+			want = append(want, record{Tag: "job:events", Event: Event{Kind: Restarted, Restarts: i}})
+		}
+		want = append(want, run...)
+	}
+	assert.Equal(t, want, records(t, td))
+	for i, d := range td {
+		assert.Equal(t, uint64(i+1), d.Seq)
+	}
+}
+
+func TestSupervisorSeveral(t *testing.T) {
+	s := New()
+	assert.Nil(t, s.Add("first", Process{Command: shell("echo one")}))
+	assert.Nil(t, s.Add("second", Process{Command: shell("sleep 0.1; echo two >&2")}))
+	var got []record
+	err := s.Run(context.Background(), func(d *iomux.TaggedData[Tag]) {
+		if d.Tag.Stream != Events {
+			got = append(got, record{Tag: d.Tag.String(), Data: string(d.Data)})
+		}
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []record{{Tag: "first:stdout", Data: "one\n"}, {Tag: "second:stderr", Data: "two\n"}}, got)
+}
+
+func TestSupervisorCancel(t *testing.T) {
+	s := New()
+	assert.Nil(t, s.Add("server", Process{Command: shell("echo ready; exec sleep 10"), Restart: RestartAlways}))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	start := time.Now()
+	var got []record
+	err := s.Run(ctx, func(d *iomux.TaggedData[Tag]) {
+		if d.Tag.Stream == iomux.Stdout {
+			cancel()
+		}
+		got = append(got, records(t, []*iomux.TaggedData[Tag]{d})...)
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.Equal(t, []record{
+		{Tag: "server:events", Event: Event{Kind: Started}},
+		{Tag: "server:stdout", Data: "ready\n"},
+		{Tag: "server:events", Event: Event{Kind: Exited, ExitCode: -1}},
+	}, got)
+}
+
+func TestSupervisorAdd(t *testing.T) {
+	s := New()
+	assert.Nil(t, s.Add("job", Process{Command: shell("true")}))
+	assert.EqualError(t, s.Add("job", Process{Command: shell("true")}), `supervisor: process "job" already added`)
+	assert.EqualError(t, s.Add("other", Process{}), `supervisor: process "other" has no command`)
+}
+
+func TestSupervisorStartError(t *testing.T) {
+	s := New()
+	assert.Nil(t, s.Add("missing", Process{Command: func() *exec.Cmd {
+		return exec.Command("/nonexistent/command")
+	}}))
+	var got []record
+	err := s.Run(context.Background(), func(d *iomux.TaggedData[Tag]) {
+		got = append(got, records(t, []*iomux.TaggedData[Tag]{d})...)
+	})
+	assert.ErrorContains(t, err, "missing: fork/exec /nonexistent/command")
+	assert.Equal(t, []record{
+		{Tag: "missing:events", Event: Event{Kind: Started}},
+		{Tag: "missing:events", Event: Event{Kind: Exited, ExitCode: -1}},
+	}, got)
+}
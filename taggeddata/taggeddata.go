@@ -0,0 +1,51 @@
+// Package taggeddata provides helpers for the tagged data returned by the read methods of a mux, such as
+// iomux.Mux.ReadWhile, so consumers don't each write the same loops.
+package taggeddata
+
+import (
+	"slices"
+
+	"github.com/netflix/go-iomux"
+)
+
+// Filter Return the chunks of td for which keep returns true, in order. td is not modified.
+func Filter[T comparable](td []*iomux.TaggedData[T], keep func(*iomux.TaggedData[T]) bool) []*iomux.TaggedData[T] {
+	var result []*iomux.TaggedData[T]
+	for _, d := range td {
+		if keep(d) {
+			result = append(result, d)
+		}
+	}
+	return result
+}
+
+// ByTag Concatenate the data of td by tag, see iomux.ByTag.
+func ByTag[T comparable](td []*iomux.TaggedData[T]) map[T][]byte {
+	return iomux.ByTag(td)
+}
+
+// Concat Concatenate the data of the chunks of td tagged with tag, in order.
+func Concat[T comparable](td []*iomux.TaggedData[T], tag T) []byte {
+	var result []byte
+	for _, d := range td {
+		if d.Tag == tag {
+			result = append(result, d.Data...)
+		}
+	}
+	return result
+}
+
+// SortBySeq Sort td in place by Seq, the order the chunks were read, keeping the order of chunks with the same Seq,
+// such as those of different captures.
+func SortBySeq[T comparable](td []*iomux.TaggedData[T]) {
+	slices.SortStableFunc(td, func(a, b *iomux.TaggedData[T]) int {
+		switch {
+		case a.Seq < b.Seq:
+			return -1
+		case a.Seq > b.Seq:
+			return 1
+		default:
+			return 0
+		}
+	})
+}
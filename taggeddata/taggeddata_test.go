@@ -0,0 +1,46 @@
+package taggeddata
+
+import (
+	"testing"
+
+	"github.com/netflix/go-iomux"
+	"github.com/stretchr/testify/assert"
+)
+
+func testData() []*iomux.TaggedData[string] {
+	return []*iomux.TaggedData[string]{
+		{Tag: "out", Data: []byte("one\n"), Seq: 3},
+		{Tag: "err", Data: []byte("oops\n"), Seq: 1},
+		{Tag: "out", Data: []byte("two\n"), Seq: 2},
+	}
+}
+
+func TestFilter(t *testing.T) {
+	td := testData()
+	got := Filter(td, func(d *iomux.TaggedData[string]) bool {
+		return d.Tag == "out"
+	})
+	assert.Len(t, got, 2)
+	assert.Equal(t, "one\n", string(got[0].Data))
+	assert.Equal(t, "two\n", string(got[1].Data))
+	assert.Len(t, td, 3)
+	assert.Nil(t, Filter(td, func(*iomux.TaggedData[string]) bool {
+		return false
+	}))
+}
+
+func TestByTag(t *testing.T) {
+	assert.Equal(t, map[string][]byte{"out": []byte("one\ntwo\n"), "err": []byte("oops\n")}, ByTag(testData()))
+}
+
+func TestConcat(t *testing.T) {
+	assert.Equal(t, "one\ntwo\n", string(Concat(testData(), "out")))
+	assert.Nil(t, Concat(testData(), "missing"))
+}
+
+func TestSortBySeq(t *testing.T) {
+	td := testData()
+	SortBySeq(td)
+	assert.Equal(t, []uint64{1, 2, 3}, []uint64{td[0].Seq, td[1].Seq, td[2].Seq})
+	assert.Equal(t, "oops\n", string(td[0].Data))
+}
@@ -0,0 +1,133 @@
+// Package testreport exports output captured with a tag per test or process as JUnit XML or TAP, for test runners
+// and CI systems.
+package testreport
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/netflix/go-iomux"
+)
+
+// TestCase is the result of a test whose output was captured with a tag of its own, see WriteJUnit and WriteTAP.
+type TestCase struct {
+	Name string
+	// Err is why the test failed, or nil if it passed.
+	Err      error
+	Skipped  bool
+	Duration time.Duration
+}
+
+// testOutput is the output and result of a test.
+type testOutput struct {
+	TestCase
+	stdout, stderr []byte
+	// output is stdout and stderr in the order they were captured
+	output []byte
+}
+
+// testOutputs groups the data of td by test, in the order of cases, then of tests without a case in the order they
+// were first captured. testOf maps a tag to its test and stream, or when nil uses the tag formatted with fmt.Sprint as
+// the test name, with all output as its stdout.
+func testOutputs[T comparable](td []*iomux.TaggedData[T], cases []TestCase, testOf func(tag T) (string, iomux.StdStream)) []*testOutput {
+	if testOf == nil {
+		testOf = func(tag T) (string, iomux.StdStream) {
+			return fmt.Sprint(tag), iomux.Stdout
+		}
+	}
+	var tests []*testOutput
+	byName := make(map[string]*testOutput)
+	get := func(name string) *testOutput {
+		test, ok := byName[name]
+		if !ok {
+			test = &testOutput{TestCase: TestCase{Name: name}}
+			byName[name] = test
+			tests = append(tests, test)
+		}
+		return test
+	}
+	for _, tc := range cases {
+		get(tc.Name).TestCase = tc
+	}
+	for _, d := range td {
+		name, stream := testOf(d.Tag)
+		test := get(name)
+		if stream == iomux.Stderr {
+			test.stderr = append(test.stderr, d.Data...)
+		} else {
+			test.stdout = append(test.stdout, d.Data...)
+		}
+		test.output = append(test.output, d.Data...)
+	}
+	return tests
+}
+
+type junitSuite struct {
+	XMLName  xml.Name    `xml:"testsuite"`
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *struct{}     `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+	SystemErr string        `xml:"system-err,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+}
+
+// WriteJUnit Write td as a JUnit XML test suite called suite, with a testcase per test embedding its stdout and
+// stderr as system-out and system-err, for test runners capturing a tag per test or process. Tags are mapped to tests
+// with testOf, see TestCase, and tests are reported as passed unless cases says otherwise.
+func WriteJUnit[T comparable](w io.Writer, suite string, td []*iomux.TaggedData[T], cases []TestCase, testOf func(tag T) (test string, stream iomux.StdStream)) error {
+	s := junitSuite{Name: suite}
+	var total time.Duration
+	for _, test := range testOutputs(td, cases, testOf) {
+		c := junitCase{
+			Name:      test.Name,
+			ClassName: suite,
+			Time:      junitTime(test.Duration),
+			SystemOut: string(test.stdout),
+			SystemErr: string(test.stderr),
+		}
+		switch {
+		case test.Skipped:
+			c.Skipped = &struct{}{}
+			s.Skipped++
+		case test.Err != nil:
+			c.Failure = &junitFailure{Message: test.Err.Error()}
+			s.Failures++
+		}
+		total += test.Duration
+		s.Tests++
+		s.Cases = append(s.Cases, c)
+	}
+	s.Time = junitTime(total)
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(&s); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// junitTime formats d in seconds, as JUnit expects.
+func junitTime(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,78 @@
+package testreport
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/netflix/go-iomux"
+	"github.com/stretchr/testify/assert"
+)
+
+// testTag tags the output of a test, as a test runner would.
+type testTag struct {
+	test   string
+	stream iomux.StdStream
+}
+
+func testTagOf(tag testTag) (string, iomux.StdStream) {
+	return tag.test, tag.stream
+}
+
+var testRunData = []*iomux.TaggedData[testTag]{
+	{Tag: testTag{"TestA", iomux.Stdout}, Data: []byte("=== RUN TestA\n")},
+	{Tag: testTag{"TestB", iomux.Stdout}, Data: []byte("=== RUN TestB\n")},
+	{Tag: testTag{"TestB", iomux.Stderr}, Data: []byte("expected 1 & got <2>\n")},
+	{Tag: testTag{"TestA", iomux.Stdout}, Data: []byte("pass")},
+}
+
+var testRunCases = []TestCase{
+	{Name: "TestB", Err: errors.New("assertion failed"), Duration: 250 * time.Millisecond},
+	{Name: "TestC", Skipped: true},
+}
+
+func TestWriteJUnit(t *testing.T) {
+	var sb strings.Builder
+	assert.Nil(t, WriteJUnit(&sb, "suite", testRunData, testRunCases, testTagOf))
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<testsuite name="suite" tests="3" failures="1" skipped="1" time="0.250">
+  <testcase name="TestB" classname="suite" time="0.250">
+    <failure message="assertion failed"></failure>
+    <system-out>=== RUN TestB&#xA;</system-out>
+    <system-err>expected 1 &amp; got &lt;2&gt;&#xA;</system-err>
+  </testcase>
+  <testcase name="TestC" classname="suite" time="0.000">
+    <skipped></skipped>
+  </testcase>
+  <testcase name="TestA" classname="suite" time="0.000">
+    <system-out>=== RUN TestA&#xA;pass</system-out>
+  </testcase>
+</testsuite>
+`, sb.String())
+}
+
+func TestWriteTAP(t *testing.T) {
+	var sb strings.Builder
+	assert.Nil(t, WriteTAP(&sb, testRunData, testRunCases, testTagOf))
+	assert.Equal(t, `TAP version 13
+1..3
+not ok 1 - TestB
+  ---
+  message: "assertion failed"
+  ...
+# === RUN TestB
+# expected 1 & got <2>
+ok 2 - TestC # SKIP
+ok 3 - TestA
+# === RUN TestA
+# pass
+`, sb.String())
+}
+
+func TestWriteTAPTags(t *testing.T) {
+	td := []*iomux.TaggedData[string]{{Tag: "build", Data: []byte("ok\n")}}
+	var sb strings.Builder
+	assert.Nil(t, WriteTAP(&sb, td, nil, nil))
+	assert.Equal(t, "TAP version 13\n1..1\nok 1 - build\n# ok\n", sb.String())
+}
@@ -0,0 +1,40 @@
+package testreport
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/netflix/go-iomux"
+)
+
+// WriteTAP Write td as a TAP version 13 stream, with a test point per test followed by its output as diagnostic
+// lines, in the order it was captured. Tags are mapped to tests with testOf, see WriteJUnit.
+func WriteTAP[T comparable](w io.Writer, td []*iomux.TaggedData[T], cases []TestCase, testOf func(tag T) (test string, stream iomux.StdStream)) error {
+	bw := bufio.NewWriter(w)
+	tests := testOutputs(td, cases, testOf)
+	fmt.Fprintf(bw, "TAP version 13\n1..%d\n", len(tests))
+	for i, test := range tests {
+		status := "ok"
+		if test.Err != nil && !test.Skipped {
+			status = "not ok"
+		}
+		fmt.Fprintf(bw, "%s %d - %s", status, i+1, test.Name)
+		if test.Skipped {
+			bw.WriteString(" # SKIP")
+		}
+		bw.WriteByte('\n')
+		if status == "not ok" {
+			fmt.Fprintf(bw, "  ---\n  message: %q\n  ...\n", test.Err.Error())
+		}
+		if len(test.output) > 0 {
+			for _, line := range bytes.Split(bytes.TrimSuffix(test.output, []byte{'\n'}), []byte{'\n'}) {
+				bw.WriteString("# ")
+				bw.Write(line)
+				bw.WriteByte('\n')
+			}
+		}
+	}
+	return bw.Flush()
+}
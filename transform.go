@@ -0,0 +1,29 @@
+package iomux
+
+import "regexp"
+
+// redacted replaces data matched by Redact.
+var redacted = []byte("[REDACTED]")
+
+// transform applies the functions given by WithTransform to data received for tag, in order.
+func (mux *Mux[T]) transform(tag T, data []byte) []byte {
+	for _, fn := range mux.transforms {
+		data = fn(tag, data)
+	}
+	return data
+}
+
+// Redact A transform for WithTransform that replaces every match of patterns with "[REDACTED]", for masking tokens and
+// passwords. Matches are found within the data given to the transform, which without WithLineSplitting is each chunk
+// as it is received. A single write can be received in several chunks, as on 'unix', the default network on macOS and
+// Windows, so use WithLineSplitting to match each line whole. Secrets spanning lines are never found.
+func Redact[T comparable](patterns ...*regexp.Regexp) func(tag T, data []byte) []byte {
+	return func(tag T, data []byte) []byte {
+		for _, re := range patterns {
+			if re.Match(data) {
+				data = re.ReplaceAll(data, redacted)
+			}
+		}
+		return data
+	}
+}
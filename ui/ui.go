@@ -0,0 +1,38 @@
+// Package ui serves a web page showing the live output of a mux, with filtering by tag and text search, so the output
+// of a long running job can be followed from a shareable URL.
+package ui
+
+import (
+	_ "embed"
+	"html/template"
+	"net/http"
+
+	"github.com/netflix/go-iomux"
+	"github.com/netflix/go-iomux/httpstream"
+)
+
+// buffer is how many chunks are buffered for each viewer, which is disconnected once it falls further behind, see
+// iomux.Broadcaster.SubscribeNoWait.
+const buffer = 256
+
+//go:embed index.html
+var index string
+
+var indexTemplate = template.Must(template.New("index").Parse(index))
+
+// Handler Return an http.Handler serving a page titled title at its root, showing the chunks broadcast by b as they are
+// read, streamed over a WebSocket served at "ws" relative to it, see httpstream.WebSocketHandler. Viewers first
+// receive the chunks kept by iomux.Broadcaster.SetHistory, so set it to let viewers joining late catch up.
+func Handler[T comparable](b *iomux.Broadcaster[T], title string) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/ws", httpstream.WebSocketHandler(b, buffer, true, nil))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = indexTemplate.Execute(w, struct{ Title string }{title})
+	})
+	return mux
+}
@@ -0,0 +1,40 @@
+package ui
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/netflix/go-iomux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler(t *testing.T) {
+	mux := iomux.NewMux[string]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	server := httptest.NewServer(Handler(mux.Broadcast(), "build <42>"))
+	t.Cleanup(server.Close)
+
+	resp, err := http.Get(server.URL)
+	assert.Nil(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/html; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Contains(t, string(body), "<title>build &lt;42&gt;</title>")
+	assert.Contains(t, string(body), `new WebSocket(new URL("ws"`)
+
+	// the stream requires a WebSocket upgrade
+	resp, err = http.Get(server.URL + "/ws")
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = http.Get(server.URL + "/missing")
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,139 @@
+package iomux
+
+import (
+	"net"
+	"slices"
+	"time"
+)
+
+// addReceiver starts receiving data from conn.
+func (mux *Mux[T]) addReceiver(conn *net.UnixConn) {
+	mux.recvlock.Lock()
+	r := &receiver{batch: newRecvBatch(mux.network, mux.batchSize(), mux.bufferSize()), conn: conn}
+	if r.batch == nil {
+		r.buf = make([]byte, mux.bufferSize())
+	}
+	if mux.creds && credOOBSize > 0 {
+		_ = setPassCred(conn)
+		r.oob = make([]byte, credOOBSize)
+	}
+	mux.recvconns = append(mux.recvconns, r)
+	mux.recvlock.Unlock()
+	mux.receiversChanged()
+}
+
+// receiversChanged wakes any receive waiting on the previous connections, so it picks up the change.
+func (mux *Mux[T]) receiversChanged() {
+	mux.recvgen.Add(1)
+	select {
+	case mux.recvchanged <- struct{}{}:
+	default:
+	}
+	// interrupt blocked reads, see read
+	for _, r := range mux.receivers() {
+		_ = r.conn.SetReadDeadline(time.Now())
+	}
+}
+
+// receivers returns the connections being received from. The returned slice is never modified, so can be used without
+// holding the lock.
+func (mux *Mux[T]) receivers() []*receiver {
+	mux.recvlock.RLock()
+	defer mux.recvlock.RUnlock()
+	return mux.recvconns
+}
+
+// hasReceiver reports whether conn is being received from.
+func (mux *Mux[T]) hasReceiver(conn *net.UnixConn) bool {
+	return slices.ContainsFunc(mux.receivers(), func(r *receiver) bool {
+		return r.conn == conn
+	})
+}
+
+// forgetUntagged drops the read state of connections and the unread data of tags removed by Untag. Called when
+// receiving, so that the state is only modified by readers.
+func (mux *Mux[T]) forgetUntagged() {
+	mux.recvlock.Lock()
+	untagged, tags := mux.untagged, mux.untaggedTags
+	mux.untagged, mux.untaggedTags = nil, nil
+	mux.recvlock.Unlock()
+	for _, tag := range tags {
+		mux.pending = slices.DeleteFunc(mux.pending, func(td *TaggedData[T]) bool {
+			if td.Tag != tag {
+				return false
+			}
+			mux.stats.buffer(-len(td.Data))
+			return true
+		})
+		if mux.lines {
+			mux.splitter.flush(tag)
+		}
+	}
+	for _, conn := range untagged {
+		delete(mux.recvclosed, conn)
+		for key := range mux.recvstate {
+			if key.conn == conn {
+				delete(mux.recvstate, key)
+			}
+		}
+	}
+}
+
+// Untag Stop receiving data for tag, closing the receive end of its writers and releasing their resources, for muxes
+// that outlive the writers they are given. Data not yet read for tag is discarded, and the writers fail on their next
+// write, except on 'unixgram', where the data they write is discarded instead. Safe to call concurrently with reads.
+func (mux *Mux[T]) Untag(tag T) error {
+	if mux.isClosed() {
+		return MuxClosed
+	}
+	mux.tagmutex.Lock()
+	delete(mux.writers, tag)
+	mux.tagged = slices.DeleteFunc(mux.tagged, func(t T) bool {
+		return t == tag
+	})
+	mux.tagmutex.Unlock()
+	mux.sendmutex.Lock()
+	var conns []*net.UnixConn
+	var addrs []string
+	for addr, s := range mux.tags {
+		if s.tag == tag {
+			delete(mux.tags, addr)
+			addrs = append(addrs, addr)
+		}
+	}
+	for conn, s := range mux.conntags {
+		if s.tag == tag {
+			delete(mux.conntags, conn)
+			conns = append(conns, conn)
+		}
+	}
+	delete(mux.open, tag)
+	mux.sendmutex.Unlock()
+
+	mux.recvlock.Lock()
+	mux.recvconns = slices.DeleteFunc(slices.Clone(mux.recvconns), func(r *receiver) bool {
+		if slices.Contains(conns, r.conn) {
+			return true
+		}
+		// connection oriented receivers are identified by the address of the sender. Receivers whose sender isn't known
+		// are left alone, as they may have been accepted by a concurrent Tag that is yet to register the address.
+		if addr := r.conn.RemoteAddr(); addr != nil && slices.Contains(addrs, addr.String()) {
+			conns = append(conns, r.conn)
+			return true
+		}
+		return false
+	})
+	mux.untagged = append(mux.untagged, conns...)
+	mux.untaggedTags = append(mux.untaggedTags, tag)
+	mux.recvlock.Unlock()
+
+	for _, conn := range conns {
+		if mux.poller != nil {
+			_ = mux.poller.remove(conn)
+		}
+		conn.Close()
+		mux.removeCloser(conn)
+	}
+	mux.receiversChanged()
+	return nil
+}
@@ -0,0 +1,145 @@
+//go:build !windows
+
+package iomux
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMuxUntag(t *testing.T) {
+	for _, network := range networks {
+		t.Run(network, func(t *testing.T) {
+			mux := &Mux[string]{network: network}
+			t.Cleanup(func() {
+				mux.Close()
+			})
+			taga, err := mux.Tag("a")
+			if err != nil {
+				skipIfProtocolNotSupported(t, err)
+				assert.Nil(t, err)
+			}
+			tagb, _ := mux.Tag("b")
+			tagc, _ := mux.Tag("c")
+
+			io.WriteString(taga, "unread taga")
+			io.WriteString(tagb, "hello tagb")
+			time.Sleep(sleepDuration)
+			assert.Nil(t, mux.Untag("a"))
+			io.WriteString(taga, "untagged taga")
+			io.WriteString(tagc, "hello tagc")
+			taga.Close()
+			tagb.Close()
+			tagc.Close()
+
+			ctx, cancelFn := context.WithTimeout(context.Background(), 5*sleepDuration)
+			defer cancelFn()
+			td, err := mux.ReadUntil(ctx)
+			assert.Nil(t, err)
+			received := make(map[string]string)
+			for _, d := range td {
+				received[d.Tag] += string(d.Data)
+			}
+			assert.Equal(t, map[string]string{"b": "hello tagb", "c": "hello tagc"}, received)
+			_, ok := mux.Stats().Tags["a"]
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestMuxUntagForgetsClosers(t *testing.T) {
+	for _, network := range networks {
+		t.Run(network, func(t *testing.T) {
+			mux := &Mux[int]{network: network}
+			t.Cleanup(func() {
+				mux.Close()
+			})
+			if _, err := mux.Tag(0); err != nil {
+				skipIfProtocolNotSupported(t, err)
+				assert.Nil(t, err)
+			}
+			closers := len(mux.closers)
+			for i := 1; i <= 10; i++ {
+				w, err := mux.Tag(i)
+				assert.Nil(t, err)
+				w.Close()
+				assert.Nil(t, mux.Untag(i))
+			}
+			assert.Equal(t, closers, len(mux.closers))
+		})
+	}
+}
+
+func TestMuxTagWhileReading(t *testing.T) {
+	for _, network := range networks {
+		t.Run(network, func(t *testing.T) {
+			mux := &Mux[string]{network: network}
+			t.Cleanup(func() {
+				mux.Close()
+			})
+			taga, err := mux.Tag("a")
+			if err != nil {
+				skipIfProtocolNotSupported(t, err)
+				assert.Nil(t, err)
+			}
+
+			td, err := mux.ReadWhile(func() error {
+				io.WriteString(taga, "hello taga")
+				time.Sleep(sleepDuration)
+				for _, tag := range []string{"b", "c"} {
+					w, err := mux.Tag(tag)
+					if err != nil {
+						return err
+					}
+					io.WriteString(w, "hello tag"+tag)
+					time.Sleep(sleepDuration)
+					w.Close()
+				}
+				time.Sleep(sleepDuration)
+				return nil
+			})
+			assert.Nil(t, err)
+			received := make(map[string]string)
+			for _, d := range td {
+				received[d.Tag] += string(d.Data)
+			}
+			assert.Equal(t, map[string]string{"a": "hello taga", "b": "hello tagb", "c": "hello tagc"}, received)
+		})
+	}
+}
+
+func TestMuxUntagDuringTag(t *testing.T) {
+	for _, network := range []string{"unix", "unixpacket"} {
+		t.Run(network, func(t *testing.T) {
+			mux := &Mux[string]{network: network}
+			t.Cleanup(func() {
+				mux.Close()
+			})
+			if _, err := mux.Tag("a"); err != nil {
+				skipIfProtocolNotSupported(t, err)
+				assert.Nil(t, err)
+			}
+
+			// a connection accepted by a concurrent Tag, which has yet to register the address of its sender
+			errs := make(chan error, 1)
+			go func() {
+				errs <- mux.acceptFn()
+			}()
+			addr, err := net.ResolveUnixAddr(network, mux.address("send_pending.sock"))
+			assert.Nil(t, err)
+			conn, err := net.DialUnix(network, addr, mux.recvaddr)
+			assert.Nil(t, err)
+			defer conn.Close()
+			assert.Nil(t, <-errs)
+			assert.Equal(t, 2, len(mux.receivers()))
+
+			assert.Nil(t, mux.Untag("a"))
+			assert.Equal(t, 1, len(mux.receivers()))
+		})
+	}
+}
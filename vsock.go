@@ -0,0 +1,17 @@
+package iomux
+
+import "fmt"
+
+// VsockAddr is the address of a VM socket, see ListenVsock.
+type VsockAddr struct {
+	CID  uint32
+	Port uint32
+}
+
+func (a *VsockAddr) Network() string {
+	return "vsock"
+}
+
+func (a *VsockAddr) String() string {
+	return fmt.Sprintf("vm(%d):%d", a.CID, a.Port)
+}
@@ -0,0 +1,116 @@
+package iomux
+
+import (
+	"net"
+	"os"
+	"sync/atomic"
+
+	"golang.org/x/sys/unix"
+)
+
+// vsockListener accepts connections on an AF_VSOCK socket.
+type vsockListener struct {
+	file   *os.File
+	addr   *VsockAddr
+	closed atomic.Bool
+}
+
+// vsockConn is a connected AF_VSOCK socket.
+type vsockConn struct {
+	*os.File
+	local  *VsockAddr
+	remote *VsockAddr
+}
+
+func (c *vsockConn) LocalAddr() net.Addr {
+	return c.local
+}
+
+func (c *vsockConn) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+// ListenVsock Listen on an AF_VSOCK port of every context ID, so processes in virtual machines can connect to remote.Serve on
+// the host with DialVsock, without any network configuration. Only supported on Linux.
+func ListenVsock(port uint32) (net.Listener, error) {
+	fd, err := unix.Socket(unix.AF_VSOCK, unix.SOCK_STREAM|unix.SOCK_CLOEXEC|unix.SOCK_NONBLOCK, 0)
+	if err != nil {
+		return nil, os.NewSyscallError("socket", err)
+	}
+	if err := unix.Bind(fd, &unix.SockaddrVM{CID: unix.VMADDR_CID_ANY, Port: port}); err != nil {
+		unix.Close(fd)
+		return nil, os.NewSyscallError("bind", err)
+	}
+	if err := unix.Listen(fd, unix.SOMAXCONN); err != nil {
+		unix.Close(fd)
+		return nil, os.NewSyscallError("listen", err)
+	}
+	addr := &VsockAddr{CID: unix.VMADDR_CID_ANY, Port: port}
+	if sa, err := unix.Getsockname(fd); err == nil {
+		if vm, ok := sa.(*unix.SockaddrVM); ok {
+			addr.Port = vm.Port
+		}
+	}
+	return &vsockListener{file: os.NewFile(uintptr(fd), "vsock"), addr: addr}, nil
+}
+
+func (l *vsockListener) Accept() (net.Conn, error) {
+	raw, err := l.file.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+	var nfd int
+	var sa unix.Sockaddr
+	var acceptErr error
+	err = raw.Read(func(fd uintptr) bool {
+		nfd, sa, acceptErr = unix.Accept4(int(fd), unix.SOCK_CLOEXEC|unix.SOCK_NONBLOCK)
+		return acceptErr != unix.EAGAIN
+	})
+	if err != nil {
+		if l.closed.Load() {
+			return nil, net.ErrClosed
+		}
+		return nil, err
+	}
+	if acceptErr != nil {
+		return nil, os.NewSyscallError("accept", acceptErr)
+	}
+	remote := &VsockAddr{}
+	if vm, ok := sa.(*unix.SockaddrVM); ok {
+		remote.CID, remote.Port = vm.CID, vm.Port
+	}
+	return &vsockConn{File: os.NewFile(uintptr(nfd), "vsock"), local: l.addr, remote: remote}, nil
+}
+
+func (l *vsockListener) Close() error {
+	l.closed.Store(true)
+	return l.file.Close()
+}
+
+func (l *vsockListener) Addr() net.Addr {
+	return l.addr
+}
+
+// DialVsock Connect to port of the AF_VSOCK context ID cid, such as unix.VMADDR_CID_HOST from a virtual machine guest,
+// for use with remote.DialTagConn. Only supported on Linux.
+func DialVsock(cid, port uint32) (net.Conn, error) {
+	fd, err := unix.Socket(unix.AF_VSOCK, unix.SOCK_STREAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return nil, os.NewSyscallError("socket", err)
+	}
+	if err := unix.Connect(fd, &unix.SockaddrVM{CID: cid, Port: port}); err != nil {
+		unix.Close(fd)
+		return nil, os.NewSyscallError("connect", err)
+	}
+	if err := unix.SetNonblock(fd, true); err != nil {
+		unix.Close(fd)
+		return nil, os.NewSyscallError("setnonblock", err)
+	}
+	local := &VsockAddr{}
+	if sa, err := unix.Getsockname(fd); err == nil {
+		if vm, ok := sa.(*unix.SockaddrVM); ok {
+			local.CID, local.Port = vm.CID, vm.Port
+		}
+	}
+	return &vsockConn{File: os.NewFile(uintptr(fd), "vsock"), local: local, remote: &VsockAddr{CID: cid, Port: port}}, nil
+}
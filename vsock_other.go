@@ -0,0 +1,18 @@
+//go:build !linux
+
+package iomux
+
+import (
+	"errors"
+	"net"
+)
+
+// ListenVsock is only supported on Linux.
+func ListenVsock(port uint32) (net.Listener, error) {
+	return nil, errors.ErrUnsupported
+}
+
+// DialVsock is only supported on Linux.
+func DialVsock(cid, port uint32) (net.Conn, error) {
+	return nil, errors.ErrUnsupported
+}
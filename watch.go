@@ -0,0 +1,64 @@
+package iomux
+
+import (
+	"regexp"
+	"slices"
+)
+
+// watchWindow is how much unmatched data of a tag a watcher keeps, so matches can span chunks.
+const watchWindow = 64 * 1024
+
+type watcher[T comparable] struct {
+	pattern *regexp.Regexp
+	fn      func(Match[T])
+	buf     []byte
+}
+
+// Watch Call fn with each match of pattern in the data of tag as it is read, without consuming the data, which is still
+// returned by Read. Matches can span chunks, within the last 64KiB of unmatched data, and Before holds the data since
+// the previous match. fn is called by the goroutine calling Read, before Read returns the data, so can be used to react
+// the moment a line like "FATAL" appears, for example by cancelling a job. Returns a function that removes the watcher.
+func (mux *Mux[T]) Watch(tag T, pattern *regexp.Regexp, fn func(Match[T])) func() {
+	mux.submutex.Lock()
+	defer mux.submutex.Unlock()
+	if mux.watchers == nil {
+		mux.watchers = make(map[T][]*watcher[T])
+	}
+	w := &watcher[T]{pattern: pattern, fn: fn}
+	mux.watchers[tag] = append(mux.watchers[tag], w)
+	return func() {
+		mux.submutex.Lock()
+		defer mux.submutex.Unlock()
+		mux.watchers[tag] = slices.DeleteFunc(mux.watchers[tag], func(other *watcher[T]) bool {
+			return other == w
+		})
+	}
+}
+
+// watch passes data read for tag to its watchers.
+func (mux *Mux[T]) watch(tag T, data []byte) {
+	mux.submutex.Lock()
+	watchers := slices.Clone(mux.watchers[tag])
+	mux.submutex.Unlock()
+	for _, w := range watchers {
+		w.match(tag, data)
+	}
+}
+
+// match calls fn with each match of data appended to what is kept from earlier data.
+func (w *watcher[T]) match(tag T, data []byte) {
+	buf := append(w.buf, data...)
+	for {
+		loc := w.pattern.FindSubmatchIndex(buf)
+		if loc == nil || loc[1] == 0 {
+			break
+		}
+		w.fn(*newMatch(tag, buf, loc))
+		buf = buf[loc[1]:]
+	}
+	if len(buf) > watchWindow {
+		buf = buf[len(buf)-watchWindow:]
+	}
+	// copy what is kept, so the matches passed to fn aren't overwritten by the next data
+	w.buf = slices.Clone(buf)
+}
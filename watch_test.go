@@ -0,0 +1,66 @@
+package iomux
+
+import (
+	"context"
+	"io"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMuxWatch(t *testing.T) {
+	mux := NewMux[string]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	stdout, _ := mux.Tag("stdout")
+	stderr, _ := mux.Tag("stderr")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	var matches []Match[string]
+	mux.Watch("stderr", regexp.MustCompile(`FATAL: (.*)\n`), func(m Match[string]) {
+		matches = append(matches, m)
+		cancel()
+	})
+
+	io.WriteString(stdout, "FATAL: not watched\n")
+	io.WriteString(stderr, "warning\nFA")
+	io.WriteString(stderr, "TAL: disk full\n")
+
+	td, err := mux.ReadUntil(ctx)
+	assert.Nil(t, err)
+	// the job was cancelled long before the timeout
+	assert.ErrorIs(t, ctx.Err(), context.Canceled)
+	assert.Len(t, matches, 1)
+	assert.Equal(t, "warning\n", string(matches[0].Before))
+	assert.Equal(t, "disk full", string(matches[0].Groups[1]))
+	// watched data is still read
+	assert.Equal(t, "FATAL: not watched\n", string(td[0].Data))
+	assert.Equal(t, "warning\nFATAL: disk full\n", string(td[1].Data))
+}
+
+func TestMuxWatchRemove(t *testing.T) {
+	mux := NewMux[string]()
+	t.Cleanup(func() {
+		mux.Close()
+	})
+	stderr, _ := mux.Tag("stderr")
+	var matched int
+	remove := mux.Watch("stderr", regexp.MustCompile(`error`), func(m Match[string]) {
+		matched++
+	})
+
+	io.WriteString(stderr, "error one, error two")
+	_, _, err := mux.Read(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 2, matched)
+
+	remove()
+	io.WriteString(stderr, "error three")
+	_, _, err = mux.Read(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 2, matched)
+}
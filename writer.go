@@ -0,0 +1,116 @@
+package iomux
+
+import (
+	"io"
+	"net"
+	"os"
+	"slices"
+)
+
+// streamCopySize is the size of the writes made by TaggedWriter.ReadFrom on stream networks.
+const streamCopySize = 65536
+
+// TaggedWriter is the file returned by Tag with support for copying readers in large writes and vectored writes, see
+// TagWriter.
+type TaggedWriter struct {
+	*os.File
+	// conn shares the socket of File, so buffers are written with writev, or is nil when File isn't a socket
+	conn net.Conn
+	// size is the largest message for message oriented networks, or 0 for streams
+	size int
+}
+
+// TagWriter Create a writer for data tagged with tag T like Tag. The writer implements io.ReaderFrom, so io.Copy from
+// a large file into it makes writes as large as the network allows, rather than many small ones, and WriteBuffers
+// writes several buffers with a single writev.
+func (mux *Mux[T]) TagWriter(tag T) (*TaggedWriter, error) {
+	file, err := mux.Tag(tag)
+	if err != nil {
+		return nil, err
+	}
+	w := &TaggedWriter{File: file}
+	if mux.network != "unix" && !mux.fragmented() {
+		// each write is a message, which must fit in the receive buffer
+		w.size = mux.bufferSize()
+	}
+	// fails where the file isn't a socket, such as the pipe used by WithFragmentation or on Windows
+	if conn, err := net.FileConn(file); err == nil {
+		w.conn = conn
+	}
+	return w, nil
+}
+
+// ReadFrom writes the data read from r until io.EOF, in writes of up to the largest message for message oriented
+// networks, or 64KiB for streams. Each read is written as soon as it returns, so data from slow readers isn't held
+// back.
+func (w *TaggedWriter) ReadFrom(r io.Reader) (int64, error) {
+	size := w.size
+	if size == 0 {
+		size = streamCopySize
+	}
+	buf := make([]byte, size)
+	var total int64
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			m, writeErr := w.File.Write(buf[:n])
+			total += int64(m)
+			if writeErr != nil {
+				return total, writeErr
+			}
+		}
+		if err == io.EOF {
+			return total, nil
+		}
+		if err != nil {
+			return total, err
+		}
+	}
+}
+
+// WriteBuffers writes the contents of bufs with writev. On message oriented networks, consecutive buffers are sent
+// together as one message where they fit, and buffers too large for a message are split.
+func (w *TaggedWriter) WriteBuffers(bufs [][]byte) (int64, error) {
+	// writing consumes the buffers, which mustn't change those of the caller
+	bufs = slices.Clone(bufs)
+	var dst io.Writer = w.File
+	if w.conn != nil {
+		dst = w.conn
+	}
+	if w.size == 0 {
+		nb := net.Buffers(bufs)
+		return nb.WriteTo(dst)
+	}
+	var total int64
+	for len(bufs) > 0 {
+		var message net.Buffers
+		n := 0
+		for len(bufs) > 0 && n+len(bufs[0]) <= w.size {
+			message = append(message, bufs[0])
+			n += len(bufs[0])
+			bufs = bufs[1:]
+		}
+		if len(message) == 0 {
+			message = net.Buffers{bufs[0][:w.size]}
+			n = w.size
+			bufs[0] = bufs[0][w.size:]
+		}
+		if n == 0 {
+			continue
+		}
+		written, err := message.WriteTo(dst)
+		total += written
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// Close closes the writer.
+func (w *TaggedWriter) Close() error {
+	if w.conn != nil {
+		w.conn.Close()
+	}
+	return w.File.Close()
+}
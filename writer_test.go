@@ -0,0 +1,78 @@
+//go:build !windows
+
+package iomux
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaggedWriterReadFrom(t *testing.T) {
+	for _, network := range networks {
+		t.Run(network, func(t *testing.T) {
+			mux := &Mux[string]{network: network}
+			t.Cleanup(func() {
+				mux.Close()
+			})
+			w, err := mux.TagWriter("a")
+			if err != nil {
+				skipIfProtocolNotSupported(t, err)
+				assert.Nil(t, err)
+			}
+
+			want := strings.Repeat("0123456789abcdef", 16384)
+			var got bytes.Buffer
+			td, err := mux.ReadWhile(func() error {
+				// hide the WriterTo of strings.Reader, which io.Copy would use instead of ReadFrom
+				n, err := io.Copy(w, struct{ io.Reader }{strings.NewReader(want)})
+				assert.Equal(t, int64(len(want)), n)
+				return err
+			})
+			assert.Nil(t, err)
+			for _, d := range td {
+				got.Write(d.Data)
+			}
+			assert.Equal(t, want, got.String())
+			assert.Zero(t, mux.Stats().Truncated)
+			assert.Nil(t, w.Close())
+		})
+	}
+}
+
+func TestTaggedWriterWriteBuffers(t *testing.T) {
+	for _, network := range networks {
+		t.Run(network, func(t *testing.T) {
+			mux := &Mux[string]{network: network}
+			t.Cleanup(func() {
+				mux.Close()
+			})
+			w, err := mux.TagWriter("a")
+			if err != nil {
+				skipIfProtocolNotSupported(t, err)
+				assert.Nil(t, err)
+			}
+			defer w.Close()
+
+			large := bytes.Repeat([]byte("x"), 3*mux.bufferSize())
+			bufs := [][]byte{[]byte("hello "), []byte("world"), large}
+			n, err := w.WriteBuffers(bufs)
+			assert.Nil(t, err)
+			assert.Equal(t, int64(11+len(large)), n)
+			// the caller's buffers are left as they were
+			assert.Equal(t, "hello ", string(bufs[0]))
+
+			var got bytes.Buffer
+			for got.Len() < int(n) {
+				data, _, err := mux.Read(context.Background())
+				assert.Nil(t, err)
+				got.Write(data)
+			}
+			assert.Equal(t, "hello world"+string(large), got.String())
+		})
+	}
+}